@@ -22,9 +22,13 @@ type Expression interface {
 }
 
 // Program is a program, containing
-// a list of statements
+// a list of statements. Doc is the text of
+// the comments at the top of the file, if
+// they're separated from the first statement
+// by a blank line.
 type Program struct {
 	Statements []Statement
+	Doc        string
 }
 
 // Tree returns a tree representation of
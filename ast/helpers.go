@@ -3,6 +3,7 @@ package ast
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/Zac-Garby/pluto/token"
@@ -148,13 +149,19 @@ func makeDictTree(indent int, pairs map[Node]Node, name string) string {
 		return str + ":]"
 	}
 
+	// The pairs are sorted so that the same map always
+	// gives the same tree
+	var entries []string
+
 	for key, value := range pairs {
-		str += fmt.Sprintf("%s\n%s\n%s\n",
+		entries = append(entries, fmt.Sprintf("%s\n%s\n%s\n",
 			in(indent),
 			Tree(key, indent+1, "key"),
 			Tree(value, indent+1, "value"),
-		)
+		))
 	}
 
-	return str + in(indent) + "]"
+	sort.Strings(entries)
+
+	return str + strings.Join(entries, "") + in(indent) + "]"
 }
@@ -15,11 +15,13 @@ type (
 		Statements []Statement
 	}
 
-	// FunctionDefinition defines a function
+	// FunctionDefinition defines a function. Doc is the
+	// text of the comments on the lines just above it.
 	FunctionDefinition struct {
 		Tok     token.Token
 		Pattern []Expression
 		Body    Statement
+		Doc     string
 	}
 
 	// ReturnStatement returns an expression from a BlockStatement
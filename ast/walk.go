@@ -0,0 +1,95 @@
+package ast
+
+// Walk traverses the tree rooted at n in depth-first
+// order, calling fn for each node. If fn returns false,
+// the node's children are skipped.
+func Walk(n Node, fn func(Node) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+
+	switch node := n.(type) {
+	case *ExpressionStatement:
+		walkExpr(node.Expr, fn)
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			Walk(stmt, fn)
+		}
+	case *FunctionDefinition:
+		walkExprs(node.Pattern, fn)
+		walkStmt(node.Body, fn)
+	case *ReturnStatement:
+		walkExpr(node.Value, fn)
+	case *WhileLoop:
+		walkExpr(node.Condition, fn)
+		walkStmt(node.Body, fn)
+	case *ForLoop:
+		walkExpr(node.Init, fn)
+		walkExpr(node.Condition, fn)
+		walkExpr(node.Increment, fn)
+		walkStmt(node.Body, fn)
+	case *Tuple:
+		walkExprs(node.Value, fn)
+	case *Array:
+		walkExprs(node.Elements, fn)
+	case *Map:
+		for key, val := range node.Pairs {
+			walkExpr(key, fn)
+			walkExpr(val, fn)
+		}
+	case *BlockLiteral:
+		walkExprs(node.Params, fn)
+		walkStmt(node.Body, fn)
+	case *AssignExpression:
+		walkExpr(node.Name, fn)
+		walkExpr(node.Value, fn)
+	case *PrefixExpression:
+		walkExpr(node.Right, fn)
+	case *InfixExpression:
+		walkExpr(node.Left, fn)
+		walkExpr(node.Right, fn)
+	case *DotExpression:
+		walkExpr(node.Left, fn)
+		walkExpr(node.Right, fn)
+	case *IndexExpression:
+		walkExpr(node.Collection, fn)
+		walkExpr(node.Index, fn)
+	case *Argument:
+		walkExpr(node.Value, fn)
+	case *FunctionCall:
+		walkExprs(node.Pattern, fn)
+	case *QualifiedFunctionCall:
+		walkExpr(node.Base, fn)
+		walkExprs(node.Pattern, fn)
+	case *IfExpression:
+		walkExpr(node.Condition, fn)
+		walkStmt(node.Consequence, fn)
+		walkStmt(node.Alternative, fn)
+	case *EmissionExpression:
+		for _, item := range node.Items {
+			if !item.IsInstruction {
+				walkExpr(item.Exp, fn)
+			}
+		}
+	}
+}
+
+// walkExpr and walkStmt check for nil interface values,
+// which some nodes have for their optional children.
+func walkExpr(e Expression, fn func(Node) bool) {
+	if e != nil {
+		Walk(e, fn)
+	}
+}
+
+func walkStmt(s Statement, fn func(Node) bool) {
+	if s != nil {
+		Walk(s, fn)
+	}
+}
+
+func walkExprs(es []Expression, fn func(Node) bool) {
+	for _, e := range es {
+		walkExpr(e, fn)
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/fatih/color"
+)
+
+// benchSuffixes are the endings of benchmark file names
+var benchSuffixes = []string{"_bench.pluto", "_bench" + literate.Extension}
+
+// maxIterations limits how many times a benchmark is run
+const maxIterations = 1e9
+
+// A benchResult is printed as a line like:
+//
+//	bench sorting	2000	612345 ns/op	2048 B/op	40 allocs/op
+//
+// separated by tabs, which is what -compare reads back.
+type benchResult struct {
+	name               string
+	iterations         int
+	nsPerOp            int64
+	bytesPerOp, allocs uint64
+}
+
+func (r benchResult) String() string {
+	return fmt.Sprintf(
+		"bench %s\t%d\t%d ns/op\t%d B/op\t%d allocs/op",
+		r.name, r.iterations, r.nsPerOp, r.bytesPerOp, r.allocs,
+	)
+}
+
+// benchCommand runs the benchmarks in the given files and
+// directories, or in the current directory if none are
+// given. A benchmark is a function whose pattern starts
+// with 'bench', in a file ending in _bench.pluto:
+//
+//	def bench sorting { ... }
+//
+// Each one is run once to warm up, then repeatedly, with
+// the number of iterations growing until the benchmark
+// takes at least -benchtime. The results can be saved and
+// compared with 'pluto bench -compare old.txt new.txt'.
+func benchCommand(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	run := flags.String("run", "", "only run the benchmarks whose names match this regular expression")
+	benchtime := flags.Duration("benchtime", time.Second, "run each benchmark for at least this long")
+	compare := flags.Bool("compare", false, "compare two files of saved results, instead of running benchmarks")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto bench [-run regexp] [-benchtime d] [files or directories...]")
+		fmt.Fprintln(os.Stderr, "       pluto bench -compare <old> <new>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if *compare {
+		if flags.NArg() != 2 {
+			flags.Usage()
+			return 2
+		}
+
+		return compareBenchmarks(flags.Arg(0), flags.Arg(1))
+	}
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: invalid -run pattern: %s\n", err)
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := sourceFiles(paths, benchSuffixes...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	if len(files) == 0 {
+		fmt.Println("no benchmark files")
+		return 0
+	}
+
+	fmt.Println("B/op and allocs/op count every allocation made by pluto while a benchmark runs")
+
+	status := 0
+
+	for _, file := range files {
+		if !benchFile(file, filter, *benchtime) {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// benchFile runs the benchmarks in a file which match
+// filter, printing the results. Returns whether they all
+// ran without errors.
+func benchFile(file string, filter *regexp.Regexp, benchtime time.Duration) bool {
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return false
+	}
+
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		parse.PrintErrors()
+		color.Red("FAIL  %s  (parse error)", file)
+		return false
+	}
+
+	var (
+		start  = time.Now()
+		failed bool
+	)
+
+	for _, bench := range findTests(prog, "bench") {
+		if !filter.MatchString(bench.name) {
+			continue
+		}
+
+		result, err := runBenchmark(src, file, bench, benchtime)
+		if err != nil {
+			failed = true
+			color.Red("--- FAIL: bench %s", bench.name)
+
+			var (
+				msg  = err.Error()
+				line = bench.line
+			)
+
+			if err, ok := err.(*vm.Error); ok {
+				msg = fmt.Sprintf("%s: %s", err.Type, err.Message)
+				line = failureLine(err, file, line)
+			}
+
+			fmt.Printf("    %s:%d: %s\n", file, line, strings.Replace(msg, "\n", "\n    ", -1))
+			continue
+		}
+
+		fmt.Println(result)
+	}
+
+	if failed {
+		color.Red("FAIL  %s  (%s)", file, time.Since(start))
+		return false
+	}
+
+	fmt.Printf("ok    %s  (%s)\n", file, time.Since(start))
+	return true
+}
+
+// A benchmark is a call to a benchmark function, which can
+// be run many times in the same session.
+type benchmark struct {
+	store *store.Store
+	code  bytecode.Code
+	cmp   compiler.Compiler
+}
+
+// runBenchmark sets up a new session for a benchmark, and
+// then calls it until it's taken at least benchtime.
+func runBenchmark(src, file string, bench plutoTest, benchtime time.Duration) (benchResult, error) {
+	b := &benchmark{store: store.New()}
+
+	if _, err := execute(vm.New(), src, file, b.store, true); err != nil {
+		return benchResult{}, err
+	}
+
+	b.cmp = compiler.New()
+
+	parse := parser.New("\\bench "+bench.name, file)
+	if err := b.cmp.CompileProgram(parse.Parse()); err != nil {
+		return benchResult{}, err
+	}
+
+	code, err := b.cmp.Code()
+	if err != nil {
+		return benchResult{}, err
+	}
+
+	b.code = code
+
+	// Warm up
+	if _, _, _, err := b.run(1); err != nil {
+		return benchResult{}, err
+	}
+
+	n := 1
+
+	for {
+		elapsed, allocs, bytes, err := b.run(n)
+		if err != nil {
+			return benchResult{}, err
+		}
+
+		if elapsed >= benchtime || n >= maxIterations {
+			return benchResult{
+				name:       bench.name,
+				iterations: n,
+				nsPerOp:    elapsed.Nanoseconds() / int64(n),
+				bytesPerOp: bytes / uint64(n),
+				allocs:     allocs / uint64(n),
+			}, nil
+		}
+
+		n = nextIterations(n, elapsed, benchtime)
+	}
+}
+
+// nextIterations predicts how many iterations will take
+// benchtime, aiming a bit higher, and growing at least by
+// one and at most a hundred times.
+func nextIterations(n int, elapsed, benchtime time.Duration) int {
+	if elapsed <= 0 {
+		elapsed = 1
+	}
+
+	next := int(1.2 * float64(n) * float64(benchtime) / float64(elapsed))
+
+	if next <= n {
+		next = n + 1
+	}
+
+	if next > 100*n {
+		next = 100 * n
+	}
+
+	if next > maxIterations {
+		next = maxIterations
+	}
+
+	return next
+}
+
+// run calls the benchmark function n times, returning the
+// time taken and the number and size of the allocations.
+// The allocations are counted from runtime.MemStats, so
+// they include any made by the rest of the process.
+func (b *benchmark) run(n int) (elapsed time.Duration, allocs, bytes uint64, err error) {
+	var (
+		before, after runtime.MemStats
+		machine       = vm.New()
+	)
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+
+	for i := 0; i < n; i++ {
+		b.store.Names = b.cmp.Names
+		b.store.Patterns = b.cmp.Patterns
+
+		machine.Reset()
+		machine.Run(b.code, b.store, b.cmp.Constants, false)
+
+		if machine.Error != nil {
+			return 0, 0, 0, machine.Error
+		}
+	}
+
+	elapsed = time.Since(start)
+
+	runtime.ReadMemStats(&after)
+
+	return elapsed, after.Mallocs - before.Mallocs, after.TotalAlloc - before.TotalAlloc, nil
+}
+
+// compareBenchmarks prints the change in time and memory
+// of each benchmark in both files of results.
+func compareBenchmarks(oldFile, newFile string) int {
+	old, order, err := readBenchResults(oldFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	current, _, err := readBenchResults(newFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "name\told ns/op\tnew ns/op\tdelta\told B/op\tnew B/op\tdelta\told allocs/op\tnew allocs/op\tdelta")
+
+	for _, name := range order {
+		o, n := old[name], current[name]
+		if n == nil {
+			continue
+		}
+
+		fmt.Fprintf(
+			w, "%s\t%d\t%d\t%s\t%d\t%d\t%s\t%d\t%d\t%s\n", name,
+			o.nsPerOp, n.nsPerOp, delta(float64(o.nsPerOp), float64(n.nsPerOp)),
+			o.bytesPerOp, n.bytesPerOp, delta(float64(o.bytesPerOp), float64(n.bytesPerOp)),
+			o.allocs, n.allocs, delta(float64(o.allocs), float64(n.allocs)),
+		)
+	}
+
+	w.Flush()
+
+	return 0
+}
+
+// delta formats the change from before to after as a
+// percentage
+func delta(before, after float64) string {
+	if before == after {
+		return "~"
+	}
+
+	if before == 0 {
+		return "+inf%"
+	}
+
+	return fmt.Sprintf("%+.2f%%", 100*(after-before)/before)
+}
+
+// readBenchResults reads the results saved from 'pluto
+// bench', ignoring the other lines it prints. The names
+// of the benchmarks are also returned, in order.
+func readBenchResults(file string) (map[string]*benchResult, []string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var (
+		results = make(map[string]*benchResult)
+		order   []string
+		scanner = bufio.NewScanner(f)
+	)
+
+	for scanner.Scan() {
+		result, ok := parseBenchResult(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		if _, seen := results[result.name]; !seen {
+			order = append(order, result.name)
+		}
+
+		results[result.name] = result
+	}
+
+	return results, order, scanner.Err()
+}
+
+func parseBenchResult(line string) (*benchResult, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 5 || !strings.HasPrefix(fields[0], "bench ") {
+		return nil, false
+	}
+
+	var (
+		r   = &benchResult{name: strings.TrimPrefix(fields[0], "bench ")}
+		err error
+	)
+
+	if r.iterations, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, false
+	}
+
+	units := []struct {
+		field string
+		scan  interface{}
+	}{
+		{fields[2], &r.nsPerOp},
+		{fields[3], &r.bytesPerOp},
+		{fields[4], &r.allocs},
+	}
+
+	for _, unit := range units {
+		if _, err := fmt.Sscan(unit.field, unit.scan); err != nil {
+			return nil, false
+		}
+	}
+
+	return r, true
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBenchResult(t *testing.T) {
+	result := benchResult{name: "sorting lists", iterations: 2000, nsPerOp: 612345, bytesPerOp: 2048, allocs: 40}
+
+	parsed, ok := parseBenchResult(result.String())
+	if !ok || !reflect.DeepEqual(*parsed, result) {
+		t.Errorf("expected %q to be read back as %+v, got %+v", result.String(), result, parsed)
+	}
+
+	for _, line := range []string{
+		"",
+		"ok    sort_bench.pluto  (1.2s)",
+		"--- FAIL: bench sorting",
+		"bench sorting\t2000\t612345 ns/op\t2048 B/op",
+		"sorting\t2000\t612345 ns/op\t2048 B/op\t40 allocs/op",
+		"bench sorting\tmany\t612345 ns/op\t2048 B/op\t40 allocs/op",
+		"bench sorting\t2000\tslow ns/op\t2048 B/op\t40 allocs/op",
+		"bench sorting\t2000\t612345 ns/op\t-1 B/op\t40 allocs/op",
+	} {
+		if _, ok := parseBenchResult(line); ok {
+			t.Errorf("expected %q not to be a result", line)
+		}
+	}
+}
+
+func TestNextIterations(t *testing.T) {
+	tests := []struct {
+		n                  int
+		elapsed, benchtime time.Duration
+		next               int
+	}{
+		{1, time.Millisecond, time.Second, 100},
+		{100, 500 * time.Millisecond, time.Second, 240},
+		{100, time.Second, time.Second, 120},
+		{100, 2 * time.Second, time.Second, 101},
+		{10, 0, time.Second, 1000},
+		{maxIterations / 2, time.Millisecond, time.Second, maxIterations},
+	}
+
+	for _, test := range tests {
+		if next := nextIterations(test.n, test.elapsed, test.benchtime); next != test.next {
+			t.Errorf("nextIterations(%d, %s, %s): expected %d, got %d", test.n, test.elapsed, test.benchtime, test.next, next)
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		before, after float64
+		delta         string
+	}{
+		{100, 100, "~"},
+		{0, 0, "~"},
+		{0, 5, "+inf%"},
+		{100, 150, "+50.00%"},
+		{200, 100, "-50.00%"},
+		{3, 4, "+33.33%"},
+	}
+
+	for _, test := range tests {
+		if d := delta(test.before, test.after); d != test.delta {
+			t.Errorf("delta(%v, %v): expected %q, got %q", test.before, test.after, test.delta, d)
+		}
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	var (
+		old = []benchResult{
+			{name: "sorting", iterations: 2000, nsPerOp: 1000, bytesPerOp: 2048, allocs: 40},
+			{name: "searching", iterations: 100, nsPerOp: 500, bytesPerOp: 0, allocs: 0},
+			{name: "removed", iterations: 1, nsPerOp: 1, bytesPerOp: 1, allocs: 1},
+		}
+
+		current = []benchResult{
+			{name: "searching", iterations: 100, nsPerOp: 500, bytesPerOp: 16, allocs: 1},
+			{name: "sorting", iterations: 4000, nsPerOp: 500, bytesPerOp: 2048, allocs: 30},
+			{name: "added", iterations: 1, nsPerOp: 1, bytesPerOp: 1, allocs: 1},
+		}
+	)
+
+	// The saved output has lines which aren't results, and
+	// a benchmark which was run twice, of which the last
+	// result is used
+	save := func(results []benchResult) string {
+		lines := []string{"B/op and allocs/op count every allocation made by pluto while a benchmark runs"}
+
+		for _, r := range results {
+			lines = append(lines, r.String())
+		}
+
+		lines = append(lines, "ok    list_bench.pluto  (2.5s)")
+
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"old.txt": "bench sorting\t1\t9999 ns/op\t1 B/op\t1 allocs/op\n" + save(old),
+		"new.txt": save(current),
+	})
+
+	results, order, err := readBenchResults(filepath.Join(dir, "old.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(order, []string{"sorting", "searching", "removed"}) {
+		t.Errorf("expected the benchmarks in the order they were saved, got %v", order)
+	}
+
+	if !reflect.DeepEqual(*results["sorting"], old[0]) {
+		t.Errorf("expected the last result for sorting, %+v, got %+v", old[0], *results["sorting"])
+	}
+
+	var status int
+
+	out := capture(t, func() {
+		status = benchCommand([]string{"-compare", filepath.Join(dir, "old.txt"), filepath.Join(dir, "new.txt")})
+	})
+
+	if status != 0 {
+		t.Errorf("expected the exit status 0, got %d", status)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	expected := [][]string{
+		{"name", "old", "ns/op", "new", "ns/op", "delta", "old", "B/op", "new", "B/op", "delta", "old", "allocs/op", "new", "allocs/op", "delta"},
+		{"sorting", "1000", "500", "-50.00%", "2048", "2048", "~", "40", "30", "-25.00%"},
+		{"searching", "500", "500", "~", "0", "16", "+inf%", "0", "1", "+inf%"},
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got:\n%s", len(expected), out)
+	}
+
+	for i, line := range lines {
+		if fields := strings.Fields(line); !reflect.DeepEqual(fields, expected[i]) {
+			t.Errorf("expected the line %v, got %v", expected[i], fields)
+		}
+	}
+}
+
+func TestRunBenchmark(t *testing.T) {
+	setup(t, nil)
+
+	src := "def bench adding {\n    1 + 2\n}\n\ndef bench failing {\n    <\"broken\", FAIL>\n}\n"
+
+	result, err := runBenchmark(src, "adding_bench.pluto", plutoTest{"adding", 1}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.name != "adding" || result.iterations < 1 || result.nsPerOp <= 0 {
+		t.Errorf("expected a result for adding, got %+v", result)
+	}
+
+	if _, err := runBenchmark(src, "adding_bench.pluto", plutoTest{"failing", 5}, time.Millisecond); err == nil {
+		t.Error("expected failing to fail")
+	}
+}
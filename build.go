@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/transpile"
+)
+
+// buildCommand transpiles a program to Go, with --go, and
+// builds it into a standalone executable with the local Go
+// toolchain. The prelude and the packages the program uses
+// are transpiled into it, so it doesn't need $PLUTO to run.
+func buildCommand(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	var (
+		goBackend = flags.Bool("go", false, "transpile the program to Go, and build it with the Go toolchain")
+		out       = flags.String("o", "", "write the executable to `file`, instead of the program's name")
+		emit      = flags.Bool("emit", false, "write the Go source to the output, instead of building it")
+		src       = flags.String("src", "", "build with pluto's source in `dir` (default: found by 'go list')")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto build --go [-o file] [-emit] [-src dir] <file>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || !*goBackend {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+
+	path := *out
+	if path == "" {
+		path = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		if *emit {
+			path += ".go"
+		}
+	}
+
+	if err := build(file, path, *src, *emit); err != nil {
+		if err != errParse {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+func build(file, path, src string, emit bool) error {
+	text, err := literate.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	parse := parser.New(text, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		parse.PrintErrors()
+		return errParse
+	}
+
+	code, err := transpile.Program(prog)
+	if err != nil {
+		return err
+	}
+
+	if emit {
+		return ioutil.WriteFile(path, code, 0644)
+	}
+
+	if src == "" {
+		if src, err = transpile.Source(); err != nil {
+			return err
+		}
+	}
+
+	return transpile.Build(code, src, path)
+}
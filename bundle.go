@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/fatih/color"
+)
+
+// bundleCommand compiles a program, with the prelude and
+// every package it uses, and appends them to a copy of
+// this executable. The result runs the program, passing it
+// its arguments, without needing $PLUTO or any sources. It
+// runs on the same platform as the pluto it was made with.
+func bundleCommand(args []string) int {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	out := flags.String("o", "", "write the executable to `file`, instead of the program's name")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto bundle [-o file] <file>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+
+	path := *out
+	if path == "" {
+		path = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	if err := bundle(file, path); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func bundle(file, path string) error {
+	main, err := loadModule(file)
+	if err != nil {
+		return err
+	}
+
+	b, err := module.NewBundle(main)
+	if err != nil {
+		return err
+	}
+
+	runtime, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	return b.WriteExecutable(runtime, path)
+}
+
+// bundled returns the bundle appended to this executable,
+// or nil if it's just pluto
+func bundled() (*module.Bundle, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil
+	}
+
+	return module.ReadExecutable(exe)
+}
+
+// runBundle runs a bundled program, with its packages, as
+// 'pluto run' would run it
+func runBundle(b *module.Bundle, args []string) int {
+	var (
+		store   = store.New()
+		machine = vm.New()
+	)
+
+	store.Define("args", stringArray(args), false)
+	machine.Packages = b.Packages
+
+	if _, err := runModule(machine, b.Main, store, true); err != nil {
+		color.New(color.FgRed).Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+
+	return machine.ExitCode
+}
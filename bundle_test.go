@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/module"
+)
+
+// A bundle has the packages locked by the program's
+// project, even if it's made from somewhere else
+func TestBundleFromOtherDirectory(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"project/pluto.lock": `{"packages": {"maths": {"version": "1.0.0", "checksum": ""}}}`,
+		"project/main.pluto": "use \"maths\"\n\nprint (\\answer)\n",
+	})
+
+	writeFiles(t, filepath.Join(os.Getenv("PLUTO"), "packages"), map[string]string{
+		"maths/maths.pluto":       "def answer { return 0 }\n",
+		"maths@1.0.0/maths.pluto": "def answer { return 42 }\n",
+	})
+
+	chdir(t, dir)
+
+	exe := filepath.Join(t.TempDir(), "app")
+
+	if status := bundleCommand([]string{"-o", exe, "project/main.pluto"}); status != 0 {
+		t.Fatalf("expected the exit status 0, got %d", status)
+	}
+
+	b, err := module.ReadExecutable(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b == nil {
+		t.Fatal("expected a bundle in the executable")
+	}
+
+	// The packages are bundled, so they aren't needed
+	t.Setenv("PLUTO", t.TempDir())
+
+	var status int
+
+	out := capture(t, func() {
+		status = runBundle(b, nil)
+	})
+
+	if status != 0 || strings.TrimSpace(out) != "42" {
+		t.Errorf("expected the locked version of maths to be bundled, got the status %d and the output %q", status, out)
+	}
+}
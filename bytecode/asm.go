@@ -0,0 +1,453 @@
+package bytecode
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// Assemble assembles bytecode written as text, which is
+// the syntax Disassemble writes. Each line is one of:
+//
+//	LOAD_CONST ("foo")    an instruction and its argument
+//	loop:                 a label, which jumps can refer to
+//	.const 0 "foo"        a constant, name or pattern, with
+//	.name x               an optional index
+//	.pattern print $
+//	.def add $a to $b     the start of a function, or a
+//	.block 2 |x, y|       block in constant 2, which are
+//	.end                  ended by .end
+//	.main file.pluto      the main unit's file name
+//
+// Blank lines, and lines starting with '#' or ';', are
+// ignored. An argument is a number, or an operand in
+// parentheses: a constant, name or pattern which is added
+// to its table if it isn't there yet, or a jump's label. If
+// both are given, the operand is put at that index in its
+// table. Numbers before the instruction are its source
+// position, which is a line and an optional column such as
+// 12:5, and its byte offset, as disassembled. The offset is
+// ignored, since it's worked out again.
+func Assemble(src, file string) (Unit, error) {
+	a := &assembler{}
+	main := &asmUnit{Unit: Unit{Kind: UnitMain, Name: file}}
+	a.units = []*asmUnit{main}
+
+	for i, line := range strings.Split(src, "\n") {
+		a.lineNo = i + 1
+
+		if err := a.line(strings.TrimSpace(line)); err != nil {
+			return Unit{}, fmt.Errorf("%s:%d: %s", file, a.lineNo, err)
+		}
+	}
+
+	if len(a.units) > 1 {
+		return Unit{}, fmt.Errorf("%s: .%s %s has no .end", file, a.top().Kind, a.top().Name)
+	}
+
+	if err := main.finish(); err != nil {
+		return Unit{}, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return main.Unit, nil
+}
+
+var (
+	labelRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*:$`)
+	positionRegexp = regexp.MustCompile(`^[0-9]+(:[0-9]+)?$`)
+	opcodes        = make(map[string]byte)
+)
+
+func init() {
+	for code, data := range Instructions {
+		opcodes[data.Name] = code
+	}
+}
+
+type assembler struct {
+	lineNo int
+
+	// units are the units being assembled, the innermost
+	// last
+	units []*asmUnit
+}
+
+// asmUnit is a unit being assembled
+type asmUnit struct {
+	Unit
+
+	// line and col are the source position of the next
+	// instruction
+	line, col int
+
+	labels map[string]int
+	jumps  []jump
+}
+
+// jump is a jump to a label, whose offset isn't known until
+// the end of the unit
+type jump struct {
+	index  int
+	label  string
+	lineNo int
+}
+
+func (a *assembler) top() *asmUnit {
+	return a.units[len(a.units)-1]
+}
+
+func (a *assembler) line(line string) error {
+	switch {
+	case line == "" || line[0] == '#' || line[0] == ';':
+		return nil
+	case line[0] == '.':
+		return a.directive(line)
+	case labelRegexp.MatchString(line):
+		return a.top().label(strings.TrimSuffix(line, ":"))
+	}
+
+	return a.instruction(line)
+}
+
+func (a *assembler) directive(line string) error {
+	var (
+		name, rest = split(line[1:])
+		u          = a.top()
+	)
+
+	switch name {
+	case "main":
+		if len(a.units) > 1 {
+			return fmt.Errorf(".main inside .%s", u.Kind)
+		}
+
+		u.Name = rest
+
+	case "def":
+		if rest == "" {
+			return fmt.Errorf(".def needs a pattern")
+		}
+
+		a.units = append(a.units, &asmUnit{
+			Unit: Unit{Kind: UnitFunction, Name: rest, Index: -1},
+			line: u.line,
+			col:  u.col,
+		})
+
+	case "block":
+		index, rest := splitIndex(rest)
+
+		if len(rest) < 2 || rest[0] != '|' || rest[len(rest)-1] != '|' {
+			return fmt.Errorf(".block needs its parameters, such as |x, y|")
+		}
+
+		a.units = append(a.units, &asmUnit{
+			Unit: Unit{Kind: UnitBlock, Name: strings.TrimSpace(rest[1 : len(rest)-1]), Index: index},
+			line: u.line,
+			col:  u.col,
+		})
+
+	case "end":
+		if len(a.units) == 1 {
+			return fmt.Errorf(".end without .def or .block")
+		}
+
+		a.units = a.units[:len(a.units)-1]
+		return a.top().nest(u)
+
+	case "const", "name", "pattern":
+		index, value := splitIndex(rest)
+
+		// A single number is a value, not an index
+		if value == "" {
+			index, value = -1, rest
+		}
+
+		if value == "" {
+			return fmt.Errorf(".%s needs a value", name)
+		}
+
+		table := map[string]*[]string{
+			"const":   &u.Constants,
+			"name":    &u.Names,
+			"pattern": &u.Patterns,
+		}[name]
+
+		_, err := define(table, index, value)
+		return err
+
+	default:
+		return fmt.Errorf("unknown directive .%s", name)
+	}
+
+	return nil
+}
+
+func (a *assembler) instruction(line string) error {
+	var (
+		u       = a.top()
+		fields  = strings.Fields(line)
+		numbers []string
+	)
+
+	// The source position and byte offset, if they're given
+	for len(fields) > 0 && positionRegexp.MatchString(fields[0]) {
+		numbers = append(numbers, fields[0])
+		fields = fields[1:]
+	}
+
+	if len(numbers) > 2 || len(fields) == 0 {
+		return fmt.Errorf("expected an instruction")
+	}
+
+	if len(numbers) == 2 {
+		if strings.Contains(numbers[1], ":") {
+			return fmt.Errorf("expected a byte offset, got %s", numbers[1])
+		}
+
+		u.line, u.col = position(numbers[0])
+	}
+
+	name := fields[0]
+
+	code, ok := opcodes[name]
+	if !ok {
+		return fmt.Errorf("unknown instruction %s", name)
+	}
+
+	instr := Instruction{
+		Code: code,
+		Name: name,
+		Pos:  token.Position{File: a.units[0].Name, Line: u.line, Column: u.col},
+	}
+
+	// The rest is taken from the line, so the spaces in
+	// strings are kept
+	rest := strings.TrimSpace(line[strings.Index(line, name)+len(name):])
+
+	if !Instructions[code].HasArg {
+		if rest != "" {
+			return fmt.Errorf("%s doesn't take an argument", name)
+		}
+
+		u.Code = append(u.Code, instr)
+		return nil
+	}
+
+	var err error
+
+	arg, operand := splitIndex(rest)
+
+	if strings.HasPrefix(operand, "(") && strings.HasSuffix(operand, ")") {
+		operand = strings.TrimSpace(operand[1 : len(operand)-1])
+	}
+
+	if arg < 0 && operand == "" {
+		return fmt.Errorf("%s needs an argument", name)
+	}
+
+	switch code {
+	case LoadConst, Use:
+		// A block is defined by .block, so its description
+		// isn't a value
+		if !strings.HasPrefix(operand, "<") {
+			arg, err = define(&u.Constants, arg, operand)
+		} else if arg < 0 {
+			return fmt.Errorf("a block constant needs its index")
+		}
+
+	case LoadName, StoreName:
+		arg, err = define(&u.Names, arg, operand)
+
+	case PushFn, PushQualFn:
+		arg, err = define(&u.Patterns, arg, operand)
+
+	case Jump, JumpIfTrue, JumpIfFalse:
+		if operand != "" {
+			u.jumps = append(u.jumps, jump{
+				index:  len(u.Code),
+				label:  strings.TrimSpace(strings.TrimPrefix(operand, "to ")),
+				lineNo: a.lineNo,
+			})
+		}
+
+	default:
+		if arg < 0 {
+			return fmt.Errorf("%s needs a number", name)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if arg > 0xFFFF {
+		return fmt.Errorf("argument %d is greater than 0xFFFF", arg)
+	}
+
+	if arg >= 0 {
+		instr.Arg = rune(arg)
+	}
+
+	u.Code = append(u.Code, instr)
+
+	return nil
+}
+
+func (u *asmUnit) label(name string) error {
+	if u.labels == nil {
+		u.labels = make(map[string]int)
+	}
+
+	if _, ok := u.labels[name]; ok {
+		return fmt.Errorf("label %s is already defined", name)
+	}
+
+	u.labels[name] = ByteLength(u.Code)
+
+	return nil
+}
+
+// nest adds a finished unit to u
+func (u *asmUnit) nest(nested *asmUnit) error {
+	if err := nested.finish(); err != nil {
+		return fmt.Errorf(".%s %s: %s", nested.Kind, nested.Name, err)
+	}
+
+	if nested.Kind == UnitBlock {
+		index, err := define(&u.Constants, nested.Index, fmt.Sprintf("<block |%s|>", nested.Name))
+		if err != nil {
+			return err
+		}
+
+		nested.Index = index
+	}
+
+	u.Units = append(u.Units, nested.Unit)
+
+	return nil
+}
+
+// finish resolves the unit's jumps to labels, and checks
+// that everything its instructions refer to is defined
+func (u *asmUnit) finish() error {
+	for _, j := range u.jumps {
+		offset, ok := u.labels[j.label]
+		if !ok {
+			return fmt.Errorf("line %d: undefined label %s", j.lineNo, j.label)
+		}
+
+		u.Code[j.index].Arg = rune(offset)
+	}
+
+	tables := []struct {
+		name  string
+		items []string
+	}{
+		{"constant", u.Constants},
+		{"name", u.Names},
+		{"pattern", u.Patterns},
+	}
+
+	for _, table := range tables {
+		for i, item := range table.items {
+			if item == "" {
+				return fmt.Errorf("%s %d is used, but not defined", table.name, i)
+			}
+		}
+	}
+
+	for _, instr := range u.Code {
+		var items []string
+
+		switch instr.Code {
+		case LoadConst, Use:
+			items = u.Constants
+		case LoadName, StoreName:
+			items = u.Names
+		case PushFn, PushQualFn:
+			items = u.Patterns
+		default:
+			continue
+		}
+
+		if int(instr.Arg) >= len(items) {
+			return fmt.Errorf("%s refers to %d, which isn't defined", instr.Name, instr.Arg)
+		}
+	}
+
+	return nil
+}
+
+// define puts a value in a table at an index, or if the
+// index is negative, finds it or adds it to the end. It
+// returns the value's index.
+func define(table *[]string, index int, value string) (int, error) {
+	// An argument can't refer to anything past 0xFFFF, and
+	// the table would be grown up to the index
+	if index > 0xFFFF {
+		return 0, fmt.Errorf("index %d is greater than 0xFFFF", index)
+	}
+
+	if index < 0 {
+		for i, item := range *table {
+			if item == value {
+				return i, nil
+			}
+		}
+
+		*table = append(*table, value)
+		return len(*table) - 1, nil
+	}
+
+	for len(*table) <= index {
+		*table = append(*table, "")
+	}
+
+	if existing := (*table)[index]; existing != "" && existing != value {
+		return 0, fmt.Errorf("%d is already defined as %s", index, existing)
+	}
+
+	(*table)[index] = value
+
+	return index, nil
+}
+
+// position parses a source position, such as 12:5 or 12
+func position(s string) (line, col int) {
+	parts := strings.SplitN(s, ":", 2)
+	line, _ = strconv.Atoi(parts[0])
+
+	if len(parts) == 2 {
+		col, _ = strconv.Atoi(parts[1])
+	}
+
+	return line, col
+}
+
+// splitIndex splits an optional index from the start of s,
+// returning -1 if there isn't one
+func splitIndex(s string) (int, string) {
+	first, rest := split(s)
+
+	n, err := strconv.Atoi(first)
+	if err != nil || n < 0 {
+		return -1, s
+	}
+
+	return n, rest
+}
+
+// split splits s into its first word, and the rest
+func split(s string) (string, string) {
+	s = strings.TrimSpace(s)
+
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+
+	return s, ""
+}
@@ -0,0 +1,216 @@
+package bytecode
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The kinds of unit
+const (
+	UnitMain     = "main"
+	UnitFunction = "def"
+	UnitBlock    = "block"
+)
+
+// Unit is some code to disassemble or which has been
+// assembled, with the data its instructions' arguments
+// refer to. Since this package doesn't know about objects,
+// each constant is given as its source text, such as "1.5"
+// or "\"foo\"", or "<block>" for a block.
+type Unit struct {
+	// Kind is the kind of unit, which is UnitMain if it's
+	// empty. Name is the main unit's
+	// file, a function's pattern, such as "add $a to $b",
+	// or a block's parameters, such as "x, y".
+	Kind string
+	Name string
+
+	// Index is the index of a block in the constants of
+	// the unit it's defined in
+	Index int
+
+	Code      Code
+	Constants []string
+	Names     []string
+	Patterns  []string
+
+	// Units are the functions and blocks defined in the
+	// unit, which are disassembled after it
+	Units []Unit
+}
+
+// Disassemble writes a listing of a unit's instructions,
+// followed by the listings of its nested units. Each line
+// has the source position, such as 12:5, if it's changed,
+// the instruction's byte offset, its name, and its argument
+// resolved to what it refers to. The targets of jumps are
+// labelled.
+//
+// The listing is valid assembly, which Assemble turns back
+// into the same code.
+func Disassemble(w io.Writer, u Unit) error {
+	var b strings.Builder
+
+	u.disassemble(&b)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (u Unit) disassemble(b *strings.Builder) {
+	switch u.Kind {
+	case UnitFunction:
+		fmt.Fprintf(b, ".def %s\n", u.Name)
+	case UnitBlock:
+		fmt.Fprintf(b, ".block %d |%s|\n", u.Index, u.Name)
+	default:
+		fmt.Fprintf(b, ".main %s\n", u.Name)
+	}
+
+	var (
+		offsets = Offsets(u.Code)
+		labels  = u.labels(offsets)
+		line    = -1
+		col     = -1
+	)
+
+	for i, instr := range u.Code {
+		if label, ok := labels[offsets[i]]; ok {
+			fmt.Fprintf(b, "%s:\n", label)
+		}
+
+		position := ""
+		if instr.Pos.Line != line || instr.Pos.Column != col {
+			line, col = instr.Pos.Line, instr.Pos.Column
+			position = fmt.Sprint(line)
+
+			if col != 0 {
+				position += fmt.Sprintf(":%d", col)
+			}
+		}
+
+		if !Instructions[instr.Code].HasArg {
+			fmt.Fprintf(b, "%8s %6d  %s\n", position, offsets[i], instr.Name)
+			continue
+		}
+
+		fmt.Fprintf(b, "%8s %6d  %-16s %5d", position, offsets[i], instr.Name, instr.Arg)
+
+		if operand := u.operand(instr, labels); operand != "" {
+			fmt.Fprintf(b, " (%s)", operand)
+		}
+
+		b.WriteString("\n")
+	}
+
+	if label, ok := labels[ByteLength(u.Code)]; ok {
+		fmt.Fprintf(b, "%s:\n", label)
+	}
+
+	for _, nested := range u.Units {
+		b.WriteString("\n")
+		nested.disassemble(b)
+	}
+
+	if u.Kind == UnitFunction || u.Kind == UnitBlock {
+		b.WriteString(".end\n")
+	}
+}
+
+// labels names the targets of the jumps in the code, in
+// order of their offsets
+func (u Unit) labels(offsets []int) map[int]string {
+	var (
+		labels  = make(map[int]string)
+		targets = make(map[int]bool)
+	)
+
+	for _, instr := range u.Code {
+		if isJump(instr.Code) {
+			targets[int(instr.Arg)] = true
+		}
+	}
+
+	// The code's length is a valid target, meaning the end
+	for _, offset := range append(offsets, ByteLength(u.Code)) {
+		if targets[offset] {
+			labels[offset] = fmt.Sprintf("L%d", len(labels)+1)
+		}
+	}
+
+	return labels
+}
+
+// operand returns what an instruction's argument refers to,
+// or an empty string if it's just a number
+func (u Unit) operand(instr Instruction, labels map[int]string) string {
+	index := int(instr.Arg)
+
+	lookup := func(items []string) string {
+		if index < len(items) {
+			return items[index]
+		}
+
+		return "?"
+	}
+
+	switch instr.Code {
+	case LoadConst, Use:
+		return lookup(u.Constants)
+	case LoadName, StoreName:
+		return lookup(u.Names)
+	case PushFn, PushQualFn:
+		return lookup(u.Patterns)
+	case Jump, JumpIfTrue, JumpIfFalse:
+		if label, ok := labels[index]; ok {
+			return "to " + label
+		}
+
+		return "to ?"
+	}
+
+	return ""
+}
+
+func isJump(code byte) bool {
+	return code == Jump || code == JumpIfTrue || code == JumpIfFalse
+}
+
+// Offsets returns the byte offset of each instruction in
+// some code, which is what jumps refer to
+func Offsets(code Code) []int {
+	var (
+		offsets = make([]int, len(code))
+		offset  int
+	)
+
+	for i, instr := range code {
+		offsets[i] = offset
+		offset += instr.Size()
+	}
+
+	return offsets
+}
+
+// ByteLength returns the number of bytes some code takes
+// up, as raw bytecode
+func ByteLength(code Code) int {
+	length := 0
+
+	for _, instr := range code {
+		length += instr.Size()
+	}
+
+	return length
+}
+
+// Size returns the number of bytes the instruction takes
+// up, as raw bytecode
+func (i Instruction) Size() int {
+	if Instructions[i.Code].HasArg {
+		return 3
+	}
+
+	return 1
+}
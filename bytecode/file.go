@@ -0,0 +1,318 @@
+package bytecode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// Magic is the start of every compiled bytecode file
+const Magic = "\x7fPLC"
+
+// Version is the version of the compiled file format which
+// is written. Files with a different version can't be read,
+// and need to be compiled again.
+const Version = 1
+
+// maxLen is the longest list which can be read. Anything
+// longer is from a corrupt file.
+const maxLen = 1 << 24
+
+// ErrNotCompiled is returned by ReadHeader if a file doesn't
+// start with Magic
+var ErrNotCompiled = errors.New("bytecode: not a compiled file")
+
+// A Writer writes the values which make up a compiled file.
+// Integers are written as varints, and strings are prefixed
+// by their lengths. The first error is kept, and returned
+// by Flush, so writes don't need to be checked separately.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+
+	// file is the file of the last position written, so it
+	// isn't repeated for each instruction
+	file string
+}
+
+// NewWriter makes a Writer which writes to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// WriteHeader writes the magic bytes and the version
+func (w *Writer) WriteHeader() {
+	w.write([]byte(Magic))
+	w.Uint(Version)
+}
+
+func (w *Writer) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+
+// Byte writes a single byte
+func (w *Writer) Byte(b byte) {
+	w.write([]byte{b})
+}
+
+// Uint writes an unsigned integer
+func (w *Writer) Uint(n uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	w.write(buf[:binary.PutUvarint(buf, n)])
+}
+
+// Int writes a signed integer
+func (w *Writer) Int(n int64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	w.write(buf[:binary.PutVarint(buf, n)])
+}
+
+// Float writes a float64, exactly
+func (w *Writer) Float(f float64) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+	w.write(buf)
+}
+
+// String writes a string
+func (w *Writer) String(s string) {
+	w.Uint(uint64(len(s)))
+	w.write([]byte(s))
+}
+
+// Strings writes a list of strings
+func (w *Writer) Strings(strs []string) {
+	w.Uint(uint64(len(strs)))
+
+	for _, s := range strs {
+		w.String(s)
+	}
+}
+
+// Code writes some instructions with their arguments and
+// positions. The instructions' names aren't written, since
+// they're known from their opcodes.
+func (w *Writer) Code(code Code) {
+	w.Uint(uint64(len(code)))
+
+	for _, instr := range code {
+		w.Byte(instr.Code)
+
+		if Instructions[instr.Code].HasArg {
+			w.Uint(uint64(instr.Arg))
+		}
+
+		w.position(instr.Pos)
+	}
+}
+
+// position writes a position. The file is only written if
+// it's different to the last one, since it rarely is.
+func (w *Writer) position(pos token.Position) {
+	if pos.File == w.file {
+		w.Uint(0)
+	} else {
+		w.Uint(1)
+		w.String(pos.File)
+		w.file = pos.File
+	}
+
+	w.Uint(uint64(pos.Line))
+	w.Uint(uint64(pos.Column))
+}
+
+// Flush writes any buffered data, returning the first error
+// which happened while writing
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	return w.w.Flush()
+}
+
+// A Reader reads the values written by a Writer. Like the
+// Writer, it keeps the first error, which is returned by
+// Err, and reads zero values after an error.
+type Reader struct {
+	r    *bufio.Reader
+	err  error
+	file string
+}
+
+// NewReader makes a Reader which reads from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadHeader checks the magic bytes and the version
+func (r *Reader) ReadHeader() error {
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(r.r, magic); err != nil || string(magic) != Magic {
+		return ErrNotCompiled
+	}
+
+	if version := r.Uint(); r.err == nil && version != Version {
+		return fmt.Errorf("bytecode: compiled file version %d, expected %d", version, Version)
+	}
+
+	return r.err
+}
+
+// Err returns the first error which happened while reading
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return r.err
+}
+
+// Fail records an error, if there hasn't already been
+// one, so that nothing more is read
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Byte reads a single byte
+func (r *Reader) Byte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	b, err := r.r.ReadByte()
+	r.Fail(err)
+
+	return b
+}
+
+// Uint reads an unsigned integer
+func (r *Reader) Uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	n, err := binary.ReadUvarint(r.r)
+	r.Fail(err)
+
+	return n
+}
+
+// Int reads a signed integer
+func (r *Reader) Int() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	n, err := binary.ReadVarint(r.r)
+	r.Fail(err)
+
+	return n
+}
+
+// Float reads a float64
+func (r *Reader) Float() float64 {
+	buf := r.bytes(8)
+	if buf == nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf))
+}
+
+// Len reads the length of a list, failing if it's
+// impossibly long, which means the file is corrupt
+func (r *Reader) Len() int {
+	n := r.Uint()
+
+	if n > maxLen {
+		r.Fail(fmt.Errorf("bytecode: length %d is too long", n))
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *Reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		r.Fail(err)
+		return nil
+	}
+
+	return buf
+}
+
+// String reads a string
+func (r *Reader) String() string {
+	return string(r.bytes(r.Len()))
+}
+
+// Strings reads a list of strings
+func (r *Reader) Strings() []string {
+	strs := make([]string, r.Len())
+
+	for i := range strs {
+		strs[i] = r.String()
+	}
+
+	return strs
+}
+
+// Code reads some instructions
+func (r *Reader) Code() Code {
+	var (
+		n    = r.Len()
+		code Code
+	)
+
+	for i := 0; i < n && r.err == nil; i++ {
+		op := r.Byte()
+
+		data, ok := Instructions[op]
+		if !ok {
+			r.Fail(fmt.Errorf("bytecode: unknown opcode %d", op))
+			break
+		}
+
+		instr := Instruction{
+			Code: op,
+			Name: data.Name,
+		}
+
+		if data.HasArg {
+			instr.Arg = rune(r.Uint())
+		}
+
+		instr.Pos = r.position()
+
+		code = append(code, instr)
+	}
+
+	return code
+}
+
+func (r *Reader) position() token.Position {
+	if r.Uint() != 0 {
+		r.file = r.String()
+	}
+
+	return token.Position{
+		File:   r.file,
+		Line:   int(r.Uint()),
+		Column: int(r.Uint()),
+	}
+}
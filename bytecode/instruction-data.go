@@ -45,9 +45,14 @@ var Instructions = map[byte]data{
 	Return:     {Name: "RETURN_FN"},
 	DoBlock:    {Name: "DO_BLOCK"},
 
-	Print:   {Name: "PRINT"},
-	Println: {Name: "PRINT_LINE"},
-	Length:  {Name: "LENGTH"},
+	Print:          {Name: "PRINT"},
+	Println:        {Name: "PRINT_LINE"},
+	Length:         {Name: "LENGTH"},
+	Exit:           {Name: "EXIT"},
+	Assert:         {Name: "ASSERT"},
+	AssertEqual:    {Name: "ASSERT_EQUAL"},
+	AssertNotEqual: {Name: "ASSERT_NOT_EQUAL"},
+	Fail:           {Name: "FAIL"},
 
 	Jump:        {Name: "JUMP", HasArg: true},
 	JumpIfTrue:  {Name: "JUMP_IF_TRUE", HasArg: true},
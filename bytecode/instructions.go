@@ -90,6 +90,27 @@ const (
 	// Length pushes the length of the collection at
 	// the top of the stack
 	Length
+
+	// Exit pops the status code at the top of the stack
+	// and halts the virtual machine
+	Exit
+
+	// Assert pops the top item and throws an assertion
+	// error if it's falsey
+	Assert
+
+	// AssertEqual pops two items, the expected value on
+	// top, and throws an assertion error if they aren't
+	// equal
+	AssertEqual
+
+	// AssertNotEqual pops two items and throws an
+	// assertion error if they're equal
+	AssertNotEqual
+
+	// Fail pops a message and throws an assertion error
+	// with it
+	Fail
 )
 
 // 90-99: control flow
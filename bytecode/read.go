@@ -2,6 +2,8 @@ package bytecode
 
 import (
 	"errors"
+
+	"github.com/Zac-Garby/pluto/token"
 )
 
 // Raw is the raw bytecode, i.e. a list of bytes.
@@ -24,6 +26,10 @@ type Instruction struct {
 	Code byte
 	Arg  rune
 	Name string
+
+	// Pos is the position of the statement the
+	// instruction was compiled from, if it's known
+	Pos token.Position
 }
 
 // ErrOutOfBytes is thrown by Read when a byte
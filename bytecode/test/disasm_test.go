@@ -0,0 +1,55 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+func TestDisassemble(t *testing.T) {
+	line := func(n int) token.Position {
+		return token.Position{Line: n}
+	}
+
+	u := Unit{
+		Kind: UnitMain,
+		Name: "main.pluto",
+		Code: Code{
+			{Code: LoadName, Arg: 0, Name: "LOAD_NAME", Pos: line(1)},
+			{Code: JumpIfFalse, Arg: 13, Name: "JUMP_IF_FALSE", Pos: line(1)},
+			{Code: LoadConst, Arg: 0, Name: "LOAD_CONST", Pos: line(2)},
+			{Code: PushFn, Arg: 0, Name: "PUSH_FN", Pos: line(2)},
+			{Code: CallFn, Name: "CALL_FN", Pos: line(2)},
+		},
+		Constants: []string{`"yes"`},
+		Names:     []string{"x"},
+		Patterns:  []string{"print $"},
+		Units: []Unit{
+			{Kind: UnitFunction, Name: "f", Code: Code{{Code: Return, Name: "RETURN_FN", Pos: line(4)}}},
+		},
+	}
+
+	expected := `.main main.pluto
+       1      0  LOAD_NAME            0 (x)
+              3  JUMP_IF_FALSE       13 (to L1)
+       2      6  LOAD_CONST           0 ("yes")
+              9  PUSH_FN              0 (print $)
+             12  CALL_FN
+L1:
+
+.def f
+       4      0  RETURN_FN
+.end
+`
+
+	var buf bytes.Buffer
+	if err := Disassemble(&buf, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+)
+
+// compileCommand compiles source files, or assembles .plasm
+// files, into .plc files, which 'pluto run' executes without
+// parsing or compiling them again. Each file is written next
+// to its source, unless -o is given for a single file.
+func compileCommand(args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	out := flags.String("o", "", "write the compiled file to `file`")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto compile [-o file] <files...>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || (*out != "" && flags.NArg() > 1) {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+
+	for _, file := range flags.Args() {
+		path := *out
+		if path == "" {
+			path = compiledPath(file)
+		}
+
+		if err := compileFile(file, path); err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func compileFile(file, path string) error {
+	m, err := loadModule(file)
+	if err != nil {
+		return err
+	}
+
+	return m.WriteFile(path)
+}
+
+// compiledPath is where a source file is compiled to by
+// default: the same path, with the .plc extension
+func compiledPath(file string) string {
+	for _, ext := range []string{literate.Extension, module.AssemblyExtension, ".pluto"} {
+		if strings.HasSuffix(file, ext) {
+			return strings.TrimSuffix(file, ext) + module.Extension
+		}
+	}
+
+	return file + module.Extension
+}
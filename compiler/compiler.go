@@ -2,7 +2,9 @@ package compiler
 
 import (
 	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
 	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/token"
 )
 
 // Compiler compiles an AST into bytecode
@@ -10,10 +12,16 @@ type Compiler struct {
 	// Generated code:
 	Bytes []byte
 
+	// Positions holds the source position of the
+	// statement each byte in Bytes was compiled from
+	Positions []token.Position
+
 	// Data:
 	Constants       []object.Object
 	Functions       []object.Function
 	Names, Patterns []string
+
+	pos token.Position // the position of the current statement
 }
 
 // New instantiates a new Compiler, and allocates
@@ -35,3 +43,26 @@ func (c *Compiler) CompileProgram(p ast.Program) error {
 
 	return nil
 }
+
+// Code reads the generated bytes into instructions,
+// giving each one the position it was compiled from.
+func (c *Compiler) Code() (bytecode.Code, error) {
+	code, err := bytecode.Read(c.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+
+	for i, instr := range code {
+		code[i].Pos = c.Positions[offset]
+
+		if bytecode.Instructions[instr.Code].HasArg {
+			offset += 3
+		} else {
+			offset++
+		}
+	}
+
+	return code, nil
+}
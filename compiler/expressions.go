@@ -380,7 +380,13 @@ func (c *Compiler) compileFnCall(node *ast.FunctionCall) error {
 		}
 	}
 
-	str := strings.Join(ptn, " ")
+	var (
+		str   = strings.Join(ptn, " ")
+		index = rune(len(c.Patterns))
+	)
+
+	// The index is found before compiling the arguments,
+	// since they might add patterns of their own
 	c.Patterns = append(c.Patterns, str)
 
 	for _, item := range node.Pattern {
@@ -391,7 +397,7 @@ func (c *Compiler) compileFnCall(node *ast.FunctionCall) error {
 		}
 	}
 
-	low, high := runeToBytes(rune(len(c.Patterns) - 1))
+	low, high := runeToBytes(index)
 	c.push(bytecode.PushFn, high, low, bytecode.CallFn)
 
 	return nil
@@ -408,7 +414,11 @@ func (c *Compiler) compileQualifiedFnCall(node *ast.QualifiedFunctionCall) error
 		}
 	}
 
-	str := strings.Join(ptn, " ")
+	var (
+		str   = strings.Join(ptn, " ")
+		index = rune(len(c.Patterns))
+	)
+
 	c.Patterns = append(c.Patterns, str)
 
 	for _, item := range node.Pattern {
@@ -423,7 +433,7 @@ func (c *Compiler) compileQualifiedFnCall(node *ast.QualifiedFunctionCall) error
 		return err
 	}
 
-	low, high := runeToBytes(rune(len(c.Patterns) - 1))
+	low, high := runeToBytes(index)
 	c.push(bytecode.PushQualFn, high, low, bytecode.CallFn)
 
 	return nil
@@ -503,7 +513,7 @@ func (c *Compiler) compileBlockLiteral(node *ast.BlockLiteral) error {
 		return err
 	}
 
-	instructions, err := bytecode.Read(fcomp.Bytes)
+	instructions, err := fcomp.Code()
 	if err != nil {
 		return err
 	}
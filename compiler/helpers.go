@@ -52,4 +52,8 @@ func (c *Compiler) loadName(index rune) {
 
 func (c *Compiler) push(bytes ...byte) {
 	c.Bytes = append(c.Bytes, bytes...)
+
+	for range bytes {
+		c.Positions = append(c.Positions, c.pos)
+	}
 }
@@ -2,17 +2,24 @@ package compiler
 
 import (
 	"fmt"
-	"path/filepath"
 	"reflect"
-	"strings"
 
 	"github.com/Zac-Garby/pluto/ast"
 	"github.com/Zac-Garby/pluto/bytecode"
 	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/token"
 )
 
 // CompileStatement compiles an AST statement.
 func (c *Compiler) CompileStatement(n ast.Statement) error {
+	// Statements made by the compiler, such as a for
+	// loop's increment, have no position of their own
+	if pos := n.Token().Start; pos.Line > 0 {
+		defer func(outer token.Position) { c.pos = outer }(c.pos)
+		c.pos = pos
+	}
+
 	switch node := n.(type) {
 	case *ast.ExpressionStatement:
 		return c.CompileExpression(node.Expr)
@@ -54,7 +61,7 @@ func (c *Compiler) compileFunctionDefinition(node *ast.FunctionDefinition) error
 		return err
 	}
 
-	instructions, err := bytecode.Read(fcomp.Bytes)
+	instructions, err := fcomp.Code()
 	if err != nil {
 		return err
 	}
@@ -65,6 +72,7 @@ func (c *Compiler) compileFunctionDefinition(node *ast.FunctionDefinition) error
 		Constants: fcomp.Constants,
 		Names:     fcomp.Names,
 		Patterns:  fcomp.Patterns,
+		Doc:       node.Doc,
 	}
 
 	c.Functions = append(c.Functions, fn)
@@ -153,14 +161,7 @@ func (c *Compiler) compileBreak(node *ast.BreakStatement) error {
 }
 
 func (c *Compiler) compileUse(node *ast.UseStatement) error {
-	pkg := node.Package
-
-	if strings.HasPrefix(node.Package, "./") {
-		dir, _ := filepath.Split(node.Tok.Start.File)
-		pkg = filepath.Join(dir, pkg)
-	}
-
-	obj := &object.String{Value: pkg}
+	obj := &object.String{Value: pkg.Glob(node.Package, node.Tok.Start.File)}
 	c.Constants = append(c.Constants, obj)
 	index := len(c.Constants) - 1
 
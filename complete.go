@@ -0,0 +1,231 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/lexer"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// completer implements readline.AutoCompleter for the
+// REPL. It suggests names defined in the session and
+// templates for the patterns of the functions in scope,
+// such as "print $obj and $other". Typing a module name
+// followed by a colon suggests the module's methods.
+type completer struct {
+	r *repl
+}
+
+// Do returns the possible continuations of line[:pos],
+// and how many characters of each are already typed.
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	call, ok := currentCall(string(line[:pos]))
+	if !ok {
+		return nil, 0
+	}
+
+	var (
+		functions = c.r.store.Functions
+		names     = c.r.store.DefinedNames()
+		current   = call.current
+		seen      = make(map[string]bool)
+		options   []string
+	)
+
+	if call.module != "" {
+		functions = c.moduleMethods(call.module)
+		names = nil
+	}
+
+	add := func(option string) {
+		if !seen[option] {
+			seen[option] = true
+			options = append(options, option)
+		}
+	}
+
+	for _, fn := range functions {
+		if option, ok := completePattern(patternTemplate(fn.Pattern), call.words, current); ok {
+			add(option)
+		}
+	}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, current) || strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		add(name[len(current):])
+
+		if c.moduleMethods(name) != nil {
+			add(name[len(current):] + ":")
+		}
+	}
+
+	sort.Strings(options)
+
+	candidates := make([][]rune, len(options))
+	for i, option := range options {
+		candidates[i] = []rune(option)
+	}
+
+	return candidates, len([]rune(current))
+}
+
+func (c *completer) moduleMethods(name string) []object.Function {
+	module, ok := c.r.store.GetName(name).(*object.Map)
+	if !ok {
+		return nil
+	}
+
+	methods, ok := module.Get(&object.String{Value: "_methods"}).(*object.Array)
+	if !ok {
+		return nil
+	}
+
+	var fns []object.Function
+
+	for _, method := range methods.Value {
+		if fn, ok := method.(*object.Function); ok {
+			fns = append(fns, *fn)
+		}
+	}
+
+	return fns
+}
+
+// A call is the function call being typed: the words
+// before the cursor, with "$" for each argument, the word
+// being typed at the cursor, and the module whose method is
+// being called, if it's a qualified call.
+type call struct {
+	words   []string
+	current string
+	module  string
+}
+
+// currentCall lexes the input up to the cursor, to find the
+// function call being typed. That's what comes after the
+// last operator or statement break, inside the innermost
+// bracket which is still open. A string, number, parameter
+// or closed bracket is an argument. It returns false if the
+// input can't be lexed, such as in an unfinished string.
+func currentCall(text string) (call, bool) {
+	var (
+		next  = lexer.Lexer(text, "")
+		calls = []call{{}}
+		last  token.Token
+	)
+
+	for tok := next(); tok.Type != token.EOF; tok = next() {
+		top := &calls[len(calls)-1]
+
+		switch tok.Type {
+		case token.Illegal:
+			return call{}, false
+
+		case token.Semi:
+			// The lexer ends the input with a semicolon
+			if tok.Start.Column > len(text) {
+				continue
+			}
+
+			*top = call{}
+
+		case token.LeftParen, token.LeftSquare, token.LeftBrace:
+			calls = append(calls, call{})
+
+		case token.RightParen, token.RightSquare, token.RightBrace:
+			if len(calls) > 1 {
+				calls = calls[:len(calls)-1]
+			}
+
+			top = &calls[len(calls)-1]
+			top.words = append(top.words, "$")
+
+		case token.String, token.Char, token.Number, token.Param:
+			top.words = append(top.words, "$")
+
+		case token.Colon:
+			if len(top.words) == 1 && last.Type == token.ID {
+				*top = call{module: top.words[0]}
+			} else {
+				*top = call{}
+			}
+
+		default:
+			if tok.Type == token.ID || token.IsKeyword(tok.Type) {
+				top.words = append(top.words, tok.Literal)
+			} else {
+				*top = call{}
+			}
+		}
+
+		last = tok
+	}
+
+	c := calls[len(calls)-1]
+
+	// A word straight before the cursor is still being typed
+	r, _ := utf8.DecodeLastRuneInString(text)
+
+	if len(c.words) > 0 && (last.Type == token.ID || token.IsKeyword(last.Type)) && !unicode.IsSpace(r) {
+		c.current = c.words[len(c.words)-1]
+		c.words = c.words[:len(c.words)-1]
+	}
+
+	return c, true
+}
+
+// patternTemplate returns a function's pattern as a list
+// of words, with each parameter written as $name.
+func patternTemplate(pattern []ast.Expression) []string {
+	words := make([]string, len(pattern))
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words[i] = "$" + param.Name
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return words
+}
+
+// completePattern checks if the words already typed, with
+// "$" for each argument, and the partial word current, are
+// the start of a call to a pattern. If they are, it returns
+// the rest of the pattern.
+func completePattern(template, typed []string, current string) (string, bool) {
+	if len(typed) >= len(template) {
+		return "", false
+	}
+
+	for i, word := range typed {
+		param := strings.HasPrefix(template[i], "$")
+
+		if param && word != "$" || !param && word != template[i] {
+			return "", false
+		}
+	}
+
+	next := template[len(typed)]
+
+	if strings.HasPrefix(next, "$") {
+		if current != "" {
+			return "", false
+		}
+	} else if !strings.HasPrefix(next, current) {
+		return "", false
+	}
+
+	rest := append([]string{next[len(current):]}, template[len(typed)+1:]...)
+
+	return strings.Join(rest, " "), true
+}
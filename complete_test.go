@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+func TestCurrentCall(t *testing.T) {
+	tests := []struct {
+		text string
+		call call
+	}{
+		{"", call{}},
+		{"pri", call{current: "pri"}},
+		{"print ", call{words: []string{"print"}}},
+		{`greet "hello world" an`, call{words: []string{"greet", "$"}, current: "an"}},
+		{`greet "a (b" and `, call{words: []string{"greet", "$", "and"}}},
+		{"greet (1 + 2) and 'x' and $y ", call{words: []string{"greet", "$", "and", "$", "and", "$"}}},
+		{"x = double (triple ", call{words: []string{"triple"}}},
+		{"x = [1, ne", call{current: "ne"}},
+		{"a; b c", call{words: []string{"b"}, current: "c"}},
+		{"if (x) { print ", call{words: []string{"print"}}},
+		{"add 1 to", call{words: []string{"add", "$"}, current: "to"}},
+		{"io:", call{module: "io"}},
+		{"io:pri", call{module: "io", current: "pri"}},
+		{"io:print 1 and ", call{module: "io", words: []string{"print", "$", "and"}}},
+		{"say 'é' and ", call{words: []string{"say", "$", "and"}}},
+	}
+
+	for _, test := range tests {
+		c, ok := currentCall(test.text)
+		if !ok {
+			t.Errorf("%q: expected a call", test.text)
+			continue
+		}
+
+		if len(c.words) == 0 {
+			c.words = nil
+		}
+
+		if !reflect.DeepEqual(c, test.call) {
+			t.Errorf("%q: expected %+v, got %+v", test.text, test.call, c)
+		}
+	}
+
+	for _, text := range []string{`print "unfinished`, "é"} {
+		if c, ok := currentCall(text); ok {
+			t.Errorf("%q: expected no call, got %+v", text, c)
+		}
+	}
+}
+
+func TestCompletePattern(t *testing.T) {
+	template := []string{"greet", "$name", "and", "$other"}
+
+	tests := []struct {
+		typed   []string
+		current string
+		rest    string
+		ok      bool
+	}{
+		{nil, "", "greet $name and $other", true},
+		{nil, "gr", "eet $name and $other", true},
+		{nil, "say", "", false},
+		{[]string{"greet"}, "", "$name and $other", true},
+		{[]string{"greet"}, "x", "", false},
+		{[]string{"greet", "$"}, "a", "nd $other", true},
+		{[]string{"greet", "bob"}, "", "", false},
+		{[]string{"greet", "$", "and"}, "", "$other", true},
+		{[]string{"greet", "$", "and", "$"}, "", "", false},
+	}
+
+	for _, test := range tests {
+		rest, ok := completePattern(template, test.typed, test.current)
+		if rest != test.rest || ok != test.ok {
+			t.Errorf("%v %q: expected %q, %t, got %q, %t", test.typed, test.current, test.rest, test.ok, rest, ok)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := store.New()
+
+	src := `def greet $name and $other { return 0 }
+def greeting for $name { return 0 }
+greeted = 1
+`
+
+	if _, err := execute(vm.New(), src, "<test>", s, false); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &completer{r: &repl{store: s}}
+
+	tests := []struct {
+		line    string
+		options []string
+		length  int
+	}{
+		{"gree", []string{"t $name and $other", "ted", "ting for $name"}, 4},
+		{`greet "a b" a`, []string{"nd $other"}, 1},
+		{`x = (greeting for "é") + gre`, []string{"et $name and $other", "eted", "eting for $name"}, 3},
+		{`greet "unfinished`, nil, 0},
+	}
+
+	for _, test := range tests {
+		candidates, length := c.Do([]rune(test.line), len([]rune(test.line)))
+
+		var options []string
+		for _, candidate := range candidates {
+			options = append(options, string(candidate))
+		}
+
+		if !reflect.DeepEqual(options, test.options) {
+			t.Errorf("%q: expected %q, got %q", test.line, test.options, options)
+		}
+
+		if length != test.length {
+			t.Errorf("%q: expected %d characters to be typed, got %d", test.line, test.length, length)
+		}
+	}
+}
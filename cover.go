@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/cover"
+	"github.com/Zac-Garby/pluto/dir"
+)
+
+// reportCoverage prints the coverage of the source files
+// in the directories of the test files, and writes LCOV
+// and HTML reports if their paths aren't empty. Other
+// files which were run, such as the prelude, are left out.
+func reportCoverage(testFiles []string, counts map[string]map[int]int, lcov, html string) error {
+	var (
+		dirs    = make(map[string]bool)
+		files   = make(map[string]bool)
+		byFile  = make(map[string]map[int]int)
+		reports []string
+	)
+
+	for _, file := range testFiles {
+		dirs[filepath.Dir(absPath(file))] = true
+	}
+
+	for d := range dirs {
+		infos, err := ioutil.ReadDir(d)
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			if !info.IsDir() && dir.IsSource(info.Name()) {
+				files[filepath.Join(d, info.Name())] = true
+			}
+		}
+	}
+
+	for file, lines := range counts {
+		// Code which isn't from a file, such as the assertion
+		// library, has a name like "<testing>"
+		if !dir.IsSource(file) {
+			continue
+		}
+
+		abs := absPath(file)
+
+		if dirs[filepath.Dir(abs)] {
+			files[abs] = true
+			byFile[abs] = lines
+		}
+	}
+
+	for file := range files {
+		if !isTestFile(file) {
+			reports = append(reports, file)
+		}
+	}
+
+	report, err := cover.NewReport(byFile, reports)
+	if err != nil {
+		return err
+	}
+
+	// Names are shown relative to the working directory
+	if wd, err := os.Getwd(); err == nil {
+		for _, f := range report.Files {
+			if rel, err := filepath.Rel(wd, f.Name); err == nil && !strings.HasPrefix(rel, "..") {
+				f.Name = rel
+			}
+		}
+	}
+
+	if err := report.WriteSummary(os.Stdout); err != nil {
+		return err
+	}
+
+	writers := map[string]func(io.Writer) error{
+		lcov: report.WriteLCOV,
+		html: report.WriteHTML,
+	}
+
+	for path, write := range writers {
+		if path == "" {
+			continue
+		}
+
+		if err := writeFile(path, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isTestFile(name string) bool {
+	for _, suffix := range testSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func absPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(file)
+}
@@ -0,0 +1,256 @@
+package cover
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+)
+
+// File is the coverage of a source file
+type File struct {
+	Name string
+
+	// Lines are the lines which have statements on them,
+	// in order, and Hits is the number of times each of
+	// them was run
+	Lines []int
+	Hits  map[int]int
+}
+
+// Covered returns the number of lines which were run
+func (f *File) Covered() int {
+	n := 0
+
+	for _, line := range f.Lines {
+		if f.Hits[line] > 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Percent returns the percentage of lines which were run.
+// A file with no statements is completely covered.
+func (f *File) Percent() float64 {
+	if len(f.Lines) == 0 {
+		return 100
+	}
+
+	return 100 * float64(f.Covered()) / float64(len(f.Lines))
+}
+
+// Report is the coverage of some files, made from the
+// counts recorded in vm.VirtualMachine.Coverage.
+type Report struct {
+	Files []*File
+}
+
+// NewReport makes a report on files, which are compiled to
+// find the lines which have statements on them.
+func NewReport(counts map[string]map[int]int, files []string) (*Report, error) {
+	sorted := append([]string{}, files...)
+	sort.Strings(sorted)
+
+	r := &Report{}
+
+	for _, name := range sorted {
+		lines, err := Lines(name)
+		if err != nil {
+			return nil, err
+		}
+
+		f := &File{
+			Name:  name,
+			Lines: lines,
+			Hits:  make(map[int]int),
+		}
+
+		for _, line := range lines {
+			f.Hits[line] = counts[name][line]
+		}
+
+		r.Files = append(r.Files, f)
+	}
+
+	return r, nil
+}
+
+// Lines returns the lines in a file which have statements
+// on them, in order.
+func Lines(file string) ([]int, error) {
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		return nil, parse.Errors[0]
+	}
+
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return nil, err
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[int]bool)
+	addLines(set, file, code, cmp.Constants, cmp.Functions)
+
+	lines := make([]int, 0, len(set))
+	for line := range set {
+		lines = append(lines, line)
+	}
+
+	sort.Ints(lines)
+
+	return lines, nil
+}
+
+// addLines adds the lines of the statements in some code
+// to set, including the code in its functions and blocks.
+func addLines(set map[int]bool, file string, code bytecode.Code, constants []object.Object, fns []object.Function) {
+	for _, instr := range code {
+		if instr.Pos.Line > 0 && instr.Pos.File == file {
+			set[instr.Pos.Line] = true
+		}
+	}
+
+	for _, fn := range fns {
+		addLines(set, file, fn.Body, fn.Constants, nil)
+	}
+
+	for _, c := range constants {
+		if block, ok := c.(*object.Block); ok {
+			addLines(set, file, block.Body, block.Constants, nil)
+		}
+	}
+}
+
+// Total returns the number of lines with statements in all
+// the files, and the number of them which were run.
+func (r *Report) Total() (lines, covered int) {
+	for _, f := range r.Files {
+		lines += len(f.Lines)
+		covered += f.Covered()
+	}
+
+	return lines, covered
+}
+
+// WriteSummary writes the percentage of each file which
+// was covered, and the total.
+func (r *Report) WriteSummary(w io.Writer) error {
+	var err error
+
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	for _, f := range r.Files {
+		printf("%6.1f%%  %d/%d  %s\n", f.Percent(), f.Covered(), len(f.Lines), f.Name)
+	}
+
+	lines, covered := r.Total()
+	total := 100.0
+	if lines > 0 {
+		total = 100 * float64(covered) / float64(lines)
+	}
+
+	printf("%6.1f%%  %d/%d  total\n", total, covered, lines)
+
+	return err
+}
+
+// WriteLCOV writes the report as an LCOV tracefile, which
+// genhtml and most CI services can read.
+func (r *Report) WriteLCOV(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("TN:\n")
+
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "SF:%s\n", f.Name)
+
+		for _, line := range f.Lines {
+			fmt.Fprintf(&b, "DA:%d,%d\n", line, f.Hits[line])
+		}
+
+		fmt.Fprintf(&b, "LH:%d\nLF:%d\nend_of_record\n", f.Covered(), len(f.Lines))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Pluto coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.3; }
+.line { color: #999; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+</style>
+</head>
+<body>
+`
+
+// WriteHTML writes the report as a web page, with the
+// source of each file. Lines which were run are green, and
+// lines which weren't are red.
+func (r *Report) WriteHTML(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString(htmlHeader)
+
+	for _, f := range r.Files {
+		src, err := ioutil.ReadFile(f.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&b, "<h2>%s (%.1f%%)</h2>\n<pre>\n", html.EscapeString(f.Name), f.Percent())
+
+		for i, text := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+			line := i + 1
+
+			class := ""
+			if hits, ok := f.Hits[line]; ok && hits > 0 {
+				class = "covered"
+			} else if ok {
+				class = "uncovered"
+			}
+
+			fmt.Fprintf(&b, "<span class=\"line\">%5d</span>  <span class=\"%s\">%s</span>\n", line, class, html.EscapeString(text))
+		}
+
+		b.WriteString("</pre>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/cover"
+)
+
+const source = `def sign of $x {
+    if ($x < 0) {
+        return -1
+    }
+
+    return 1
+}
+
+a = sign of 5
+`
+
+func TestReport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sign.pluto")
+	if err := ioutil.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := Lines(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []int{2, 3, 6, 9}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected statements on lines %v, got %v", expected, lines)
+	}
+
+	counts := map[string]map[int]int{
+		file: {2: 1, 6: 1, 9: 1},
+	}
+
+	report, err := NewReport(counts, []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f := report.Files[0]; f.Covered() != 3 || f.Percent() != 75 {
+		t.Errorf("expected 3 lines (75%%) to be covered, got %d (%.1f%%)", f.Covered(), f.Percent())
+	}
+
+	var lcov bytes.Buffer
+	if err := report.WriteLCOV(&lcov); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "TN:\nSF:" + file + "\nDA:2,1\nDA:3,0\nDA:6,1\nDA:9,1\nLH:3\nLF:4\nend_of_record\n"
+	if lcov.String() != expected {
+		t.Errorf("expected the LCOV report:\n%s\ngot:\n%s", expected, lcov.String())
+	}
+}
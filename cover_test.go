@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoverFromTestDirectory(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"sum.pluto": `def sum of $a and $b {
+    return $a + $b
+}
+
+def unused {
+    return 0
+}
+`,
+		"sum_test.pluto": `use "./sum.pluto"
+
+def test sum {
+    assert (sum of 1 and 2) equals 3
+}
+`,
+	})
+
+	chdir(t, dir)
+
+	for _, args := range [][]string{{"-cover"}, {"-cover", "."}} {
+		var status int
+
+		out := capture(t, func() {
+			status = testCommand(args)
+		})
+
+		if status != 0 {
+			t.Errorf("pluto test %s: expected the exit status 0, got %d:\n%s", strings.Join(args, " "), status, out)
+		}
+
+		if !strings.Contains(out, "sum.pluto") || strings.Contains(out, "<testing>") {
+			t.Errorf("pluto test %s: expected the coverage of only sum.pluto, got:\n%s", strings.Join(args, " "), out)
+		}
+	}
+}
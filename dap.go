@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Zac-Garby/pluto/dap"
+)
+
+// dapCommand runs a debug adapter, which lets an editor
+// debug Pluto programs over stdin and stdout.
+func dapCommand(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto dap")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,588 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/debug"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+// There's only ever one thread
+const threadID = 1
+
+// Server is a debug adapter for Pluto, which lets an
+// editor run a program under a debug.Debugger using the
+// Debug Adapter Protocol.
+type Server struct {
+	in *bufio.Reader
+
+	writeMu sync.Mutex
+	out     io.Writer
+	seq     int
+
+	debugger *debug.Debugger
+	machine  *vm.VirtualMachine
+	run      func() error
+	entry    bool
+
+	// handles are the collections and frames whose
+	// variables can be requested while the program is
+	// stopped. A variablesReference is an index into it,
+	// plus one.
+	handlesMu sync.Mutex
+	handles   []interface{}
+
+	disconnected bool
+}
+
+type handler func(s *Server, args json.RawMessage) (interface{}, error)
+
+var requests = map[string]handler{
+	"initialize":              (*Server).initialize,
+	"launch":                  (*Server).launch,
+	"setBreakpoints":          (*Server).setBreakpoints,
+	"setExceptionBreakpoints": (*Server).setExceptionBreakpoints,
+	"configurationDone":       (*Server).configurationDone,
+	"threads":                 (*Server).threads,
+	"stackTrace":              (*Server).stackTrace,
+	"scopes":                  (*Server).scopes,
+	"variables":               (*Server).variables,
+	"continue":                (*Server).continueRequest,
+	"next":                    (*Server).next,
+	"stepIn":                  (*Server).stepIn,
+	"stepOut":                 (*Server).stepOut,
+	"pause":                   (*Server).pause,
+	"evaluate":                (*Server).evaluate,
+	"terminate":               (*Server).terminate,
+	"disconnect":              (*Server).disconnect,
+}
+
+// errNotLaunched is returned by requests which need a
+// program before it's been launched.
+var errNotLaunched = errors.New("no program has been launched")
+
+// NewServer creates a debug adapter which reads messages
+// from in and writes them to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:       bufio.NewReader(in),
+		out:      out,
+		debugger: debug.New(),
+	}
+}
+
+// Serve handles requests until the client disconnects,
+// or the input ends.
+func (s *Server) Serve() error {
+	for !s.disconnected {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("dap: %s", err)
+		}
+
+		if req.Type != "request" {
+			continue
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+
+	s.debugger.Terminate()
+
+	return nil
+}
+
+// handle calls a request's handler and sends the response
+// to the client. The returned error is only set if the
+// response couldn't be written.
+func (s *Server) handle(req request) error {
+	resp := response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Command:    req.Command,
+	}
+
+	h, ok := requests[req.Command]
+	if !ok {
+		resp.Message = "unsupported request: " + req.Command
+		return s.send(&resp)
+	}
+
+	body, err := h(s, req.Arguments)
+	if err != nil {
+		resp.Message = err.Error()
+	} else {
+		resp.Success = true
+		resp.Body = body
+	}
+
+	if err := s.send(&resp); err != nil {
+		return err
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	switch req.Command {
+	case "initialize":
+		// Now the client knows the server's capabilities,
+		// it can start sending breakpoints
+		return s.event("initialized", nil)
+	case "configurationDone":
+		s.start()
+	}
+
+	return nil
+}
+
+// send writes a response or event, setting its sequence
+// number.
+func (s *Server) send(msg interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+
+	switch m := msg.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+
+	return writeMessage(s.out, msg)
+}
+
+func (s *Server) event(name string, body interface{}) error {
+	return s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+// output is an io.Writer which sends what the program
+// prints to the client as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o output) Write(p []byte) (int, error) {
+	err := o.s.event("output", map[string]string{
+		"category": o.category,
+		"output":   string(p),
+	})
+
+	return len(p), err
+}
+
+func (s *Server) initialize(args json.RawMessage) (interface{}, error) {
+	return map[string]bool{
+		"supportsConfigurationDoneRequest": true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+// launch loads the program, which is started when the
+// client has finished configuring breakpoints.
+func (s *Server) launch(raw json.RawMessage) (interface{}, error) {
+	var args launchArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	if args.Program == "" {
+		return nil, errors.New("launch: no program given")
+	}
+
+	file, err := filepath.Abs(args.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		msgs := make([]string, len(parse.Errors))
+		for i, err := range parse.Errors {
+			msgs[i] = err.Error()
+		}
+
+		return nil, errors.New(strings.Join(msgs, "\n"))
+	}
+
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return nil, err
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		return nil, err
+	}
+
+	s.machine = vm.New()
+	s.machine.Out = output{s, "stdout"}
+	s.entry = args.StopOnEntry
+
+	s.run = func() error {
+		st := store.New()
+
+		scriptArgs := &object.Array{}
+		for _, arg := range args.Args {
+			scriptArgs.Value = append(scriptArgs.Value, &object.String{Value: arg})
+		}
+
+		st.Define("args", scriptArgs, false)
+
+		st.Names = cmp.Names
+		st.Patterns = cmp.Patterns
+		st.FunctionStore.Define(cmp.Functions...)
+
+		s.machine.Run(code, st, cmp.Constants, true)
+
+		if s.machine.Error != nil {
+			return s.machine.Error
+		}
+
+		return nil
+	}
+
+	return nil, nil
+}
+
+func (s *Server) setBreakpoints(raw json.RawMessage) (interface{}, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	var (
+		lines       = make([]int, len(args.Breakpoints))
+		breakpoints = make([]breakpoint, len(args.Breakpoints))
+	)
+
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line
+		breakpoints[i] = breakpoint{Verified: true, Line: bp.Line}
+	}
+
+	s.debugger.SetBreakpoints(args.Source.Path, lines)
+
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+func (s *Server) setExceptionBreakpoints(raw json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// configurationDone checks that there's a program to
+// start. It's started after the response has been sent.
+func (s *Server) configurationDone(raw json.RawMessage) (interface{}, error) {
+	if s.run == nil {
+		return nil, errNotLaunched
+	}
+
+	return nil, nil
+}
+
+// start starts the program, and forwards the debugger's
+// events to the client.
+func (s *Server) start() {
+	s.debugger.Start(s.machine, s.run, s.entry)
+
+	go s.forwardEvents()
+}
+
+func (s *Server) forwardEvents() {
+	for e := range s.debugger.Events() {
+		if !e.Exited() {
+			s.handlesMu.Lock()
+			s.handles = nil
+			s.handlesMu.Unlock()
+
+			s.event("stopped", map[string]interface{}{
+				"reason":            e.Reason,
+				"threadId":          threadID,
+				"allThreadsStopped": true,
+			})
+
+			continue
+		}
+
+		if e.Err != nil {
+			output{s, "stderr"}.Write([]byte(e.Err.Error() + "\n"))
+		}
+
+		exitCode := e.ExitCode
+		if e.Err != nil && exitCode == 0 {
+			exitCode = 1
+		}
+
+		s.event("exited", map[string]int{"exitCode": exitCode})
+		s.event("terminated", nil)
+	}
+}
+
+func (s *Server) threads(raw json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"threads": []map[string]interface{}{
+			{"id": threadID, "name": "main"},
+		},
+	}, nil
+}
+
+// stackTrace lists the frames the program is stopped in,
+// innermost first. A frame's ID is its index plus one.
+func (s *Server) stackTrace(raw json.RawMessage) (interface{}, error) {
+	stopped := s.debugger.Stopped()
+	if stopped == nil {
+		return nil, debug.ErrRunning
+	}
+
+	frames := []stackFrame{}
+
+	for f := stopped; f != nil; f = f.Previous() {
+		pos := f.Pos()
+
+		frame := stackFrame{
+			ID:     len(frames) + 1,
+			Name:   f.Name(),
+			Line:   pos.Line,
+			Column: pos.Column,
+		}
+
+		if pos.File != "" && !strings.HasPrefix(pos.File, "<") {
+			frame.Source = &source{Name: filepath.Base(pos.File), Path: pos.File}
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return map[string]interface{}{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	}, nil
+}
+
+func (s *Server) scopes(raw json.RawMessage) (interface{}, error) {
+	var args frameArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"scopes": []scope{{
+			Name:               "Locals",
+			VariablesReference: s.reference(frame),
+		}},
+	}, nil
+}
+
+// variables lists the names in a frame's store, or the
+// elements of a collection.
+func (s *Server) variables(raw json.RawMessage) (interface{}, error) {
+	var args variablesArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	s.handlesMu.Lock()
+	ref := args.VariablesReference - 1
+	if ref < 0 || ref >= len(s.handles) {
+		s.handlesMu.Unlock()
+		return nil, fmt.Errorf("invalid variables reference %d", args.VariablesReference)
+	}
+
+	target := s.handles[ref]
+	s.handlesMu.Unlock()
+
+	vars := []variable{}
+
+	switch t := target.(type) {
+	case *vm.Frame:
+		locals := t.Locals()
+
+		for _, name := range locals.DefinedNames() {
+			vars = append(vars, s.variable(name, locals.GetName(name)))
+		}
+	case *object.Map:
+		hashes := make([]string, 0, len(t.Keys))
+		for hash := range t.Keys {
+			hashes = append(hashes, hash)
+		}
+
+		sort.Strings(hashes)
+
+		for _, hash := range hashes {
+			vars = append(vars, s.variable(t.Keys[hash].String(), t.Values[hash]))
+		}
+	case object.Collection:
+		for i, el := range t.Elements() {
+			vars = append(vars, s.variable(fmt.Sprintf("[%d]", i), el))
+		}
+	}
+
+	return map[string]interface{}{"variables": vars}, nil
+}
+
+func (s *Server) variable(name string, obj object.Object) variable {
+	v := variable{Name: name, Value: "null"}
+
+	if obj == nil {
+		return v
+	}
+
+	v.Value = obj.String()
+	v.Type = string(obj.Type())
+	v.VariablesReference = s.children(obj)
+
+	return v
+}
+
+// children returns a reference to an object's elements,
+// or 0 if it doesn't have any.
+func (s *Server) children(obj object.Object) int {
+	switch o := obj.(type) {
+	case *object.Map:
+		if len(o.Keys) > 0 {
+			return s.reference(o)
+		}
+	case object.Collection:
+		if len(o.Elements()) > 0 {
+			return s.reference(o)
+		}
+	}
+
+	return 0
+}
+
+// reference adds a frame or collection to the handles,
+// returning its variablesReference.
+func (s *Server) reference(target interface{}) int {
+	s.handlesMu.Lock()
+	defer s.handlesMu.Unlock()
+
+	s.handles = append(s.handles, target)
+	return len(s.handles)
+}
+
+func (s *Server) continueRequest(raw json.RawMessage) (interface{}, error) {
+	if err := s.debugger.Continue(); err != nil {
+		return nil, err
+	}
+
+	return map[string]bool{"allThreadsContinued": true}, nil
+}
+
+func (s *Server) next(raw json.RawMessage) (interface{}, error) {
+	return nil, s.debugger.StepOver()
+}
+
+func (s *Server) stepIn(raw json.RawMessage) (interface{}, error) {
+	return nil, s.debugger.StepIn()
+}
+
+func (s *Server) stepOut(raw json.RawMessage) (interface{}, error) {
+	return nil, s.debugger.StepOut()
+}
+
+func (s *Server) pause(raw json.RawMessage) (interface{}, error) {
+	s.debugger.Pause()
+	return nil, nil
+}
+
+// evaluate evaluates an expression in a stopped frame, or
+// the innermost one if no frame is given.
+func (s *Server) evaluate(raw json.RawMessage) (interface{}, error) {
+	var args evaluateArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	if args.FrameID == 0 {
+		args.FrameID = 1
+	}
+
+	frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := frame.Eval(args.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result := "null"
+	if obj != nil {
+		result = obj.String()
+	}
+
+	return map[string]interface{}{
+		"result":             result,
+		"variablesReference": s.children(obj),
+	}, nil
+}
+
+func (s *Server) terminate(raw json.RawMessage) (interface{}, error) {
+	s.debugger.Terminate()
+	return nil, nil
+}
+
+func (s *Server) disconnect(raw json.RawMessage) (interface{}, error) {
+	s.debugger.Terminate()
+	s.disconnected = true
+
+	return nil, nil
+}
+
+// frame finds a stopped frame by its ID
+func (s *Server) frame(id int) (*vm.Frame, error) {
+	f := s.debugger.Stopped()
+	if f == nil {
+		return nil, debug.ErrRunning
+	}
+
+	for i := 1; i < id && f != nil; i++ {
+		f = f.Previous()
+	}
+
+	if f == nil || id < 1 {
+		return nil, fmt.Errorf("invalid frame ID %d", id)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,292 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/Zac-Garby/pluto/dap"
+)
+
+const program = `def double $x {
+    y = $x * 2
+    return y
+}
+
+a = 1
+b = double $a
+print $b
+`
+
+// prelude defines print, like the standard prelude
+const prelude = `def print $obj {
+    <$obj, PRINT_LINE>
+}
+`
+
+const loop = `i = 0
+while (true) {
+    i = i + 1
+}
+`
+
+type message map[string]interface{}
+
+// client is a scripted debug adapter client. It talks to
+// a server through pipes, as an editor would over stdio.
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan message
+	seq      int
+	events   []message
+	done     chan error
+}
+
+func start(t *testing.T) *client {
+	var (
+		inR, inW   = io.Pipe()
+		outR, outW = io.Pipe()
+		c          = &client{t: t, w: inW, messages: make(chan message, 100), done: make(chan error, 1)}
+	)
+
+	go func() {
+		c.done <- NewServer(inR, outW).Serve()
+		outW.Close()
+	}()
+
+	go func() {
+		r := bufio.NewReader(outR)
+
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			content := make([]byte, length)
+			io.ReadFull(r, content)
+
+			var msg message
+			json.Unmarshal(content, &msg)
+			c.messages <- msg
+		}
+	}()
+
+	return c
+}
+
+// next returns the next message from the server
+func (c *client) next() message {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed its output")
+		}
+
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+
+	return nil
+}
+
+// request sends a request, and waits for its response.
+// Events received in the meantime are saved.
+func (c *client) request(command string, args interface{}) message {
+	c.seq++
+
+	content, _ := json.Marshal(message{
+		"seq":       c.seq,
+		"type":      "request",
+		"command":   command,
+		"arguments": args,
+	})
+
+	fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(content), content)
+
+	for {
+		msg := c.next()
+
+		if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+
+		if msg["request_seq"] != float64(c.seq) {
+			continue
+		}
+
+		if msg["success"] != true {
+			c.t.Fatalf("%s failed: %v", command, msg["message"])
+		}
+
+		body, _ := msg["body"].(map[string]interface{})
+		return body
+	}
+}
+
+// event waits for an event, including ones which were
+// received while waiting for a response.
+func (c *client) event(name string) message {
+	for i, e := range c.events {
+		if e["event"] == name {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			body, _ := e["body"].(map[string]interface{})
+			return body
+		}
+	}
+
+	for {
+		msg := c.next()
+
+		if msg["type"] == "event" && msg["event"] == name {
+			body, _ := msg["body"].(map[string]interface{})
+			return body
+		} else if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+		}
+	}
+}
+
+// stoppedAt waits for the program to stop, and checks the
+// reason and the line of the innermost frame, unless line
+// is 0.
+func (c *client) stoppedAt(reason string, line int) []interface{} {
+	if got := c.event("stopped")["reason"]; got != reason {
+		c.t.Errorf("expected to stop for %s, but stopped for %v", reason, got)
+	}
+
+	frames := c.request("stackTrace", message{"threadId": 1})["stackFrames"].([]interface{})
+	if got := frames[0].(map[string]interface{})["line"]; line > 0 && got != float64(line) {
+		c.t.Errorf("expected to stop on line %d, but stopped on %v", line, got)
+	}
+
+	return frames
+}
+
+func (c *client) launch(src string, breakpoints ...int) {
+	file := filepath.Join(c.t.TempDir(), "main.pluto")
+
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		c.t.Fatal(err)
+	}
+
+	c.request("initialize", message{"adapterID": "pluto"})
+	c.event("initialized")
+	c.request("launch", message{"program": file})
+
+	var bps []message
+	for _, line := range breakpoints {
+		bps = append(bps, message{"line": line})
+	}
+
+	c.request("setBreakpoints", message{"source": message{"path": file}, "breakpoints": bps})
+	c.request("configurationDone", nil)
+}
+
+func (c *client) disconnect() {
+	c.request("disconnect", nil)
+
+	if err := <-c.done; err != nil {
+		c.t.Error(err)
+	}
+}
+
+// usePrelude sets $PLUTO to a directory containing a
+// minimal prelude.
+func usePrelude(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "packages", "std", "prelude")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "io.pluto"), []byte(prelude), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("PLUTO", home)
+}
+
+func TestStepping(t *testing.T) {
+	usePrelude(t)
+
+	c := start(t)
+	c.launch(program, 2)
+
+	frames := c.stoppedAt("breakpoint", 2)
+	if len(frames) != 2 || frames[0].(map[string]interface{})["name"] != "double $x" {
+		t.Errorf("expected to be in 'double $x', called from main. got %v", frames)
+	}
+
+	scopes := c.request("scopes", message{"frameId": 1})["scopes"].([]interface{})
+	ref := scopes[0].(map[string]interface{})["variablesReference"]
+
+	locals := make(map[string]interface{})
+	for _, v := range c.request("variables", message{"variablesReference": ref})["variables"].([]interface{}) {
+		v := v.(map[string]interface{})
+		locals[v["name"].(string)] = v["value"]
+	}
+
+	if locals["a"] != "1" || locals["x"] != "1" {
+		t.Errorf("expected a = 1 and x = 1, got %v", locals)
+	}
+
+	if result := c.request("evaluate", message{"expression": "$x + 10", "frameId": 1})["result"]; result != "11" {
+		t.Errorf("expected $x + 10 to be 11, got %v", result)
+	}
+
+	c.request("next", message{"threadId": 1})
+	c.stoppedAt("step", 3)
+
+	c.request("stepOut", message{"threadId": 1})
+	c.stoppedAt("step", 8)
+
+	c.request("continue", message{"threadId": 1})
+
+	if output := c.event("output")["output"]; output != "2\n" {
+		t.Errorf("expected the program to print 2, got %q", output)
+	}
+
+	if code := c.event("exited")["exitCode"]; code != float64(0) {
+		t.Errorf("expected exit code 0, got %v", code)
+	}
+
+	c.event("terminated")
+	c.disconnect()
+}
+
+func TestPause(t *testing.T) {
+	usePrelude(t)
+
+	c := start(t)
+	c.launch(loop)
+
+	c.request("pause", message{"threadId": 1})
+
+	if reason := c.event("stopped")["reason"]; reason != "pause" {
+		t.Errorf("expected to stop for pause, but stopped for %v", reason)
+	}
+
+	c.request("stepIn", message{"threadId": 1})
+	frames := c.stoppedAt("step", 0)[0].(map[string]interface{})
+
+	if line := frames["line"]; line != float64(2) && line != float64(3) {
+		t.Errorf("expected to stop in the loop, but stopped on line %v", line)
+	}
+
+	c.disconnect()
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/debug"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/fatih/color"
+)
+
+const debugPrompt = "(pluto) "
+
+const debugHelp = `commands:
+  break <file>:<line>  stop before the statement on a line
+  break <line>         the same, in the program's file
+  break on <pattern>   stop when a function is called, e.g. 'break on double $n'
+  step                 run to the next statement, going into function calls
+  next                 run to the next statement in this function
+  finish               run until this function returns
+  continue             run to the next breakpoint
+  bt                   show the frames which are being run
+  locals               show the variables in this frame
+  stack                show the objects on this frame's stack
+  print <expr>         evaluate an expression in this frame
+  quit                 stop the program and exit
+
+An empty line repeats the last command. Commands can be shortened
+to their first letter, except for bt, locals and stack.
+`
+
+// debugCommand runs a program under a gdb-style debugger,
+// controlled by commands read from stdin. The program
+// stops before its first statement, so breakpoints can be
+// set before it's continued.
+func debugCommand(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto debug <file> [args...]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var (
+		file       = flags.Arg(0)
+		scriptArgs = flags.Args()[1:]
+	)
+
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	c := newConsole(file, src, scriptArgs, os.Stdout)
+	return c.run(os.Stdin)
+}
+
+// newConsole starts a program under the debugger, stopped
+// before its first statement, with a console which writes
+// to out. The program's output is written to out too.
+func newConsole(file, src string, args []string, out io.Writer) *console {
+	var (
+		store   = store.New()
+		machine = vm.New()
+		d       = debug.New()
+	)
+
+	machine.Out = out
+	store.Define("args", stringArray(args), false)
+
+	d.Start(machine, func() error {
+		_, err := execute(machine, src, file, store, true)
+		return err
+	}, true)
+
+	return &console{
+		debugger: d,
+		file:     file,
+		out:      out,
+		sources:  make(map[string][]string),
+	}
+}
+
+// console reads debugger commands and prints the results.
+// The program is only inspected while it's stopped, which
+// it always is while a command is being read.
+type console struct {
+	debugger *debug.Debugger
+	file     string
+	out      io.Writer
+	frame    *vm.Frame
+	sources  map[string][]string
+
+	// exited is set once the program has exited, and
+	// status is the status pluto should exit with
+	exited bool
+	status int
+}
+
+func (c *console) run(in io.Reader) int {
+	c.wait()
+
+	var (
+		scanner = bufio.NewScanner(in)
+		last    string
+	)
+
+	for !c.exited {
+		fmt.Fprint(c.out, debugPrompt)
+
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			c.quit()
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+
+		if line == "" {
+			continue
+		}
+
+		last = line
+		c.command(line)
+	}
+
+	return c.status
+}
+
+// command runs a single command
+func (c *console) command(line string) {
+	var (
+		fields   = strings.Fields(line)
+		name     = fields[0]
+		argument = strings.TrimSpace(strings.TrimPrefix(line, name))
+	)
+
+	switch name {
+	case "break", "b":
+		c.setBreakpoint(argument)
+	case "step", "s":
+		c.resume(c.debugger.StepIn)
+	case "next", "n":
+		c.resume(c.debugger.StepOver)
+	case "finish", "f":
+		c.resume(c.debugger.StepOut)
+	case "continue", "c":
+		c.resume(c.debugger.Continue)
+	case "bt", "backtrace":
+		c.backtrace()
+	case "locals":
+		c.locals()
+	case "stack":
+		c.stack()
+	case "print", "p":
+		c.print(argument)
+	case "help", "h":
+		fmt.Fprint(c.out, debugHelp)
+	case "quit", "q":
+		c.quit()
+	default:
+		fmt.Fprintf(c.out, "unknown command '%s'. try 'help'\n", name)
+	}
+}
+
+// wait waits for the program to stop or exit, and reports
+// where it stopped, or how it exited
+func (c *console) wait() {
+	event, ok := <-c.debugger.Events()
+	if !ok {
+		c.exited = true
+		return
+	}
+
+	if event.Exited() {
+		c.frame = nil
+		c.exited = true
+		c.status = event.ExitCode
+
+		if event.Err != nil {
+			c.status = 1
+
+			if event.Err != errParse {
+				color.New(color.FgRed).Fprintf(os.Stderr, "%s\n", event.Err)
+			}
+		}
+
+		fmt.Fprintf(c.out, "program exited with status %d\n", c.status)
+
+		return
+	}
+
+	c.frame = event.Frame
+
+	pos := c.frame.Pos()
+	fmt.Fprintf(c.out, "stopped (%s) in %s at %s:%d\n", event.Reason, c.frame.Name(), pos.File, pos.Line)
+
+	c.showSource()
+}
+
+// showSource prints the line the program is stopped on,
+// and the instruction which is about to be run
+func (c *console) showSource() {
+	pos := c.frame.Pos()
+
+	if line, ok := c.sourceLine(pos.File, pos.Line); ok {
+		fmt.Fprintf(c.out, "%5d  %s\n", pos.Line, line)
+	}
+
+	instr, index := c.frame.Instruction()
+
+	if bytecode.Instructions[instr.Code].HasArg {
+		fmt.Fprintf(c.out, "       -> %d  %s %d\n", index, instr.Name, instr.Arg)
+	} else {
+		fmt.Fprintf(c.out, "       -> %d  %s\n", index, instr.Name)
+	}
+}
+
+// sourceLine returns a line of a source file. Files are
+// only read once.
+func (c *console) sourceLine(file string, n int) (string, bool) {
+	lines, ok := c.sources[file]
+	if !ok {
+		content, err := ioutil.ReadFile(file)
+		if err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+
+		c.sources[file] = lines
+	}
+
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
+
+func (c *console) resume(fn func() error) {
+	if err := fn(); err != nil {
+		fmt.Fprintln(c.out, err)
+		return
+	}
+
+	c.wait()
+}
+
+// setBreakpoint adds a breakpoint, keeping the existing
+// ones
+func (c *console) setBreakpoint(arg string) {
+	if strings.HasPrefix(arg, "on ") {
+		pattern := strings.TrimSpace(strings.TrimPrefix(arg, "on "))
+
+		patterns := append(c.debugger.FunctionBreakpoints(), pattern)
+		c.debugger.SetFunctionBreakpoints(patterns)
+
+		fmt.Fprintf(c.out, "breakpoint on '%s'\n", pattern)
+		return
+	}
+
+	file, lineText := c.file, arg
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		file, lineText = arg[:i], arg[i+1:]
+	}
+
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 || file == "" {
+		fmt.Fprintln(c.out, "usage: break <file>:<line>, break <line> or break on <pattern>")
+		return
+	}
+
+	c.debugger.AddBreakpoint(file, line)
+
+	fmt.Fprintf(c.out, "breakpoint at %s:%d\n", file, line)
+}
+
+func (c *console) backtrace() {
+	for i, f := 0, c.frame; f != nil; i, f = i+1, f.Previous() {
+		pos := f.Pos()
+		fmt.Fprintf(c.out, "#%d  %s at %s:%d\n", i, f.Name(), pos.File, pos.Line)
+	}
+}
+
+func (c *console) locals() {
+	locals := c.frame.Locals()
+
+	for _, name := range locals.DefinedNames() {
+		fmt.Fprintf(c.out, "%s = %s\n", name, describe(locals.GetName(name)))
+	}
+}
+
+// stack prints the frame's stack, with the top first
+func (c *console) stack() {
+	objects := c.frame.Stack()
+
+	if len(objects) == 0 {
+		fmt.Fprintln(c.out, "the stack is empty")
+		return
+	}
+
+	for i := len(objects) - 1; i >= 0; i-- {
+		fmt.Fprintf(c.out, "[%d] %s\n", i, describe(objects[i]))
+	}
+}
+
+func (c *console) print(expr string) {
+	if expr == "" {
+		fmt.Fprintln(c.out, "usage: print <expr>")
+		return
+	}
+
+	obj, err := c.frame.Eval(expr)
+	if err != nil {
+		color.New(color.FgRed).Fprintf(c.out, "%s\n", err)
+		return
+	}
+
+	fmt.Fprintln(c.out, describe(obj))
+}
+
+// quit stops the program, and waits for it to exit
+func (c *console) quit() {
+	c.debugger.Terminate()
+
+	for range c.debugger.Events() {
+	}
+
+	c.exited = true
+}
+
+func describe(obj object.Object) string {
+	if obj == nil {
+		return "null"
+	}
+
+	return obj.String()
+}
@@ -0,0 +1,351 @@
+package debug
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Zac-Garby/pluto/token"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+// The reasons a program can stop
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+	ReasonFunction   = "function breakpoint"
+)
+
+// Event is sent when the program stops, or when it exits.
+type Event struct {
+	// Frame is the frame which stopped, or nil if the
+	// program has exited
+	Frame  *vm.Frame
+	Reason string
+
+	// Err is the error the program exited with, if any,
+	// and ExitCode is the status it exited with
+	Err      error
+	ExitCode int
+}
+
+// Exited checks if the event is for the program exiting
+func (e Event) Exited() bool {
+	return e.Frame == nil
+}
+
+type step int
+
+const (
+	stepNone step = iota
+	stepIn
+	stepOver
+	stepOut
+)
+
+// ErrRunning is returned when the program needs to be
+// stopped to do something, but isn't.
+var ErrRunning = errors.New("debug: the program isn't stopped")
+
+// Debugger runs a program on a virtual machine, stopping
+// it at breakpoints, when stepping, and when it's paused.
+// While it's stopped, its frames can be inspected, and
+// expressions evaluated in them with vm.Frame.Eval.
+type Debugger struct {
+	events  chan Event
+	resume  chan step
+	pausing int32
+
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool
+	functions   map[string]bool
+	stopped     *vm.Frame
+	terminated  bool
+	machine     *vm.VirtualMachine
+
+	// Only used by the program's goroutine
+	entry bool
+	step  step
+	depth int
+	paths map[string]string
+}
+
+// New creates a debugger with no breakpoints
+func New() *Debugger {
+	return &Debugger{
+		events:      make(chan Event),
+		resume:      make(chan step, 1),
+		breakpoints: make(map[string]map[int]bool),
+		functions:   make(map[string]bool),
+		paths:       make(map[string]string),
+	}
+}
+
+// Events returns the channel which events are sent on.
+// It's closed after the program exits.
+func (d *Debugger) Events() <-chan Event {
+	return d.events
+}
+
+// Start runs a program in a new goroutine. run should run
+// the program on machine, and return its error. If entry
+// is true, the program stops before its first statement.
+func (d *Debugger) Start(machine *vm.VirtualMachine, run func() error, entry bool) {
+	d.machine = machine
+	machine.StatementHook = d.hook
+
+	d.entry = entry
+
+	go func() {
+		err := run()
+
+		d.events <- Event{Err: err, ExitCode: machine.ExitCode}
+		close(d.events)
+	}()
+}
+
+// SetBreakpoints replaces the breakpoints in a file with
+// breakpoints on the given lines.
+func (d *Debugger) SetBreakpoints(file string, lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	set := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		set[line] = true
+	}
+
+	d.breakpoints[normalise(file)] = set
+}
+
+// AddBreakpoint adds a breakpoint on a line, keeping the
+// file's other breakpoints.
+func (d *Debugger) AddBreakpoint(file string, line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file = normalise(file)
+
+	if d.breakpoints[file] == nil {
+		d.breakpoints[file] = make(map[int]bool)
+	}
+
+	d.breakpoints[file][line] = true
+}
+
+// Breakpoints returns the breakpoints in each file
+func (d *Debugger) Breakpoints() map[string][]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	all := make(map[string][]int)
+
+	for file, lines := range d.breakpoints {
+		for line := range lines {
+			all[file] = append(all[file], line)
+		}
+	}
+
+	return all
+}
+
+// SetFunctionBreakpoints replaces the function breakpoints.
+// The program stops when it calls a function whose pattern
+// matches one of patterns. A parameter in a pattern matches
+// any parameter, so "double $n" matches "double $x".
+func (d *Debugger) SetFunctionBreakpoints(patterns []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.functions = make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		d.functions[searchPattern(pattern)] = true
+	}
+}
+
+// FunctionBreakpoints returns the patterns of the function
+// breakpoints, with their parameters written as "$".
+func (d *Debugger) FunctionBreakpoints() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	patterns := make([]string, 0, len(d.functions))
+	for pattern := range d.functions {
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Strings(patterns)
+
+	return patterns
+}
+
+// Stopped returns the frame the program is stopped in, or
+// nil if it's running.
+func (d *Debugger) Stopped() *vm.Frame {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.stopped
+}
+
+// Continue runs the program until the next breakpoint
+func (d *Debugger) Continue() error {
+	return d.resumeWith(stepNone)
+}
+
+// StepIn runs the program until the next statement, even
+// if it's in a function called by this one.
+func (d *Debugger) StepIn() error {
+	return d.resumeWith(stepIn)
+}
+
+// StepOver runs the program until the next statement in
+// the current frame, or in one of the frames below it.
+func (d *Debugger) StepOver() error {
+	return d.resumeWith(stepOver)
+}
+
+// StepOut runs the program until the current frame has
+// returned to its caller.
+func (d *Debugger) StepOut() error {
+	return d.resumeWith(stepOut)
+}
+
+// Pause stops the program before its next statement
+func (d *Debugger) Pause() {
+	atomic.StoreInt32(&d.pausing, 1)
+}
+
+// Terminate stops the program, which then exits with an
+// interruption error.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.terminated = true
+
+	if d.machine != nil {
+		d.machine.Interrupt()
+	}
+
+	if d.stopped != nil {
+		d.stopped = nil
+		d.resume <- stepNone
+	}
+}
+
+func (d *Debugger) resumeWith(s step) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped == nil {
+		return ErrRunning
+	}
+
+	d.stopped = nil
+	d.resume <- s
+
+	return nil
+}
+
+// hook is the virtual machine's statement hook. It decides
+// whether to stop, and if so, waits to be resumed.
+func (d *Debugger) hook(f *vm.Frame) {
+	reason, ok := d.shouldStop(f)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		return
+	}
+
+	d.stopped = f
+	d.mu.Unlock()
+
+	d.events <- Event{Frame: f, Reason: reason}
+
+	d.step = <-d.resume
+	d.depth = f.Depth()
+}
+
+func (d *Debugger) shouldStop(f *vm.Frame) (string, bool) {
+	if d.entry {
+		d.entry = false
+		return ReasonEntry, true
+	}
+
+	if atomic.CompareAndSwapInt32(&d.pausing, 1, 0) {
+		return ReasonPause, true
+	}
+
+	if d.isBreakpoint(f.Pos()) {
+		return ReasonBreakpoint, true
+	}
+
+	if f.First() && d.isFunctionBreakpoint(f.Name()) {
+		return ReasonFunction, true
+	}
+
+	switch d.step {
+	case stepIn:
+		return ReasonStep, true
+	case stepOver:
+		return ReasonStep, f.Depth() <= d.depth
+	case stepOut:
+		return ReasonStep, f.Depth() < d.depth
+	}
+
+	return "", false
+}
+
+func (d *Debugger) isBreakpoint(pos token.Position) bool {
+	file, ok := d.paths[pos.File]
+	if !ok {
+		file = normalise(pos.File)
+		d.paths[pos.File] = file
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.breakpoints[file][pos.Line]
+}
+
+func (d *Debugger) isFunctionBreakpoint(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.functions) > 0 && d.functions[searchPattern(name)]
+}
+
+// searchPattern replaces the parameters in a pattern with
+// "$", and normalises the spaces between its words.
+func searchPattern(pattern string) string {
+	words := strings.Fields(pattern)
+
+	for i, word := range words {
+		if strings.HasPrefix(word, "$") {
+			words[i] = "$"
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// normalise makes file paths comparable, so a breakpoint
+// set on "./main.pluto" is hit in "main.pluto".
+func normalise(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(file)
+}
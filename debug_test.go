@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const debugProgram = `def double $n {
+    m = $n * 2
+    return m
+}
+
+x = 5
+y = double (x)
+print (y)
+`
+
+// debugScript runs a file under the debugger, from its
+// directory, with the commands in script, and returns the exit status and
+// everything the console printed
+func debugScript(t *testing.T, src, script string) (int, string) {
+	dir := setup(t, map[string]string{"prog.pluto": src})
+
+	chdir(t, dir)
+
+	var out bytes.Buffer
+	status := newConsole("prog.pluto", src, nil, &out).run(strings.NewReader(script))
+
+	return status, out.String()
+}
+
+// expectOutput checks that the lines are in out, in order
+func expectOutput(t *testing.T, out string, lines ...string) {
+	rest := out
+
+	for _, line := range lines {
+		i := strings.Index(rest, line)
+		if i < 0 {
+			t.Errorf("expected %q in the output, in order, got:\n%s", line, out)
+			return
+		}
+
+		rest = rest[i+len(line):]
+	}
+}
+
+func TestDebugBreakpoints(t *testing.T) {
+	status, out := debugScript(t, debugProgram, strings.Join([]string{
+		"break prog.pluto:7",
+		"break on double $n",
+		"continue",
+		"locals",
+		"c",
+		"bt",
+		"locals",
+		"print $n * 10",
+		"step",
+		"stack",
+		"c",
+	}, "\n"))
+
+	if status != 0 {
+		t.Errorf("expected the exit status 0, got %d", status)
+	}
+
+	expectOutput(t, out,
+		"stopped (entry) in main at prog.pluto:6\n    6  x = 5\n       -> 0  LOAD_CONST 0\n",
+		"(pluto) breakpoint at prog.pluto:7\n",
+		"(pluto) breakpoint on 'double $n'\n",
+		"(pluto) stopped (breakpoint) in main at prog.pluto:7\n    7  y = double (x)\n",
+		"(pluto) args = []\nx = 5\n",
+		"(pluto) stopped (function breakpoint) in double $n at prog.pluto:2\n",
+		"(pluto) #0  double $n at prog.pluto:2\n#1  main at prog.pluto:7\n",
+		"n = 5\n",
+		"(pluto) 50\n",
+		"(pluto) stopped (step) in double $n at prog.pluto:3\n    3      return m\n",
+		"(pluto) [0] 10\n",
+		"(pluto) 10\nprogram exited with status 0\n",
+	)
+}
+
+func TestDebugBreakpointInProgramFile(t *testing.T) {
+	_, out := debugScript(t, debugProgram, "b 8\nc\nprint y\n\nc\n")
+
+	expectOutput(t, out,
+		"(pluto) breakpoint at prog.pluto:8\n",
+		"(pluto) stopped (breakpoint) in main at prog.pluto:8\n",
+		"(pluto) 10\n(pluto) 10\n",
+		"(pluto) 10\nprogram exited with status 0\n",
+	)
+}
+
+func TestDebugStepping(t *testing.T) {
+	_, out := debugScript(t, debugProgram, "n\ns\nfinish\nnext\n")
+
+	expectOutput(t, out,
+		"stopped (entry) in main at prog.pluto:6\n",
+		"(pluto) stopped (step) in main at prog.pluto:7\n",
+		"(pluto) stopped (step) in double $n at prog.pluto:2\n",
+		"(pluto) stopped (step) in main at prog.pluto:8\n",
+		"(pluto) 10\nprogram exited with status 0\n",
+	)
+}
+
+func TestDebugErrors(t *testing.T) {
+	status, out := debugScript(t, "x = 1\ny = nothing\n", strings.Join([]string{
+		"break",
+		"break nowhere",
+		"break prog.pluto:0",
+		"print",
+		"print x +",
+		"frob",
+		"continue",
+	}, "\n"))
+
+	if status != 1 {
+		t.Errorf("expected the exit status 1, got %d", status)
+	}
+
+	usage := "usage: break <file>:<line>, break <line> or break on <pattern>\n"
+
+	expectOutput(t, out,
+		"(pluto) "+usage,
+		"(pluto) "+usage,
+		"(pluto) "+usage,
+		"(pluto) usage: print <expr>\n",
+		"(pluto) <eval>:1:",
+		"(pluto) unknown command 'frob'. try 'help'\n",
+		"(pluto) program exited with status 1\n",
+	)
+}
+
+func TestDebugEndOfInput(t *testing.T) {
+	status, out := debugScript(t, debugProgram, "")
+
+	if status != 0 {
+		t.Errorf("expected the exit status 0, got %d", status)
+	}
+
+	if strings.Contains(out, "10") {
+		t.Errorf("expected the program to be stopped at the end of the input, got:\n%s", out)
+	}
+}
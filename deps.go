@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/deps"
+)
+
+// depsCommand prints the files a program loads, found by
+// following its 'use' statements without running it, as a
+// tree or, with -dot, a Graphviz graph. Uses which can't be
+// resolved, files which can't be parsed and cycles are
+// reported afterwards, and make the exit status 1.
+func depsCommand(args []string) int {
+	flags := flag.NewFlagSet("deps", flag.ExitOnError)
+	var (
+		dot     = flags.Bool("dot", false, "write a Graphviz DOT graph instead of a tree")
+		prelude = flags.Bool("prelude", true, "include the prelude, which programs are run with")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto deps [-dot] [-prelude=false] <file>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	graph := deps.Build(flags.Arg(0), *prelude)
+
+	write := graph.WriteTree
+	if *dot {
+		write = graph.WriteDOT
+	}
+
+	if err := write(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	var problems []string
+
+	for _, use := range graph.Unresolved() {
+		pos := use.Pos.File
+		if !use.Prelude() {
+			pos += ":" + use.Pos.String()
+		}
+
+		problems = append(problems, fmt.Sprintf("%s: can't resolve use %q: %s", pos, use.Package, use.Err))
+	}
+
+	var broken []string
+	for _, f := range graph.Files {
+		if f.Err != nil {
+			broken = append(broken, f.Err.Error())
+		}
+	}
+
+	sort.Strings(broken)
+	problems = append(problems, broken...)
+
+	for _, cycle := range graph.Cycles {
+		problems = append(problems, "cycle: "+strings.Join(append(cycle, cycle[0]), " -> "))
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", problem)
+	}
+
+	if len(problems) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,267 @@
+// Package deps finds which files a program loads, by
+// following its 'use' statements without running it.
+package deps
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// errNoFiles is a use's error when no files are found, but
+// the glob was valid
+var errNoFiles = errors.New("no sources found")
+
+// A Use is a use statement, and the files its glob found
+type Use struct {
+	// Package is the string given to 'use', and Glob is
+	// what's looked for, with "./" made relative to the
+	// file it's in
+	Package string
+	Glob    string
+
+	// Pos is where the statement is. The prelude's is at
+	// line 0 of the root file.
+	Pos token.Position
+
+	// Files are the files found, in order, or nil if the
+	// use couldn't be resolved, in which case Err says why
+	Files []string
+	Err   error
+}
+
+// Prelude checks if the use is the prelude's, which every
+// program is run with
+func (u *Use) Prelude() bool {
+	return u.Pos.Line == 0
+}
+
+// A File is a source file in the graph
+type File struct {
+	Path string
+	Uses []*Use
+
+	// Err is why the file couldn't be read or parsed, in
+	// which case its uses aren't known
+	Err error
+}
+
+// Graph is the graph of the files which a program loads
+type Graph struct {
+	// Root is the program's file
+	Root string
+
+	// Files maps the paths of every file found, including
+	// the root, to the files
+	Files map[string]*File
+
+	// Cycles are the loops of files which use each other.
+	// Each starts with the file first found in the loop,
+	// which isn't repeated at the end.
+	Cycles [][]string
+}
+
+// Build makes the graph of the files a program loads,
+// following the uses in each file found. The packages are
+// found the same way as when the program is run. With
+// prelude, the root file uses the prelude, as it does when
+// it's run.
+func Build(root string, prelude bool) *Graph {
+	g := &Graph{
+		Root:  root,
+		Files: make(map[string]*File),
+	}
+
+	var (
+		queue = []string{root}
+		first = true
+	)
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		if _, ok := g.Files[path]; ok {
+			continue
+		}
+
+		f := read(path)
+		g.Files[path] = f
+
+		if first && prelude && f.Err == nil {
+			use := &Use{
+				Package: module.Prelude,
+				Glob:    module.Prelude,
+				Pos:     token.Position{File: path},
+			}
+
+			f.Uses = append([]*Use{use}, f.Uses...)
+		}
+
+		first = false
+
+		for _, use := range f.Uses {
+			use.Files, use.Err = pkg.LocateSourcesFrom(filepath.Dir(path), use.Glob)
+			if use.Err == nil && len(use.Files) == 0 {
+				use.Err = errNoFiles
+			}
+
+			queue = append(queue, use.Files...)
+		}
+	}
+
+	g.findCycles()
+
+	return g
+}
+
+// read parses a file, and finds the uses in it
+func read(path string) *File {
+	f := &File{Path: path}
+
+	text, err := literate.ReadFile(path)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+
+	parse := parser.New(text, path)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		f.Err = parse.Errors[0]
+		return f
+	}
+
+	for _, stmt := range prog.Statements {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			if use, ok := n.(*ast.UseStatement); ok {
+				f.Uses = append(f.Uses, &Use{
+					Package: use.Package,
+					Glob:    pkg.Glob(use.Package, path),
+					Pos:     use.Tok.Start,
+				})
+			}
+
+			return true
+		})
+	}
+
+	return f
+}
+
+// findCycles finds the cycles in the graph, with a depth
+// first search from the root
+func (g *Graph) findCycles() {
+	var (
+		stack []string
+		state = make(map[string]int)
+		seen  = make(map[string]bool)
+		visit func(path string)
+	)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	visit = func(path string) {
+		state[path] = visiting
+		stack = append(stack, path)
+
+		for _, dep := range g.deps(path) {
+			switch state[dep] {
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						cycle := append([]string{}, stack[i:]...)
+
+						if key := strings.Join(cycle, "\x00"); !seen[key] {
+							seen[key] = true
+							g.Cycles = append(g.Cycles, cycle)
+						}
+
+						break
+					}
+				}
+			case 0:
+				visit(dep)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[path] = visited
+	}
+
+	visit(g.Root)
+}
+
+// deps returns the files which a file uses, in order,
+// without repeats
+func (g *Graph) deps(path string) []string {
+	var (
+		deps []string
+		seen = make(map[string]bool)
+	)
+
+	if f, ok := g.Files[path]; ok {
+		for _, use := range f.Uses {
+			for _, file := range use.Files {
+				if !seen[file] {
+					seen[file] = true
+					deps = append(deps, file)
+				}
+			}
+		}
+	}
+
+	return deps
+}
+
+// InCycle checks if a file uses another in one of the
+// graph's cycles
+func (g *Graph) InCycle(from, to string) bool {
+	for _, cycle := range g.Cycles {
+		for i, path := range cycle {
+			if path == from && cycle[(i+1)%len(cycle)] == to {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Unresolved returns the uses which couldn't be resolved,
+// ordered by file and position
+func (g *Graph) Unresolved() []*Use {
+	var uses []*Use
+
+	for _, f := range g.Files {
+		for _, use := range f.Uses {
+			if use.Err != nil {
+				uses = append(uses, use)
+			}
+		}
+	}
+
+	sort.Slice(uses, func(i, j int) bool {
+		a, b := uses[i].Pos, uses[j].Pos
+
+		if a.File != b.File {
+			return a.File < b.File
+		}
+
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	return uses
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/deps"
+)
+
+func TestGraph(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	root := filepath.Join(home, "packages")
+
+	files := map[string]string{
+		"std/prelude/io.pluto": "def print $x {}\n",
+		"a/a.pluto":            "use \"b\"\n",
+		"b/b.pluto":            "use \"a\"\nuse \"c/*\"\n",
+		"c/one.pluto":          "x = 1\n",
+		"c/two.pluto":          "def f { use \"./one.pluto\" }\n",
+		"broken/broken.pluto":  "x = (\n",
+	}
+
+	for name, text := range files {
+		path := filepath.Join(root, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	main := filepath.Join(t.TempDir(), "main.pluto")
+	if err := ioutil.WriteFile(main, []byte("use \"a\"\n\nuse \"missing\"\nuse \"broken\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := Build(main, true)
+
+	var b bytes.Buffer
+	if err := g.WriteTree(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(`MAIN
+  use "std/prelude/*.pluto" (prelude)
+    ROOT/std/prelude/io.pluto
+  use "a" (line 1)
+    ROOT/a/a.pluto
+      use "b" (line 1)
+        ROOT/b/b.pluto
+          use "a" (line 1)
+            ROOT/a/a.pluto (cycle)
+          use "c/*" (line 2)
+            ROOT/c/one.pluto
+            ROOT/c/two.pluto
+              use "./one.pluto" (line 1, as "ROOT/c/one.pluto")
+                ROOT/c/one.pluto (shown above)
+  use "missing" (line 3): unresolved: no sources found
+  use "broken" (line 4)
+    ROOT/broken/broken.pluto (error)
+`, "ROOT", root, -1)
+	expected = strings.Replace(expected, "MAIN", main, 1)
+
+	if b.String() != expected {
+		t.Errorf("expected the tree:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	a, bPath := filepath.Join(root, "a", "a.pluto"), filepath.Join(root, "b", "b.pluto")
+
+	if len(g.Cycles) != 1 || strings.Join(g.Cycles[0], " ") != a+" "+bPath {
+		t.Errorf("expected a cycle between a and b, got %q", g.Cycles)
+	}
+
+	if u := g.Unresolved(); len(u) != 1 || u[0].Package != "missing" || u[0].Pos.Line != 3 {
+		t.Errorf("expected only 'missing' to be unresolved, got %d uses", len(u))
+	}
+
+	if f := g.Files[filepath.Join(root, "broken", "broken.pluto")]; f == nil || f.Err == nil {
+		t.Errorf("expected an error parsing broken.pluto")
+	}
+
+	b.Reset()
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := b.String()
+
+	for _, line := range []string{
+		"digraph deps {",
+		`"` + a + `" -> "` + bPath + `" [label="b", color=red];`,
+		`"` + main + `" -> "unresolved 1" [label="missing", style=dashed, color=red];`,
+		`[label="std/prelude/*.pluto", style=dotted];`,
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected the graph to contain %s, got:\n%s", line, dot)
+		}
+	}
+}
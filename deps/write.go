@@ -0,0 +1,156 @@
+package deps
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTree writes the graph as a tree, starting at the
+// root, with each use under the file it's in and each file
+// under the use which found it. A file which has already
+// been written isn't expanded again, and one which can't
+// be read or parsed is marked, but its error isn't written.
+// A file used again inside itself is marked as a cycle.
+func (g *Graph) WriteTree(w io.Writer) error {
+	var (
+		b     strings.Builder
+		shown = make(map[string]bool)
+		above = make(map[string]bool)
+		write func(path string, depth int)
+	)
+
+	write = func(path string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		f := g.Files[path]
+
+		switch {
+		case above[path]:
+			fmt.Fprintf(&b, "%s%s (cycle)\n", indent, path)
+			return
+		case shown[path]:
+			fmt.Fprintf(&b, "%s%s (shown above)\n", indent, path)
+			return
+		case f.Err != nil:
+			fmt.Fprintf(&b, "%s%s (error)\n", indent, path)
+			return
+		}
+
+		shown[path] = true
+		above[path] = true
+		fmt.Fprintf(&b, "%s%s\n", indent, path)
+
+		for _, use := range f.Uses {
+			fmt.Fprintf(&b, "%s  %s", indent, use.describe())
+
+			if use.Err != nil {
+				fmt.Fprintf(&b, ": unresolved: %s\n", strings.TrimPrefix(use.Err.Error(), "use: "))
+				continue
+			}
+
+			b.WriteString("\n")
+
+			for _, file := range use.Files {
+				write(file, depth+2)
+			}
+		}
+
+		above[path] = false
+	}
+
+	write(g.Root, 0)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describe describes a use in a tree
+func (u *Use) describe() string {
+	where := fmt.Sprintf("line %d", u.Pos.Line)
+	if u.Prelude() {
+		where = "prelude"
+	}
+
+	if u.Glob != u.Package {
+		where += fmt.Sprintf(", as %q", u.Glob)
+	}
+
+	return fmt.Sprintf("use %q (%s)", u.Package, where)
+}
+
+// WriteDOT writes the graph in Graphviz's DOT language.
+// Each file is a node, and each use is an edge labelled
+// with its package from the file it's in to each file it
+// found. Unresolved uses, files which couldn't be read and
+// the edges in cycles are red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("digraph deps {\n\tnode [shape=box];\n")
+
+	var (
+		written    = make(map[string]bool)
+		queue      = []string{g.Root}
+		unresolved = 0
+	)
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		if written[path] {
+			continue
+		}
+
+		written[path] = true
+		f := g.Files[path]
+
+		if f.Err != nil {
+			fmt.Fprintf(&b, "\t%s [label=%s, color=red];\n", dotQuote(path), dotQuote(path+"\n"+f.Err.Error()))
+		} else {
+			fmt.Fprintf(&b, "\t%s;\n", dotQuote(path))
+		}
+
+		for _, use := range f.Uses {
+			if use.Err != nil {
+				unresolved++
+				node := fmt.Sprintf("unresolved %d", unresolved)
+
+				fmt.Fprintf(&b, "\t%s [label=%s, style=dashed, color=red];\n", dotQuote(node), dotQuote(use.Glob+"\n"+use.Err.Error()))
+				fmt.Fprintf(&b, "\t%s -> %s [label=%s, style=dashed, color=red];\n", dotQuote(path), dotQuote(node), dotQuote(use.Package))
+
+				continue
+			}
+
+			for _, file := range use.Files {
+				attrs := "label=" + dotQuote(use.Package)
+
+				if use.Prelude() {
+					attrs += ", style=dotted"
+				}
+
+				if g.InCycle(path, file) {
+					attrs += ", color=red"
+				}
+
+				fmt.Fprintf(&b, "\t%s -> %s [%s];\n", dotQuote(path), dotQuote(file), attrs)
+				queue = append(queue, file)
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes a string as a DOT ID, with line breaks
+// as \n, which DOT shows centred
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+
+	return `"` + s + `"`
+}
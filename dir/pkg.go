@@ -5,15 +5,48 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/Zac-Garby/pluto/literate"
 )
 
 var (
 	errNoSources = errors.New("use: no sources found")
 )
 
+// Extensions are the extensions of Pluto source files, in
+// the order they're looked for.
+var Extensions = []string{".pluto", literate.Extension}
+
+// IsSource checks if name is the name of a Pluto source
+// file, either plain or literate.
+func IsSource(name string) bool {
+	for _, ext := range Extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MainSource finds the source file called name in dir,
+// i.e. name.pluto or name.lpluto.
+func MainSource(dir, name string) (string, bool) {
+	for _, ext := range Extensions {
+		file := filepath.Join(dir, name+ext)
+
+		if stat, err := os.Stat(file); err == nil && !stat.IsDir() {
+			return file, true
+		}
+	}
+
+	return "", false
+}
+
 // LocateSources finds the source files specified.
 // pkg is a glob, such as "std/io" or "std/*".
-// The package is located relative to dir.
+// The package is located relative to dir. Both
+// .pluto and literate .lpluto files are found.
 func LocateSources(dir, pkg string) ([]string, error) {
 	base := filepath.Join(dir, pkg)
 
@@ -31,23 +64,19 @@ func LocateSources(dir, pkg string) ([]string, error) {
 		}
 
 		if !stat.IsDir() {
-			if strings.HasSuffix(stat.Name(), ".pluto") {
+			if IsSource(stat.Name()) {
 				newFiles = append(newFiles, file)
 			}
 
 			continue
 		}
 
-		pfile := filepath.Join(file, stat.Name()+".pluto")
-
-		pstat, err := os.Stat(pfile)
-		if err != nil {
+		pfile, ok := MainSource(file, stat.Name())
+		if !ok {
 			return nil, nil
 		}
 
-		if !pstat.IsDir() && strings.HasSuffix(pstat.Name(), ".pluto") {
-			newFiles = append(newFiles, pfile)
-		}
+		newFiles = append(newFiles, pfile)
 	}
 
 	if len(newFiles) == 0 {
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+)
+
+// disasmCommand prints the bytecode compiled from source
+// files, or stored in .plc files, with each instruction's
+// argument resolved to the constant, name or pattern it
+// refers to. Functions and blocks are listed after the
+// code they're defined in. The output is assembly, which
+// can be saved as a .plasm file, edited, and run.
+func disasmCommand(args []string) int {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto disasm <files...>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+
+	for i, file := range flags.Args() {
+		m, err := loadModule(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			status = 1
+			continue
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+
+		if err := bytecode.Disassemble(os.Stdout, m.Unit(file)); err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+	}
+
+	return status
+}
+
+// loadModule reads a compiled .plc file, assembles a .plasm
+// file, or compiles a source file
+func loadModule(file string) (*module.Module, error) {
+	if strings.HasSuffix(file, module.Extension) {
+		return module.ReadFile(file)
+	}
+
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(file, module.AssemblyExtension) {
+		return module.Assemble(src, file)
+	}
+
+	return module.Compile(src, file)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/doc"
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// docCommand prints the documentation of a package, which
+// is a directory, a source file, or a package which can be
+// used, such as "std/io". A package is found as a use in
+// the project in -from would find it, so a locked package's
+// locked version is documented. Tests and benchmarks are
+// left out. The documentation is Markdown, or a web page
+// with -html.
+func docCommand(args []string) int {
+	flags := flag.NewFlagSet("doc", flag.ExitOnError)
+	asHTML := flags.Bool("html", false, "write a web page instead of Markdown")
+	out := flags.String("o", "", "write the documentation to `file` instead of stdout")
+	from := flags.String("from", ".", "find packages as a use in `dir` would find them")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto doc [-html] [-o file] [-from dir] [package or directory]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	target := "."
+	if flags.NArg() > 0 {
+		target = flags.Arg(0)
+	}
+
+	name, files, err := docSources(target, *from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	docs, err := doc.Read(name, files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	write := docs.WriteMarkdown
+	if *asHTML {
+		write = docs.WriteHTML
+	}
+
+	if *out == "" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(*out, write)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// docSources finds the name and source files of the
+// package to document. A package is found from the
+// directory from.
+func docSources(target, from string) (string, []string, error) {
+	stat, err := os.Stat(target)
+	if err != nil {
+		// A package's directory is documented if it has
+		// no main source file
+		for _, glob := range []string{target, target + "/*"} {
+			if files, err := pkg.LocateSourcesFrom(from, glob); err == nil && len(files) > 0 {
+				return target, files, nil
+			}
+		}
+
+		return "", nil, fmt.Errorf("doc: can't find %s", target)
+	}
+
+	if !stat.IsDir() {
+		name := strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+		return name, []string{target}, nil
+	}
+
+	infos, err := ioutil.ReadDir(target)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var files []string
+
+	for _, info := range infos {
+		name := info.Name()
+
+		if info.IsDir() || !dir.IsSource(name) || isTestFile(name) || isBenchFile(name) {
+			continue
+		}
+
+		files = append(files, filepath.Join(target, name))
+	}
+
+	sort.Strings(files)
+
+	name := filepath.Base(target)
+	if abs, err := filepath.Abs(target); err == nil {
+		name = filepath.Base(abs)
+	}
+
+	return name, files, nil
+}
+
+func isBenchFile(name string) bool {
+	for _, suffix := range benchSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,251 @@
+package doc
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/highlight"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+)
+
+// Function is the documentation of a function
+type Function struct {
+	// Pattern is the function's pattern, such as
+	// "double $x", and Params are its parameters'
+	// names, in order
+	Pattern string
+	Params  []string
+	Doc     string
+
+	// File and Line are where the function is defined, if
+	// it's known
+	File string
+	Line int
+}
+
+// NewFunction documents a function with a pattern and a
+// doc comment
+func NewFunction(pattern []ast.Expression, doc string) Function {
+	var (
+		words  = make([]string, len(pattern))
+		params []string
+	)
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words[i] = "$" + param.Name
+			params = append(params, param.Name)
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return Function{
+		Pattern: strings.Join(words, " "),
+		Params:  params,
+		Doc:     doc,
+	}
+}
+
+// Package is the documentation of a package's functions
+type Package struct {
+	Name      string
+	Doc       string
+	Functions []Function
+}
+
+// Read documents the functions defined at the top level
+// of some source files, which make up a package. The
+// package's doc is made from the files' docs.
+func Read(name string, files []string) (*Package, error) {
+	var (
+		pkg  = &Package{Name: name}
+		docs []string
+	)
+
+	for _, file := range files {
+		src, err := literate.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		parse := parser.New(src, file)
+		prog := parse.Parse()
+
+		if len(parse.Errors) > 0 {
+			return nil, parse.Errors[0]
+		}
+
+		if prog.Doc != "" {
+			docs = append(docs, prog.Doc)
+		}
+
+		for _, stmt := range prog.Statements {
+			def, ok := stmt.(*ast.FunctionDefinition)
+			if !ok {
+				continue
+			}
+
+			fn := NewFunction(def.Pattern, def.Doc)
+			fn.File = file
+			fn.Line = def.Tok.Start.Line
+
+			pkg.Functions = append(pkg.Functions, fn)
+		}
+	}
+
+	pkg.Doc = strings.Join(docs, "\n\n")
+
+	return pkg, nil
+}
+
+// Markdown returns the function's documentation as
+// Markdown, starting with a level 3 heading.
+func (f Function) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### `%s`\n", f.Pattern)
+
+	if len(f.Params) > 0 {
+		params := make([]string, len(f.Params))
+		for i, param := range f.Params {
+			params[i] = "`$" + param + "`"
+		}
+
+		fmt.Fprintf(&b, "\nParameters: %s\n", strings.Join(params, ", "))
+	}
+
+	if f.Doc != "" {
+		fmt.Fprintf(&b, "\n%s\n", f.Doc)
+	}
+
+	return b.String()
+}
+
+// WriteMarkdown writes the package's documentation as
+// Markdown
+func (p *Package) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", p.Name)
+
+	if p.Doc != "" {
+		fmt.Fprintf(&b, "\n%s\n", p.Doc)
+	}
+
+	if len(p.Functions) > 0 {
+		b.WriteString("\n## Functions\n")
+	}
+
+	for _, fn := range p.Functions {
+		fmt.Fprintf(&b, "\n%s", fn.Markdown())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: auto; }
+code, pre { background: #f4f4f4; }
+.params { color: #555; }
+</style>
+</head>
+<body>
+`
+
+// WriteHTML writes the package's documentation as a web
+// page, with a list of the functions linking to their
+// documentation.
+func (p *Package) WriteHTML(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, htmlHeader, html.EscapeString(p.Name))
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(p.Name))
+	b.WriteString(htmlText(p.Doc))
+
+	if len(p.Functions) > 0 {
+		b.WriteString("<h2>Functions</h2>\n<ul>\n")
+
+		for i, fn := range p.Functions {
+			fmt.Fprintf(&b, "<li><a href=\"#fn%d\"><code>%s</code></a></li>\n", i, html.EscapeString(fn.Pattern))
+		}
+
+		b.WriteString("</ul>\n")
+	}
+
+	for i, fn := range p.Functions {
+		fmt.Fprintf(&b, "<h3 id=\"fn%d\"><code>%s</code></h3>\n", i, html.EscapeString(fn.Pattern))
+
+		if len(fn.Params) > 0 {
+			params := make([]string, len(fn.Params))
+			for i, param := range fn.Params {
+				params[i] = "<code>$" + html.EscapeString(param) + "</code>"
+			}
+
+			fmt.Fprintf(&b, "<p class=\"params\">Parameters: %s</p>\n", strings.Join(params, ", "))
+		}
+
+		b.WriteString(htmlText(fn.Doc))
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlText converts doc text to HTML. Paragraphs are
+// separated by blank lines, and indented lines are
+// preformatted and highlighted, such as examples of code.
+func htmlText(text string) string {
+	var (
+		b    strings.Builder
+		para []string
+		pre  []string
+	)
+
+	flush := func() {
+		if len(para) > 0 {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(strings.Join(para, "\n")))
+			para = nil
+		}
+
+		if len(pre) > 0 {
+			fmt.Fprintf(&b, "<pre>%s</pre>\n", highlight.HTML(strings.Join(pre, "\n"), highlight.DefaultTheme))
+			pre = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			if len(para) > 0 {
+				flush()
+			}
+
+			pre = append(pre, line)
+		default:
+			if len(pre) > 0 {
+				flush()
+			}
+
+			para = append(para, line)
+		}
+	}
+
+	flush()
+
+	return b.String()
+}
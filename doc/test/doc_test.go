@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/doc"
+)
+
+const source = `# Maths helpers.
+
+# add adds two numbers.
+def add $a to $b {
+    return $a + $b
+}
+
+def pi {
+    return 3.14
+}
+`
+
+const markdown = "# maths\n" +
+	"\n" +
+	"Maths helpers.\n" +
+	"\n" +
+	"## Functions\n" +
+	"\n" +
+	"### `add $a to $b`\n" +
+	"\n" +
+	"Parameters: `$a`, `$b`\n" +
+	"\n" +
+	"add adds two numbers.\n" +
+	"\n" +
+	"### `pi`\n"
+
+func TestMarkdown(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maths.pluto")
+	if err := ioutil.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := Read("maths", []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fn := pkg.Functions[0]; fn.File != file || fn.Line != 4 {
+		t.Errorf("expected 'add $a to $b' to be at %s:4, got %s:%d", file, fn.File, fn.Line)
+	}
+
+	var b bytes.Buffer
+	if err := pkg.WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != markdown {
+		t.Errorf("expected:\n%s\ngot:\n%s", markdown, b.String())
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A package is documented as a project would use it, so
+// with -from, its locked version is documented
+func TestDocFrom(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"project/pluto.lock": `{"packages": {"maths": {"version": "1.0.0", "checksum": ""}}}`,
+	})
+
+	writeFiles(t, filepath.Join(os.Getenv("PLUTO"), "packages"), map[string]string{
+		"maths/maths.pluto":       "# Returns an old answer\ndef old answer { return 0 }\n",
+		"maths@1.0.0/maths.pluto": "# Returns the answer\ndef new answer { return 42 }\n",
+	})
+
+	chdir(t, dir)
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"maths"}, "old answer"},
+		{[]string{"-from", "project", "maths"}, "new answer"},
+	}
+
+	for _, test := range tests {
+		var status int
+
+		out := capture(t, func() {
+			status = docCommand(test.args)
+		})
+
+		if status != 0 || !strings.Contains(out, test.expected) {
+			t.Errorf("%v: expected the status 0 and %q in the output, got the status %d and:\n%s", test.args, test.expected, status, out)
+		}
+	}
+}
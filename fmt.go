@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/format"
+	"github.com/Zac-Garby/pluto/literate"
+)
+
+// fmtCommand formats source files in the canonical style.
+// By default, the formatted code is printed. With -w, the
+// files are rewritten instead, and with -check, the names
+// of any unformatted files are printed and the exit status
+// is 1. Directories are searched for .pluto files, and if
+// no files are given, stdin is formatted to stdout.
+// Literate files aren't formatted, since their prose would
+// be lost.
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the files instead of stdout")
+	check := flags.Bool("check", false, "list the files which aren't formatted, and change nothing")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto fmt [-w] [-check] [files...]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+
+		out, err := format.Source(src, "<stdin>")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+
+		if *check {
+			if !bytes.Equal(src, out) {
+				fmt.Println("<stdin>")
+				return 1
+			}
+
+			return 0
+		}
+
+		os.Stdout.Write(out)
+		return 0
+	}
+
+	files, err := sourceFiles(flags.Args(), ".pluto")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	status := 0
+
+	for _, file := range files {
+		if literate.IsLiterate(file) {
+			fmt.Fprintf(os.Stderr, "pluto: %s: literate files can't be formatted\n", file)
+			status = 1
+			continue
+		}
+
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			status = 1
+			continue
+		}
+
+		out, err := format.Source(src, file)
+		if err != nil {
+			// A parse error already starts with the file's name
+			if err == format.ErrChanged {
+				err = fmt.Errorf("%s: %s", file, err)
+			}
+
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			status = 1
+			continue
+		}
+
+		switch {
+		case *check:
+			if !bytes.Equal(src, out) {
+				fmt.Println(file)
+				status = 1
+			}
+		case *write:
+			if bytes.Equal(src, out) {
+				continue
+			}
+
+			if err := ioutil.WriteFile(file, out, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+				status = 1
+			}
+		default:
+			os.Stdout.Write(out)
+		}
+	}
+
+	return status
+}
+
+// sourceFiles expands any directories in paths to the
+// files inside them whose names end with one of suffixes.
+func sourceFiles(paths []string, suffixes ...string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stat.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			for _, suffix := range suffixes {
+				if strings.HasSuffix(file, suffix) {
+					files = append(files, file)
+					break
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,500 @@
+package format
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/lexer"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// These mirror the parser's precedences, with a couple
+// of extra levels for expressions which never need to
+// be wrapped in parentheses.
+const (
+	lowest = iota
+	assign
+	question
+	or
+	and
+	bitOr
+	bitAnd
+	equals
+	compare
+	sum
+	product
+	exp
+	prefix
+	methodCall
+	index
+	call
+	primary
+)
+
+var infixPrecedences = map[string]int{
+	"?":  question,
+	"||": or,
+	"&&": and,
+	"|":  bitOr,
+	"&":  bitAnd,
+	"==": equals,
+	"!=": equals,
+	"<":  compare,
+	">":  compare,
+	"<=": compare,
+	">=": compare,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  product,
+	"%":  product,
+	"**": exp,
+	"//": exp,
+}
+
+var shorthandAssignments = map[token.Type]bool{
+	token.PlusEquals:         true,
+	token.MinusEquals:        true,
+	token.StarEquals:         true,
+	token.ExpEquals:          true,
+	token.SlashEquals:        true,
+	token.FloorDivEquals:     true,
+	token.ModEquals:          true,
+	token.OrEquals:           true,
+	token.AndEquals:          true,
+	token.BitOrEquals:        true,
+	token.BitAndEquals:       true,
+	token.QuestionMarkEquals: true,
+}
+
+// precedenceOf returns how tightly an expression binds,
+// i.e. the lowest precedence its surroundings can have
+// without it needing to be put in parentheses.
+func precedenceOf(n ast.Expression) int {
+	switch node := n.(type) {
+	case *ast.AssignExpression:
+		return assign
+	case *ast.InfixExpression:
+		return infixPrecedences[node.Operator]
+	case *ast.PrefixExpression:
+		return prefix
+	case *ast.QualifiedFunctionCall:
+		return methodCall
+	case *ast.DotExpression, *ast.IndexExpression:
+		return index
+	case *ast.FunctionCall:
+		return call
+	case *ast.IfExpression:
+		return lowest
+	default:
+		return primary
+	}
+}
+
+// expression writes an expression, in parentheses if it
+// binds less tightly than min.
+func (p *printer) expression(n ast.Expression, min int) {
+	if precedenceOf(n) < min {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch node := n.(type) {
+	case *ast.Identifier:
+		p.write(node.Value)
+	case *ast.Number:
+		p.write(strconv.FormatFloat(node.Value, 'f', -1, 64))
+	case *ast.Boolean:
+		p.write(strconv.FormatBool(node.Value))
+	case *ast.String:
+		p.write(quote(node.Value))
+	case *ast.Char:
+		p.write("'", string(node.Value), "'")
+	case *ast.Null:
+		p.write("null")
+	case *ast.Parameter:
+		p.write("$", node.Name)
+	case *ast.Argument:
+		p.expression(node.Value, min)
+	case *ast.Tuple:
+		p.tuple(node)
+	case *ast.Array:
+		p.write("[")
+		p.list(node.Elements)
+		p.write("]")
+	case *ast.Map:
+		p.mapLiteral(node)
+	case *ast.BlockLiteral:
+		p.blockLiteral(node)
+	case *ast.AssignExpression:
+		p.assign(node)
+	case *ast.PrefixExpression:
+		p.write(node.Operator)
+		p.expression(node.Right, prefix+1)
+	case *ast.InfixExpression:
+		precedence := infixPrecedences[node.Operator]
+
+		p.expression(node.Left, precedence)
+		p.write(" ", node.Operator, " ")
+		p.expression(node.Right, precedence+1)
+	case *ast.DotExpression:
+		p.operand(node.Left, index)
+		p.write(".")
+		p.expression(node.Right, index+1)
+	case *ast.IndexExpression:
+		p.operand(node.Collection, index)
+		p.write("[")
+		p.expression(node.Index, lowest)
+		p.write("]")
+	case *ast.FunctionCall:
+		p.functionCall(node)
+	case *ast.QualifiedFunctionCall:
+		p.operand(node.Base, methodCall)
+		p.write(":")
+		p.pattern(node.Pattern)
+	case *ast.IfExpression:
+		p.ifExpression(node)
+	case *ast.EmissionExpression:
+		p.emission(node)
+	}
+}
+
+// operand writes the left-hand side of a dot, index or
+// qualified call. Function calls are put in parentheses
+// even though they don't need to be, because "f x.y"
+// looks like it should mean "f (x.y)".
+func (p *printer) operand(n ast.Expression, min int) {
+	if _, ok := n.(*ast.FunctionCall); ok {
+		min = primary
+	}
+
+	p.expression(n, min)
+}
+
+func (p *printer) list(exprs []ast.Expression) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		p.expression(expr, lowest)
+	}
+}
+
+func (p *printer) tuple(node *ast.Tuple) {
+	p.write("(")
+	p.list(node.Value)
+
+	if len(node.Value) == 1 {
+		p.write(",")
+	}
+
+	p.write(")")
+}
+
+func (p *printer) mapLiteral(node *ast.Map) {
+	if len(node.Pairs) == 0 {
+		p.write("[:]")
+		return
+	}
+
+	var keys []ast.Expression
+
+	for key := range node.Pairs {
+		keys = append(keys, key)
+	}
+
+	// The pairs are stored in a Go map, so they're sorted
+	// back into the order they were written in
+	sort.Slice(keys, func(i, j int) bool {
+		return before(keys[i].Token().Start, keys[j].Token().Start)
+	})
+
+	p.write("[")
+
+	for i, key := range keys {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		p.expression(key, primary)
+		p.write(": ")
+		p.expression(node.Pairs[key], lowest)
+	}
+
+	p.write("]")
+}
+
+func (p *printer) assign(node *ast.AssignExpression) {
+	p.expression(node.Name, assign+1)
+
+	if infix, ok := node.Value.(*ast.InfixExpression); ok && shorthandAssignments[node.Tok.Type] && infix.Left == node.Name {
+		p.write(" ", node.Tok.Literal, " ")
+		p.expression(infix.Right, lowest)
+		return
+	}
+
+	p.write(" = ")
+	p.expression(node.Value, lowest)
+}
+
+// blockLiteral writes a block literal. If its body is a
+// single, short statement, it goes on one line.
+func (p *printer) blockLiteral(node *ast.BlockLiteral) {
+	var (
+		body = node.Body.(*ast.BlockStatement)
+		end  = p.closingBrace(node.Tok.Start)
+	)
+
+	p.write("{")
+
+	if len(node.Params) > 0 {
+		var params []string
+
+		for _, param := range node.Params {
+			params = append(params, param.Token().Literal)
+		}
+
+		p.write(" |", strings.Join(params, ", "), "| ->")
+	}
+
+	if p.hasCommentsBefore(end) {
+		p.blockBody(body, end)
+		p.write("}")
+		return
+	}
+
+	switch len(body.Statements) {
+	case 0:
+		if len(node.Params) > 0 {
+			p.write(" ")
+		}
+
+		p.write("}")
+		return
+	case 1:
+		inline := &printer{closing: p.closing}
+		inline.statement(body.Statements[0])
+
+		if line := inline.buf.String(); !strings.Contains(line, "\n") {
+			p.write(" ", line, " }")
+			return
+		}
+	}
+
+	p.blockBody(body, end)
+	p.write("}")
+}
+
+// functionCall writes an unqualified function call. The
+// call is usually written with the first item at the
+// start, e.g. "print $x" or "5 times", but needs a
+// backslash if that wouldn't parse as the same call.
+func (p *printer) functionCall(node *ast.FunctionCall) {
+	var (
+		first = node.Pattern[0]
+		rest  = node.Pattern[1:]
+	)
+
+	if needsBackslash(node.Pattern) {
+		p.write("\\")
+		p.pattern(node.Pattern)
+		return
+	}
+
+	if arg, ok := first.(*ast.Argument); ok {
+		switch arg.Value.(type) {
+		case *ast.Parameter, *ast.Number, *ast.String, *ast.Char, *ast.Tuple, *ast.BlockLiteral:
+			p.expression(arg.Value, primary)
+		default:
+			p.write("(")
+			p.expression(arg.Value, lowest)
+			p.write(")")
+		}
+	} else {
+		p.write(first.Token().Literal)
+	}
+
+	p.write(" ")
+	p.pattern(rest)
+}
+
+func needsBackslash(pattern []ast.Expression) bool {
+	first, ok := pattern[0].(*ast.Identifier)
+	if !ok {
+		// An argument at the start of a call is only ever
+		// an identifier if the call began with a backslash
+		arg := pattern[0].(*ast.Argument)
+		_, isID := arg.Value.(*ast.Identifier)
+
+		return isID
+	}
+
+	if len(pattern) == 1 || token.IsKeyword(first.Tok.Type) {
+		return true
+	}
+
+	if second, ok := pattern[1].(*ast.Identifier); ok {
+		if t, isKeyword := token.Keywords[second.Value]; isKeyword {
+			return t != token.True && t != token.False && t != token.Null
+		}
+	}
+
+	return false
+}
+
+// pattern writes the items of a pattern, separated by
+// spaces, as they'd be parsed after a backslash.
+func (p *printer) pattern(items []ast.Expression) {
+	for i, item := range items {
+		if i > 0 {
+			p.write(" ")
+		}
+
+		arg, ok := item.(*ast.Argument)
+		if !ok {
+			p.write(item.Token().Literal)
+			continue
+		}
+
+		switch val := arg.Value.(type) {
+		case *ast.Identifier:
+			p.write("$", val.Value)
+		case *ast.Number, *ast.String, *ast.Char, *ast.Tuple, *ast.BlockLiteral:
+			p.expression(val, primary)
+		default:
+			p.write("(")
+			p.expression(val, lowest)
+			p.write(")")
+		}
+	}
+}
+
+func (p *printer) ifExpression(node *ast.IfExpression) {
+	p.write("if (")
+	p.expression(node.Condition, lowest)
+	p.write(") ")
+	p.block(node.Consequence, node.Consequence.Token().Start)
+
+	if node.Alternative == nil {
+		return
+	}
+
+	if elif, ok := elifOf(node.Alternative); ok {
+		p.write(" el")
+		p.ifExpression(elif)
+		return
+	}
+
+	p.write(" else ")
+	p.block(node.Alternative, node.Alternative.Token().Start)
+}
+
+// elifOf checks if the alternative of an if expression is
+// just another if expression, which is how the parser
+// represents an elif.
+func elifOf(alt ast.Statement) (*ast.IfExpression, bool) {
+	block, ok := alt.(*ast.BlockStatement)
+	if !ok || len(block.Statements) != 1 {
+		return nil, false
+	}
+
+	stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil, false
+	}
+
+	ifExpr, ok := stmt.Expr.(*ast.IfExpression)
+
+	return ifExpr, ok
+}
+
+func (p *printer) emission(node *ast.EmissionExpression) {
+	p.write("<")
+
+	for i, item := range node.Items {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		if item.IsInstruction {
+			p.write(item.Instruction)
+
+			if item.Argument != 0 || hasArg(item.Instruction) {
+				p.write(" ", strconv.Itoa(int(item.Argument)))
+			}
+
+			continue
+		}
+
+		// An identifier with the same name as an instruction
+		// would be read as the instruction
+		if id, ok := item.Exp.(*ast.Identifier); ok && hasInstruction(id.Value) {
+			p.write("(", id.Value, ")")
+			continue
+		}
+
+		p.expression(item.Exp, compare+1)
+	}
+
+	p.write(">")
+}
+
+func hasInstruction(name string) bool {
+	for _, data := range bytecode.Instructions {
+		if data.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasArg(name string) bool {
+	for _, data := range bytecode.Instructions {
+		if data.Name == name {
+			return data.HasArg
+		}
+	}
+
+	return false
+}
+
+// quote returns a string literal which the lexer will
+// read back as str. Double quotes are used if possible,
+// otherwise it falls back to a raw string.
+func quote(str string) string {
+	replacer := strings.NewReplacer(
+		"\n", `\n`,
+		"\a", `\a`,
+		"\b", `\b`,
+		"\f", `\f`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"\v", `\v`,
+	)
+
+	quoted := `"` + replacer.Replace(str) + `"`
+
+	if lit, ok := lexString(quoted); ok && lit == str {
+		return quoted
+	}
+
+	if !strings.Contains(str, "`") {
+		return "`" + str + "`"
+	}
+
+	return quoted
+}
+
+func lexString(src string) (string, bool) {
+	var (
+		next = lexer.Lexer(src, "")
+		tok  = next()
+	)
+
+	return tok.Literal, tok.Type == token.String && tok.End.Column == len(src)
+}
@@ -0,0 +1,195 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/lexer"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+const indentation = "    "
+
+// ErrChanged is returned by Source if the formatted code
+// doesn't parse to the same tree as the original. It
+// should never happen, but the source is left alone if
+// it does.
+var ErrChanged = errors.New("format: formatting would change the parse tree")
+
+// eof is a position after the end of any source file
+var eof = token.Position{Line: math.MaxInt32}
+
+// Source formats some Pluto source code in the canonical
+// style, keeping its comments. If there's a parse error,
+// the first one is returned.
+func Source(src []byte, file string) ([]byte, error) {
+	var (
+		parse = parser.New(string(src), file)
+		prog  = parse.Parse()
+	)
+
+	if len(parse.Errors) > 0 {
+		return nil, parse.Errors[0]
+	}
+
+	p := newPrinter(string(src), file, parse.Comments)
+	p.program(prog)
+
+	out := p.buf.Bytes()
+
+	var (
+		check     = parser.New(string(out), file)
+		checkProg = check.Parse()
+	)
+
+	if len(check.Errors) > 0 || checkProg.Tree() != prog.Tree() {
+		return nil, ErrChanged
+	}
+
+	return out, nil
+}
+
+// Program returns the canonical source code of a parsed
+// program. Since the AST doesn't store comments, there
+// won't be any in the output.
+func Program(prog ast.Program) string {
+	p := newPrinter("", "", nil)
+	p.program(prog)
+
+	return p.buf.String()
+}
+
+// printer writes out an AST as source code. The source
+// it was parsed from is used to find where the blocks
+// end, where the comments should go, and which blank
+// lines to keep.
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+	first  bool // whether nothing has been written in the current block yet
+
+	lines    []string
+	comments []token.Token
+	closing  map[token.Position]token.Position // the position of each {'s matching }
+}
+
+func newPrinter(src, file string, comments []token.Token) *printer {
+	p := &printer{
+		first:    true,
+		comments: comments,
+		closing:  make(map[token.Position]token.Position),
+	}
+
+	if src == "" {
+		return p
+	}
+
+	p.lines = strings.Split(src, "\n")
+
+	var (
+		next  = lexer.Lexer(src, file)
+		opens []token.Position
+	)
+
+	for tok := next(); tok.Type != token.EOF; tok = next() {
+		switch tok.Type {
+		case token.LeftBrace:
+			opens = append(opens, tok.Start)
+		case token.RightBrace:
+			if len(opens) > 0 {
+				p.closing[opens[len(opens)-1]] = tok.Start
+				opens = opens[:len(opens)-1]
+			}
+		}
+	}
+
+	return p
+}
+
+func (p *printer) program(prog ast.Program) {
+	p.statements(prog.Statements, eof)
+
+	if p.buf.Len() > 0 {
+		p.write("\n")
+	}
+}
+
+func (p *printer) write(strs ...string) {
+	for _, str := range strs {
+		p.buf.WriteString(str)
+	}
+}
+
+// newline starts a new line at the current indentation,
+// unless nothing has been written at all.
+func (p *printer) newline() {
+	if p.buf.Len() > 0 {
+		p.write("\n", strings.Repeat(indentation, p.indent))
+	}
+}
+
+// item starts a new line for a statement or comment,
+// leaving a blank line before it if there was one in
+// the source.
+func (p *printer) item(line int) {
+	if !p.first && p.blankBefore(line) {
+		p.write("\n")
+	}
+
+	p.newline()
+	p.first = false
+}
+
+func (p *printer) blankBefore(line int) bool {
+	return line >= 2 && line-2 < len(p.lines) && strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// isTrailing checks if there is code before a comment
+// on the same line.
+func (p *printer) isTrailing(comment token.Token) bool {
+	var (
+		line = p.lines[comment.Start.Line-1]
+		col  = comment.Start.Column - 1
+	)
+
+	return col <= len(line) && strings.TrimSpace(line[:col]) != ""
+}
+
+// flushComments writes every comment before pos which
+// hasn't already been written. A comment which followed
+// some code in the source is put at the end of the
+// current line.
+func (p *printer) flushComments(pos token.Position) {
+	for len(p.comments) > 0 && before(p.comments[0].Start, pos) {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if p.buf.Len() == 0 || !p.isTrailing(comment) {
+			p.item(comment.Start.Line)
+		} else {
+			p.write(" ")
+		}
+
+		p.write("#", strings.TrimRight(comment.Literal, " \t\r"))
+	}
+}
+
+func (p *printer) hasCommentsBefore(pos token.Position) bool {
+	return len(p.comments) > 0 && before(p.comments[0].Start, pos)
+}
+
+func (p *printer) closingBrace(open token.Position) token.Position {
+	if end, ok := p.closing[open]; ok {
+		return end
+	}
+
+	return eof
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
@@ -0,0 +1,112 @@
+package format
+
+import (
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// statements writes a list of statements, each on its
+// own line, along with any comments before end, which
+// is where the enclosing block finishes.
+func (p *printer) statements(stmts []ast.Statement, end token.Position) {
+	for i, stmt := range stmts {
+		start := stmt.Token().Start
+		p.flushComments(start)
+
+		p.item(start.Line)
+		p.statement(stmt)
+
+		next := end
+		if i+1 < len(stmts) {
+			next = stmts[i+1].Token().Start
+		}
+
+		p.flushComments(next)
+	}
+
+	p.flushComments(end)
+}
+
+func (p *printer) statement(n ast.Statement) {
+	switch node := n.(type) {
+	case *ast.ExpressionStatement:
+		p.expression(node.Expr, lowest)
+	case *ast.BlockStatement:
+		p.block(node, node.Tok.Start)
+	case *ast.FunctionDefinition:
+		p.functionDefinition(node)
+	case *ast.ReturnStatement:
+		p.write("return")
+
+		if node.Value != nil {
+			p.write(" ")
+			p.expression(node.Value, lowest)
+		}
+	case *ast.NextStatement:
+		p.write("next")
+	case *ast.BreakStatement:
+		p.write("break")
+	case *ast.UseStatement:
+		p.write("use ", quote(node.Package))
+	case *ast.WhileLoop:
+		p.write("while (")
+		p.expression(node.Condition, lowest)
+		p.write(") ")
+		p.block(node.Body, node.Body.Token().Start)
+	case *ast.ForLoop:
+		p.write("for (")
+		p.expression(node.Init, lowest)
+		p.write("; ")
+		p.expression(node.Condition, lowest)
+		p.write("; ")
+		p.expression(node.Increment, lowest)
+		p.write(") ")
+		p.block(node.Body, node.Body.Token().Start)
+	}
+}
+
+// block writes a block statement over multiple lines.
+// open is the position of the block's opening brace.
+func (p *printer) block(n ast.Statement, open token.Position) {
+	var (
+		block = n.(*ast.BlockStatement)
+		end   = p.closingBrace(open)
+	)
+
+	if len(block.Statements) == 0 && !p.hasCommentsBefore(end) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.blockBody(block, end)
+	p.write("}")
+}
+
+// blockBody writes the statements in a block on their
+// own lines, indented, leaving the printer at the start
+// of the line where the closing brace should go.
+func (p *printer) blockBody(block *ast.BlockStatement, end token.Position) {
+	p.indent++
+	p.first = true
+
+	p.statements(block.Statements, end)
+
+	p.indent--
+	p.first = false
+	p.newline()
+}
+
+func (p *printer) functionDefinition(node *ast.FunctionDefinition) {
+	p.write("def ")
+
+	for _, item := range node.Pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			p.write("$", param.Name, " ")
+		} else {
+			p.write(item.Token().Literal, " ")
+		}
+	}
+
+	p.block(node.Body, node.Body.Token().Start)
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/format"
+)
+
+func TestFormat(t *testing.T) {
+	cases := map[string]string{
+		// Spacing
+		"x=1+2*3":    "x = 1 + 2 * 3\n",
+		"x  +=   1":  "x += 1\n",
+		"a; b":       "a\nb\n",
+		"a\n\n\n\nb": "a\n\nb\n",
+
+		// Parentheses are only kept where they're needed
+		"(1 + 2) * 3":   "(1 + 2) * 3\n",
+		"1 + (2 * 3)":   "1 + 2 * 3\n",
+		"x - (y - z)":   "x - (y - z)\n",
+		"(x - y) - z":   "x - y - z\n",
+		"(print $x).y":  "(print $x).y\n",
+		"-(x + y)":      "-(x + y)\n",
+		"(x = y) + z":   "(x = y) + z\n",
+		"print (x + y)": "print (x + y)\n",
+
+		// Literals
+		"[1,2,3]":         "[1, 2, 3]\n",
+		`["a":1,"b":2]`:   "[\"a\": 1, \"b\": 2]\n",
+		"[ : ]":           "[:]\n",
+		"(1,)":            "(1,)\n",
+		"`a\nb`":          "\"a\\nb\"\n",
+		"`\"x\"`":         "`\"x\"`\n",
+		"{|a,b|->a+b}":    "{ |a, b| -> a + b }\n",
+		"{ |a| -> }":      "{ |a| -> }\n",
+		"<$x,PRINT_LINE>": "<$x, PRINT_LINE>\n",
+
+		// Calls
+		"\\fib 10":   "fib 10\n",
+		"\\x":        "\\x\n",
+		"5 times {}": "5 times {}\n",
+		"x:y $z":     "x:y $z\n",
+
+		// Blocks
+		"def f $x { return $x }":    "def f $x {\n    return $x\n}\n",
+		"if(a){b}elif(c){d}else{e}": "if (a) {\n    b\n} elif (c) {\n    d\n} else {\n    e\n}\n",
+		"while (true) {}":           "while (true) {}\n",
+
+		// Comments
+		"# a\nx # b":         "# a\nx # b\n",
+		"x = {\n# a\ny\n}":   "x = {\n    # a\n    y\n}\n",
+		"def f {   #a   \n}": "def f { #a\n}\n",
+	}
+
+	for in, expected := range cases {
+		out, err := Source([]byte(in), "<test suite>")
+		if err != nil {
+			t.Errorf("could not format '%s': %s", in, err)
+			continue
+		}
+
+		if string(out) != expected {
+			t.Errorf("formatting '%s' gave '%s', expected '%s'", in, out, expected)
+			continue
+		}
+
+		again, err := Source(out, "<test suite>")
+		if err != nil || string(again) != string(out) {
+			t.Errorf("formatting '%s' is not idempotent", in)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// getCommand installs the dependencies of the project in
+// the working directory. Any packages given as arguments,
+// written as name or name@constraint, are added to the
+// manifest first. If the lockfile still satisfies the
+// manifest, the locked versions are installed; otherwise
+// the dependencies are resolved again and the lockfile is
+// rewritten.
+func getCommand(args []string) int {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	registry := flags.String("registry", "", "the registry to fetch packages from (default: the manifest's, or $PLUTO/registry)")
+	update := flags.Bool("update", false, "ignore the lockfile and resolve the newest allowed versions")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto get [-registry dir] [-update] [name[@constraint]...]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if err := get(*registry, *update, flags.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func get(location string, update bool, packages []string) error {
+	project, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := pkg.ReadManifest(project)
+	if os.IsNotExist(err) && len(packages) > 0 {
+		manifest = &pkg.Manifest{
+			Name:         filepath.Base(project),
+			Version:      "0.1.0",
+			Dependencies: make(map[string]string),
+		}
+	} else if os.IsNotExist(err) {
+		return fmt.Errorf("no %s in the current directory", pkg.ManifestFile)
+	} else if err != nil {
+		return err
+	}
+
+	reg, err := projectRegistry(location, project, manifest)
+	if err != nil {
+		return err
+	}
+
+	if len(packages) > 0 {
+		if err := addDependencies(manifest, reg, packages); err != nil {
+			return err
+		}
+	}
+
+	lock, err := pkg.ReadLock(project)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if lock == nil || update || !lock.Satisfies(manifest) {
+		lock, err = pkg.Resolve(manifest, reg)
+		if err != nil {
+			return err
+		}
+
+		if err := lock.Write(project); err != nil {
+			return err
+		}
+	}
+
+	// The manifest is only changed once the new
+	// dependencies are known to be resolvable
+	if len(packages) > 0 {
+		if err := manifest.Write(project); err != nil {
+			return err
+		}
+	}
+
+	installed, err := pkg.Install(lock, reg)
+	for _, name := range installed {
+		fmt.Printf("installed %s\n", name)
+	}
+
+	return err
+}
+
+// projectRegistry opens the registry at location or, if
+// that's empty, the one named in the manifest. A relative
+// path in the manifest is relative to the project.
+func projectRegistry(location, project string, manifest *pkg.Manifest) (pkg.Registry, error) {
+	if location == "" && manifest.Registry != "" {
+		location = manifest.Registry
+
+		if !filepath.IsAbs(location) && !strings.Contains(location, "://") {
+			location = filepath.Join(project, location)
+		}
+	}
+
+	return pkg.OpenRegistry(location)
+}
+
+// addDependencies adds packages, written as name or
+// name@constraint, to a manifest. Without a constraint,
+// any version compatible with the newest release is
+// allowed.
+func addDependencies(manifest *pkg.Manifest, reg pkg.Registry, packages []string) error {
+	for _, arg := range packages {
+		var (
+			parts      = strings.SplitN(arg, "@", 2)
+			name       = parts[0]
+			constraint string
+		)
+
+		if !pkg.ValidName(name) {
+			return fmt.Errorf("invalid package name '%s'", name)
+		}
+
+		if len(parts) == 2 {
+			constraint = parts[1]
+
+			if _, err := pkg.ParseConstraint(constraint); err != nil {
+				return err
+			}
+		} else {
+			latest, err := latestRelease(reg, name)
+			if err != nil {
+				return err
+			}
+
+			constraint = "^" + latest.String()
+		}
+
+		manifest.Dependencies[name] = constraint
+	}
+
+	return nil
+}
+
+func latestRelease(reg pkg.Registry, name string) (pkg.Version, error) {
+	versions, err := reg.Versions(name)
+	if err != nil {
+		return pkg.Version{}, err
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Pre == "" {
+			return versions[i], nil
+		}
+	}
+
+	return pkg.Version{}, fmt.Errorf("no releases of %s found", name)
+}
@@ -0,0 +1,15 @@
+module github.com/Zac-Garby/pluto
+
+go 1.21
+
+require (
+	github.com/chzyer/readline v1.5.1
+	github.com/fatih/color v1.10.0
+	github.com/mitchellh/go-homedir v1.1.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.8 // indirect
+	github.com/mattn/go-isatty v0.0.14 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+)
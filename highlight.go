@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Zac-Garby/pluto/highlight"
+	"github.com/Zac-Garby/pluto/literate"
+)
+
+// highlightCommand prints a source file highlighted as HTML,
+// with -html, or with ANSI escape codes for a terminal, with
+// -ansi. The HTML is a <pre> element which can be pasted
+// into a page. If no file is given, stdin is highlighted.
+// Only the code in a literate file is highlighted.
+func highlightCommand(args []string) int {
+	flags := flag.NewFlagSet("highlight", flag.ExitOnError)
+	var (
+		asHTML    = flags.Bool("html", false, "write HTML, with the styles inline")
+		asANSI    = flags.Bool("ansi", false, "write text with ANSI escape codes, for a terminal")
+		themePath = flags.String("theme", "", "read the theme from a JSON `file`, mapping classes to styles")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto highlight -html|-ansi [-theme file] [file]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if *asHTML == *asANSI || flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	theme := highlight.DefaultTheme
+
+	if *themePath != "" {
+		f, err := os.Open(*themePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+
+		theme, err = highlight.ReadTheme(f)
+		f.Close()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s: %s\n", filepath.Base(*themePath), err)
+			return 1
+		}
+	}
+
+	var (
+		src string
+		err error
+	)
+
+	if flags.NArg() == 0 {
+		var b []byte
+		b, err = ioutil.ReadAll(os.Stdin)
+		src = string(b)
+	} else {
+		src, err = literate.ReadFile(flags.Arg(0))
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	if *asHTML {
+		fmt.Printf("<pre class=\"pluto\">%s</pre>\n", highlight.HTML(src, theme))
+	} else {
+		fmt.Print(highlight.ANSI(src, theme))
+	}
+
+	return 0
+}
@@ -0,0 +1,178 @@
+// Package highlight highlights Pluto source code as HTML or
+// with ANSI escape codes, using the tokens from the lexer.
+package highlight
+
+import (
+	"github.com/Zac-Garby/pluto/lexer"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// Class is the kind of a highlighted piece of source, which
+// a theme gives a style
+type Class string
+
+const (
+	// Plain is anything which isn't highlighted, such as
+	// identifiers, brackets and whitespace
+	Plain Class = ""
+
+	// Keyword is a keyword, including true, false and null
+	Keyword Class = "keyword"
+
+	// Param is a parameter ($x)
+	Param Class = "parameter"
+
+	// String is a string literal
+	String Class = "string"
+
+	// Char is a character literal
+	Char Class = "char"
+
+	// Number is a number literal
+	Number Class = "number"
+
+	// Operator is an operator, including assignments
+	Operator Class = "operator"
+
+	// Comment is a comment, including its #
+	Comment Class = "comment"
+)
+
+// Classes are the classes which can be highlighted
+var Classes = []Class{Keyword, Param, String, Char, Number, Operator, Comment}
+
+var operators = map[token.Type]bool{
+	token.Plus:        true,
+	token.Minus:       true,
+	token.Star:        true,
+	token.Exp:         true,
+	token.Slash:       true,
+	token.FloorDiv:    true,
+	token.Mod:         true,
+	token.BackSlash:   true,
+	token.LessThan:    true,
+	token.GreaterThan: true,
+
+	token.LessThanEq:    true,
+	token.GreaterThanEq: true,
+	token.Equal:         true,
+	token.NotEqual:      true,
+	token.Or:            true,
+	token.And:           true,
+	token.BitOr:         true,
+	token.BitAnd:        true,
+	token.Arrow:         true,
+	token.QuestionMark:  true,
+	token.Bang:          true,
+
+	token.Assign:             true,
+	token.PlusEquals:         true,
+	token.MinusEquals:        true,
+	token.StarEquals:         true,
+	token.ExpEquals:          true,
+	token.SlashEquals:        true,
+	token.FloorDivEquals:     true,
+	token.ModEquals:          true,
+	token.OrEquals:           true,
+	token.AndEquals:          true,
+	token.BitOrEquals:        true,
+	token.BitAndEquals:       true,
+	token.QuestionMarkEquals: true,
+}
+
+// Classify returns the class of a type of token
+func Classify(t token.Type) Class {
+	switch t {
+	case token.Param:
+		return Param
+	case token.String:
+		return String
+	case token.Char:
+		return Char
+	case token.Number:
+		return Number
+	case token.Comment:
+		return Comment
+	}
+
+	if token.IsKeyword(t) {
+		return Keyword
+	}
+
+	if operators[t] {
+		return Operator
+	}
+
+	return Plain
+}
+
+// A Span is a piece of source with a class
+type Span struct {
+	Class Class
+	Text  string
+}
+
+// Spans splits source code into spans. Joining their text
+// gives back the source, including its whitespace.
+func Spans(src string) []Span {
+	var (
+		spans  []Span
+		cursor = 0
+		starts = []int{0}
+		next   = lexer.Lexer(src, "")
+	)
+
+	for i, c := range src {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	// offset finds the index in src of a position
+	offset := func(pos token.Position) int {
+		if pos.Line < 1 || pos.Line > len(starts) {
+			return len(src)
+		}
+
+		if i := starts[pos.Line-1] + pos.Column - 1; i < len(src) {
+			return i
+		}
+
+		return len(src)
+	}
+
+	add := func(class Class, text string) {
+		if text == "" {
+			return
+		}
+
+		// Neighbouring spans of the same class are merged
+		if n := len(spans); n > 0 && spans[n-1].Class == class {
+			spans[n-1].Text += text
+			return
+		}
+
+		spans = append(spans, Span{Class: class, Text: text})
+	}
+
+	for tok := next(); tok.Type != token.EOF; tok = next() {
+		// Semis are plain, and most aren't in the source
+		if tok.Type == token.Semi {
+			continue
+		}
+
+		start, end := offset(tok.Start), offset(tok.End)+1
+		if start < cursor || end > len(src) {
+			continue
+		}
+
+		add(Plain, src[cursor:start])
+		add(Classify(tok.Type), src[start:end])
+
+		cursor = end
+	}
+
+	add(Plain, src[cursor:])
+
+	return spans
+}
@@ -0,0 +1,93 @@
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// HTML highlights source code as HTML, with each span's
+// style inline so it can be put in any page. It doesn't
+// include the <pre> element to go around it.
+func HTML(src string, theme Theme) string {
+	var b strings.Builder
+
+	for _, span := range Spans(src) {
+		text := html.EscapeString(span.Text)
+
+		style, ok := theme[span.Class]
+		if !ok || style == (Style{}) {
+			b.WriteString(text)
+			continue
+		}
+
+		var css []string
+
+		if style.Color != "" {
+			css = append(css, "color: "+style.Color)
+		}
+
+		if style.Bold {
+			css = append(css, "font-weight: bold")
+		}
+
+		if style.Italic {
+			css = append(css, "font-style: italic")
+		}
+
+		fmt.Fprintf(&b, `<span style="%s">%s</span>`, strings.Join(css, "; "), text)
+	}
+
+	return b.String()
+}
+
+// ANSI highlights source code with ANSI escape codes, for
+// terminals with 24-bit color. Styles are reset at the end
+// of each line, so it can be shown a line at a time.
+func ANSI(src string, theme Theme) string {
+	var b strings.Builder
+
+	for _, span := range Spans(src) {
+		codes := ansiCodes(theme[span.Class])
+		if codes == "" {
+			b.WriteString(span.Text)
+			continue
+		}
+
+		for _, line := range strings.SplitAfter(span.Text, "\n") {
+			text := strings.TrimSuffix(line, "\n")
+
+			if text != "" {
+				fmt.Fprintf(&b, "\x1b[%sm%s\x1b[0m", codes, text)
+			}
+
+			if len(text) < len(line) {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// ansiCodes returns the SGR parameters which set a style,
+// separated by semicolons
+func ansiCodes(style Style) string {
+	var codes []string
+
+	if style.Bold {
+		codes = append(codes, "1")
+	}
+
+	if style.Italic {
+		codes = append(codes, "3")
+	}
+
+	if hexColor.MatchString(style.Color) {
+		rgb, _ := strconv.ParseUint(style.Color[1:], 16, 32)
+		codes = append(codes, fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, rgb>>8&0xff, rgb&0xff))
+	}
+
+	return strings.Join(codes, ";")
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/highlight"
+)
+
+const source = `# doubles a number
+def double $x {
+    return $x * 2 + 'c'
+}
+
+s = "one
+two" # after
+`
+
+func TestSpans(t *testing.T) {
+	expected := []struct {
+		class Class
+		text  string
+	}{
+		{Comment, "# doubles a number"},
+		{Plain, "\n"},
+		{Keyword, "def"},
+		{Plain, " double "},
+		{Param, "$x"},
+		{Plain, " {\n    "},
+		{Keyword, "return"},
+		{Plain, " "},
+		{Param, "$x"},
+		{Plain, " "},
+		{Operator, "*"},
+		{Plain, " "},
+		{Number, "2"},
+		{Plain, " "},
+		{Operator, "+"},
+		{Plain, " "},
+		{Char, "'c'"},
+		{Plain, "\n}\n\ns "},
+		{Operator, "="},
+		{Plain, " "},
+		{String, "\"one\ntwo\""},
+		{Plain, " "},
+		{Comment, "# after"},
+		{Plain, "\n"},
+	}
+
+	spans := Spans(source)
+
+	if len(spans) != len(expected) {
+		t.Fatalf("expected %d spans, got %d: %q", len(expected), len(spans), spans)
+	}
+
+	for i, span := range spans {
+		if exp := expected[i]; span.Class != exp.class || span.Text != exp.text {
+			t.Errorf("span %d: expected %q, got %q", i, exp, span)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	theme, err := ReadTheme(strings.NewReader(`{"keyword": {"color": "#0000ff"}, "number": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if html := HTML("if x < 1 { \"<b>\" }", theme); html != `<span style="color: #0000ff">if</span> x `+
+		`<span style="color: #3e999f">&lt;</span> 1 { <span style="color: #718c00">&#34;&lt;b&gt;&#34;</span> }` {
+		t.Errorf("wrong HTML: %s", html)
+	}
+
+	if ansi := ANSI("x = 'a'\n# one", DefaultTheme); ansi != "x \x1b[38;2;62;153;159m=\x1b[0m "+
+		"\x1b[38;2;113;140;0m'a'\x1b[0m\n\x1b[3;38;2;142;144;140m# one\x1b[0m" {
+		t.Errorf("wrong ANSI: %q", ansi)
+	}
+
+	for _, text := range []string{`{"keywords": {}}`, `{"string": {"color": "red"}}`, `[]`} {
+		if _, err := ReadTheme(strings.NewReader(text)); err == nil {
+			t.Errorf("expected an error reading the theme %s", text)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package highlight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Style is how a class of source is shown. Color is a hex
+// color, such as "#8959a8", or empty for no color.
+type Style struct {
+	Color  string `json:"color"`
+	Bold   bool   `json:"bold"`
+	Italic bool   `json:"italic"`
+}
+
+// Theme gives each class a style. Classes which aren't in
+// the theme are plain.
+type Theme map[Class]Style
+
+// DefaultTheme is the theme used when no other is given
+var DefaultTheme = Theme{
+	Keyword:  {Color: "#8959a8", Bold: true},
+	Param:    {Color: "#c82829"},
+	String:   {Color: "#718c00"},
+	Char:     {Color: "#718c00"},
+	Number:   {Color: "#f5871f"},
+	Operator: {Color: "#3e999f"},
+	Comment:  {Color: "#8e908c", Italic: true},
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ReadTheme reads a theme as a JSON object, mapping classes
+// to styles, such as:
+//
+//	{"keyword": {"color": "#0000ff", "bold": true}}
+//
+// The classes it leaves out have their default style.
+func ReadTheme(r io.Reader) (Theme, error) {
+	styles := make(map[Class]Style)
+	if err := json.NewDecoder(r).Decode(&styles); err != nil {
+		return nil, fmt.Errorf("reading theme: %s", err)
+	}
+
+	theme := make(Theme)
+	for class, style := range DefaultTheme {
+		theme[class] = style
+	}
+
+	for class, style := range styles {
+		if !validClass(class) {
+			return nil, fmt.Errorf("theme: unknown class '%s'", class)
+		}
+
+		if style.Color != "" && !hexColor.MatchString(style.Color) {
+			return nil, fmt.Errorf("theme: %s's color '%s' isn't like #rrggbb", class, style.Color)
+		}
+
+		theme[class] = style
+	}
+
+	return theme, nil
+}
+
+func validClass(class Class) bool {
+	for _, c := range Classes {
+		if class == c {
+			return true
+		}
+	}
+
+	return false
+}
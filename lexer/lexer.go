@@ -36,35 +36,54 @@ func Lexer(str, file string) func() token.Token {
 		ch    = make(chan token.Token)
 	)
 
+	// comment reads a comment, starting at the current
+	// index, up to the end of the line. The literal is
+	// the comment's text, not including the #.
+	comment := func() token.Token {
+		start := index
+
+		for index < len(str) && str[index] != '\n' {
+			index++
+		}
+
+		tok := token.Token{
+			Type:    token.Comment,
+			Literal: str[start+1 : index],
+			Start:   token.Position{Line: line, Column: col, File: file},
+			End:     token.Position{Line: line, Column: col + index - start - 1, File: file},
+		}
+
+		col += index - start
+
+		return tok
+	}
+
 	go func() {
 		for {
 			if index < len(str) {
 				foundSpace := false
 
-				for index < len(str) && (unicode.IsSpace(rune(str[index])) || str[index] == '#') {
-					if unicode.IsSpace(rune(str[index])) {
-						index++
-						col++
-
-						if str[index-1] == '\n' {
-							col = 1
-							line++
-						}
-
-						foundSpace = true
-					} else {
-						for index < len(str) && str[index] != '\n' {
-							index++
-						}
+				for index < len(str) && unicode.IsSpace(rune(str[index])) {
+					index++
+					col++
 
+					if str[index-1] == '\n' {
 						col = 1
+						line++
 					}
+
+					foundSpace = true
 				}
 
 				if foundSpace {
 					continue
 				}
 
+				if str[index] == '#' {
+					ch <- comment()
+					continue
+				}
+
 				found := false
 
 				remainingSubstring := str[index:]
@@ -80,17 +99,27 @@ func Lexer(str, file string) func() token.Token {
 					if len(match) > 0 {
 						found = true
 						t, literal, whole := handler(match)
-						l := len(whole)
+						start := token.Position{Line: line, Column: col, File: file}
+
+						// Strings can span lines, so the
+						// position is moved over each line
+						for i := 0; i < len(whole); i++ {
+							if whole[i] == '\n' {
+								line++
+								col = 1
+							} else {
+								col++
+							}
+						}
 
 						ch <- token.Token{
 							Type:    t,
 							Literal: literal,
-							Start:   token.Position{Line: line, Column: col, File: file},
-							End:     token.Position{Line: line, Column: col + l - 1, File: file},
+							Start:   start,
+							End:     token.Position{Line: line, Column: col - 1, File: file},
 						}
 
-						index += l
-						col += l
+						index += len(whole)
 
 						for index < len(str) && unicode.IsSpace(rune(str[index])) && str[index] != '\n' {
 							index++
@@ -98,9 +127,7 @@ func Lexer(str, file string) func() token.Token {
 						}
 
 						if index < len(str) && str[index] == '#' {
-							for index < len(str) && str[index] != '\n' {
-								index++
-							}
+							ch <- comment()
 						}
 
 						isLineEnding := false
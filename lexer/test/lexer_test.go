@@ -24,8 +24,10 @@ func TestLexer(t *testing.T) {
 		`= += -= *= **= /= //= %=`:          {token.Assign, token.PlusEquals, token.MinusEquals, token.StarEquals, token.ExpEquals, token.SlashEquals, token.FloorDivEquals, token.ModEquals},
 		`||= &&= |= &= ?=`:                  {token.OrEquals, token.AndEquals, token.BitOrEquals, token.BitAndEquals, token.QuestionMarkEquals},
 		`true false null`:                   {token.True, token.False, token.Null},
-		`def return import use`:             {token.Def, token.Return, token.Import, token.Use},
+		`def return use`:                    {token.Def, token.Return, token.Use},
 		`if else elif while for next break`: {token.If, token.Else, token.Elif, token.While, token.For, token.Next, token.Break},
+		"#!/usr/bin/env pluto\nx # y":       {token.Comment, token.ID, token.Comment},
+		"# only a comment":                  {token.Comment},
 	}
 
 	for in, out := range cases {
@@ -52,3 +54,25 @@ func TestLexer(t *testing.T) {
 		}
 	}
 }
+
+func TestPositions(t *testing.T) {
+	var (
+		l   = Lexer("x = \"a\nb\" + y", "<test suite>")
+		exp = []struct{ start, end token.Position }{
+			{token.Position{Line: 1, Column: 1}, token.Position{Line: 1, Column: 1}},
+			{token.Position{Line: 1, Column: 3}, token.Position{Line: 1, Column: 3}},
+			{token.Position{Line: 1, Column: 5}, token.Position{Line: 2, Column: 2}},
+			{token.Position{Line: 2, Column: 4}, token.Position{Line: 2, Column: 4}},
+			{token.Position{Line: 2, Column: 6}, token.Position{Line: 2, Column: 6}},
+		}
+	)
+
+	for _, pos := range exp {
+		tok := l()
+		tok.Start.File, tok.End.File = "", ""
+
+		if tok.Start != pos.start || tok.End != pos.end {
+			t.Errorf("%s: expected %s → %s", tok.String(), pos.start.String(), pos.end.String())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/lint"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+)
+
+// lintCommand checks the source files in the given files
+// and directories, or in the current directory, without
+// running them. It prints a warning for each likely mistake,
+// and exits with status 1 if there were any, so it can be
+// used in CI. It's also available as 'pluto check'.
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto lint [files or directories...]")
+		fmt.Fprintln(os.Stderr, "\nwarnings can be suppressed with a '# lint:ignore [checks...]' comment")
+		fmt.Fprintln(os.Stderr, "on the same line, or the line above")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := sourceFiles(paths, dir.Extensions...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	status := 0
+
+	for _, file := range files {
+		src, err := literate.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			status = 1
+			continue
+		}
+
+		// Tests can call the assertion library's functions
+		var extra []object.Function
+		if isTestFile(file) {
+			extra = testLibraryFunctions()
+		}
+
+		warnings, err := lint.Check(src, file, extra...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			status = 1
+			continue
+		}
+
+		for _, w := range warnings {
+			fmt.Println(w)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// testLibraryFunctions compiles the assertion library
+// which tests are run with
+func testLibraryFunctions() []object.Function {
+	parse := parser.New(testLibrary, "<testing>")
+	cmp := compiler.New()
+
+	if err := cmp.CompileProgram(parse.Parse()); err != nil {
+		return nil
+	}
+
+	return cmp.Functions
+}
@@ -0,0 +1,329 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+)
+
+// reads returns the names of the variables read in a node,
+// not including those only assigned to, the words in
+// patterns, or the fields after dots.
+func reads(n ast.Node) map[string]bool {
+	names := make(map[string]bool)
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.Identifier:
+			names[node.Value] = true
+		case *ast.Parameter:
+			names[node.Name] = true
+		case *ast.FunctionDefinition:
+			ast.Walk(node.Body, visit)
+			return false
+		case *ast.BlockLiteral:
+			ast.Walk(node.Body, visit)
+			return false
+		case *ast.FunctionCall:
+			walkArguments(node.Pattern, visit)
+			return false
+		case *ast.QualifiedFunctionCall:
+			ast.Walk(node.Base, visit)
+			walkArguments(node.Pattern, visit)
+			return false
+		case *ast.DotExpression:
+			ast.Walk(node.Left, visit)
+			return false
+		case *ast.AssignExpression:
+			switch name := node.Name.(type) {
+			case *ast.IndexExpression, *ast.DotExpression:
+				ast.Walk(name, visit)
+			}
+
+			ast.Walk(node.Value, visit)
+			return false
+		}
+
+		return true
+	}
+
+	ast.Walk(n, visit)
+
+	return names
+}
+
+func walkArguments(pattern []ast.Expression, fn func(ast.Node) bool) {
+	for _, item := range pattern {
+		if arg, ok := item.(*ast.Argument); ok {
+			ast.Walk(arg, fn)
+		}
+	}
+}
+
+// assignments returns the names assigned to in some
+// statements, with the nodes naming them, in order. The
+// statements in functions and blocks aren't included.
+func assignments(stmts []ast.Statement) ([]string, map[string]ast.Node) {
+	var (
+		order []string
+		nodes = make(map[string]ast.Node)
+	)
+
+	for _, stmt := range stmts {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FunctionDefinition, *ast.BlockLiteral:
+				return false
+			case *ast.AssignExpression:
+				if id, ok := node.Name.(*ast.Identifier); ok {
+					if _, seen := nodes[id.Value]; !seen {
+						order = append(order, id.Value)
+						nodes[id.Value] = id
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	return order, nodes
+}
+
+// params returns the names of the parameters in a function
+// pattern, or in a block's parameter list, which is made
+// of identifiers.
+func params(pattern []ast.Expression) ([]string, map[string]ast.Node) {
+	var (
+		order []string
+		nodes = make(map[string]ast.Node)
+	)
+
+	for _, item := range pattern {
+		var name string
+
+		switch p := item.(type) {
+		case *ast.Parameter:
+			name = p.Name
+		default:
+			continue
+		}
+
+		order = append(order, name)
+		nodes[name] = item
+	}
+
+	return order, nodes
+}
+
+// blockParams returns a block's parameters as parameters,
+// like the ones in a function pattern
+func blockParams(block *ast.BlockLiteral) []ast.Expression {
+	ps := make([]ast.Expression, len(block.Params))
+
+	for i, p := range block.Params {
+		ps[i] = &ast.Parameter{Tok: p.Token(), Name: p.Token().Literal}
+	}
+
+	return ps
+}
+
+// unused warns about variables which are never read, and
+// parameters which aren't read in their function. Names
+// starting with an underscore are ignored.
+func (l *linter) unused(prog ast.Program) {
+	read := make(map[string]bool)
+
+	for _, stmt := range prog.Statements {
+		for name := range reads(stmt) {
+			read[name] = true
+		}
+	}
+
+	var visit func(stmts []ast.Statement)
+	visit = func(stmts []ast.Statement) {
+		order, nodes := assignments(stmts)
+
+		for _, name := range order {
+			if !read[name] && !strings.HasPrefix(name, "_") {
+				l.warn(nodes[name], Unused, "%s is assigned to, but never used", name)
+			}
+		}
+
+		for _, stmt := range stmts {
+			ast.Walk(stmt, func(n ast.Node) bool {
+				var (
+					pattern []ast.Expression
+					body    ast.Statement
+				)
+
+				switch node := n.(type) {
+				case *ast.FunctionDefinition:
+					pattern, body = node.Pattern, node.Body
+				case *ast.BlockLiteral:
+					pattern, body = blockParams(node), node.Body
+				default:
+					return true
+				}
+
+				used := reads(body)
+				order, nodes := params(pattern)
+
+				for _, name := range order {
+					if !used[name] && !strings.HasPrefix(name, "_") {
+						l.warn(nodes[name], Unused, "parameter %s is never used", name)
+					}
+				}
+
+				if block, ok := body.(*ast.BlockStatement); ok {
+					visit(block.Statements)
+				}
+
+				return false
+			})
+		}
+	}
+
+	visit(prog.Statements)
+}
+
+// scope warns about names in functions and blocks which
+// are also variables outside of them. Since functions
+// share their caller's variables, assigning to one of
+// these names changes the outer variable.
+func (l *linter) scope(stmts []ast.Statement, pattern []ast.Expression, outer []map[string]bool) {
+	var (
+		names = make(map[string]bool)
+
+		paramOrder, paramNodes   = params(pattern)
+		assignOrder, assignNodes = assignments(stmts)
+	)
+
+	check := func(order []string, nodes map[string]ast.Node, what string) {
+		for _, name := range order {
+			if names[name] {
+				continue
+			}
+
+			names[name] = true
+
+			for _, scope := range outer {
+				if scope[name] {
+					l.warn(nodes[name], Shadow, "%s %s shadows the outer variable %s, which it will overwrite", what, name, name)
+					break
+				}
+			}
+		}
+	}
+
+	check(paramOrder, paramNodes, "parameter")
+	check(assignOrder, assignNodes, "assignment to")
+
+	inner := append(append([]map[string]bool{}, outer...), names)
+
+	for _, stmt := range stmts {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FunctionDefinition:
+				l.scope(statements(node.Body), node.Pattern, inner)
+				return false
+			case *ast.BlockLiteral:
+				l.scope(statements(node.Body), blockParams(node), inner)
+				return false
+			}
+
+			return true
+		})
+	}
+}
+
+// block checks for unreachable code, for break and next
+// statements outside of loops, and for calls which don't
+// match any function. loops is the number of loops the
+// statements are in.
+func (l *linter) block(stmts []ast.Statement, loops int) {
+	for i, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement, *ast.NextStatement:
+			if i+1 < len(stmts) {
+				l.warn(stmts[i+1], Unreachable, "unreachable code after %s", stmt.Token().Literal)
+			}
+		}
+
+		l.statement(stmt, loops)
+	}
+}
+
+func (l *linter) statement(stmt ast.Statement, loops int) {
+	ast.Walk(stmt, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BlockStatement:
+			l.block(node.Statements, loops)
+			return false
+		case *ast.FunctionDefinition:
+			l.block(statements(node.Body), 0)
+			return false
+		case *ast.BlockLiteral:
+			l.block(statements(node.Body), 0)
+			return false
+		case *ast.WhileLoop:
+			l.expression(node.Condition, loops)
+			l.block(statements(node.Body), loops+1)
+			return false
+		case *ast.ForLoop:
+			l.expression(node.Init, loops)
+			l.expression(node.Condition, loops)
+			l.expression(node.Increment, loops)
+			l.block(statements(node.Body), loops+1)
+			return false
+		case *ast.BreakStatement, *ast.NextStatement:
+			if loops == 0 {
+				l.warn(node, Loop, "%s outside of a loop", node.Token().Literal)
+			}
+		case *ast.FunctionCall:
+			l.call(node)
+		}
+
+		return true
+	})
+}
+
+func (l *linter) expression(e ast.Expression, loops int) {
+	if e != nil {
+		l.statement(&ast.ExpressionStatement{Tok: e.Token(), Expr: e}, loops)
+	}
+}
+
+// call warns if no function matches a call's pattern
+func (l *linter) call(node *ast.FunctionCall) {
+	if !l.prelude {
+		return
+	}
+
+	words := make([]string, len(node.Pattern))
+
+	for i, item := range node.Pattern {
+		if id, ok := item.(*ast.Identifier); ok {
+			words[i] = id.Value
+		} else {
+			words[i] = "$"
+		}
+	}
+
+	search := strings.Join(words, " ")
+
+	if l.functions.SearchString(search) == nil {
+		l.warn(node, Undefined, "no function matches the pattern '%s'", search)
+	}
+}
+
+// statements returns the statements in a function or block
+// body
+func statements(body ast.Statement) []ast.Statement {
+	if block, ok := body.(*ast.BlockStatement); ok {
+		return block.Statements
+	}
+
+	return []ast.Statement{body}
+}
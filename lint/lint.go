@@ -0,0 +1,218 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// The checks which can be reported, and suppressed
+const (
+	Unused      = "unused"
+	Shadow      = "shadow"
+	Unreachable = "unreachable"
+	Loop        = "loop"
+	Undefined   = "undefined"
+)
+
+// Warning is a possible mistake found in a program
+type Warning struct {
+	Pos     token.Position
+	Check   string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", w.Pos.File, w.Pos.Line, w.Pos.Column, w.Message, w.Check)
+}
+
+// Check parses and compiles some source code, without
+// running it, and returns the warnings about it, in order.
+// Calls are checked against the functions defined in the
+// code, in the prelude, in the packages it uses, and in
+// extra.
+//
+// A warning is suppressed by a "lint:ignore" comment at
+// the end of its line, or on its own on the line above.
+// The comment can name the checks to ignore, such as
+// "# lint:ignore unused", otherwise every check is ignored.
+func Check(src, file string, extra ...object.Function) ([]Warning, error) {
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		return nil, parse.Errors[0]
+	}
+
+	l := &linter{
+		functions: &store.FunctionStore{},
+	}
+
+	l.functions.Define(extra...)
+	l.prelude = l.use("std/prelude/*.pluto", "")
+
+	for _, stmt := range prog.Statements {
+		if use, ok := stmt.(*ast.UseStatement); ok {
+			l.use(use.Package, file)
+		}
+	}
+
+	defined(prog, l.functions)
+
+	l.unused(prog)
+	l.scope(prog.Statements, nil, nil)
+	l.block(prog.Statements, 0)
+
+	// The linter doesn't need the compiled code, but the
+	// program should compile. This is done last, since
+	// compiling for loops changes their bodies.
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return nil, err
+	}
+
+	warnings := suppress(l.warnings, parse.Comments, src)
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		a, b := warnings[i].Pos, warnings[j].Pos
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+
+	return warnings, nil
+}
+
+type linter struct {
+	warnings  []Warning
+	functions *store.FunctionStore
+
+	// prelude is whether the prelude was found. If it
+	// wasn't, calls aren't checked.
+	prelude bool
+}
+
+func (l *linter) warn(n ast.Node, check, format string, args ...interface{}) {
+	l.warnings = append(l.warnings, Warning{
+		Pos:     n.Token().Start,
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// use adds the functions defined in a package, used from
+// a file, returning whether it was found
+func (l *linter) use(src, file string) bool {
+	sources, err := pkg.LocateUse(src, file)
+	if err != nil || len(sources) == 0 {
+		return false
+	}
+
+	for _, source := range sources {
+		text, err := literate.ReadFile(source)
+		if err != nil {
+			continue
+		}
+
+		parse := parser.New(text, source)
+		prog := parse.Parse()
+
+		if len(parse.Errors) == 0 {
+			defined(prog, l.functions)
+		}
+	}
+
+	return true
+}
+
+// defined adds every function defined in a program to fs,
+// including those defined inside other functions.
+func defined(prog ast.Program, fs *store.FunctionStore) {
+	for _, stmt := range prog.Statements {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			if def, ok := n.(*ast.FunctionDefinition); ok {
+				fs.Define(object.Function{Pattern: def.Pattern})
+			}
+
+			return true
+		})
+	}
+}
+
+// suppress removes the warnings which are suppressed by
+// lint:ignore comments in src. A comment after some code
+// only covers its own line, but one on its own line also
+// covers the line below.
+func suppress(warnings []Warning, comments []token.Token, src string) []Warning {
+	var (
+		lines = strings.Split(src, "\n")
+
+		// ignored maps each line to the checks ignored on
+		// it, or to nil if every check is ignored
+		ignored = make(map[int][]string)
+	)
+
+	for _, c := range comments {
+		text := strings.TrimSpace(c.Literal)
+		if !strings.HasPrefix(text, "lint:ignore") {
+			continue
+		}
+
+		checks := strings.Fields(strings.TrimPrefix(text, "lint:ignore"))
+
+		covered := []int{c.Start.Line}
+		if ownLine(lines, c.Start) {
+			covered = append(covered, c.Start.Line+1)
+		}
+
+		for _, line := range covered {
+			if existing, ok := ignored[line]; ok && (existing == nil || len(checks) == 0) {
+				ignored[line] = nil
+			} else {
+				ignored[line] = append(existing, checks...)
+			}
+		}
+	}
+
+	var kept []Warning
+
+outer:
+	for _, w := range warnings {
+		checks, ok := ignored[w.Pos.Line]
+		if ok && checks == nil {
+			continue
+		}
+
+		for _, check := range checks {
+			if check == w.Check {
+				continue outer
+			}
+		}
+
+		kept = append(kept, w)
+	}
+
+	return kept
+}
+
+// ownLine checks if there's only space before a position
+// on its line
+func ownLine(lines []string, pos token.Position) bool {
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return false
+	}
+
+	line := lines[pos.Line-1]
+	if pos.Column-1 < len(line) {
+		line = line[:pos.Column-1]
+	}
+
+	return strings.TrimSpace(line) == ""
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/lint"
+)
+
+const source = `x = 1
+unused = 2
+
+def double $x {
+    return $x * 2
+    print $x
+}
+
+break
+
+def ignored $y { # lint:ignore unused
+    return 0
+}
+
+print (double $x)
+`
+
+func TestCheck(t *testing.T) {
+	// Without a prelude, calls aren't checked
+	t.Setenv("PLUTO", t.TempDir())
+
+	warnings, err := Check(source, "test.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		line  int
+		check string
+	}{
+		{2, Unused},
+		{4, Shadow},
+		{6, Unreachable},
+		{9, Loop},
+		{11, Unreachable},
+	}
+
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+
+	for i, e := range expected {
+		if w := warnings[i]; w.Pos.Line != e.line || w.Check != e.check {
+			t.Errorf("warning %d: expected %s on line %d, got %s", i, e.check, e.line, w)
+		}
+	}
+}
+
+func TestCheckCalls(t *testing.T) {
+	var (
+		home = t.TempDir()
+		root = t.TempDir()
+	)
+
+	t.Setenv("PLUTO", home)
+
+	files := map[string]string{
+		filepath.Join(home, "packages", "std", "prelude", "io.pluto"): "def print $x {}\n",
+		filepath.Join(root, "lib", "twice.pluto"):                     "def twice $n { return $n * 2 }\n",
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The used file is found from the linted file's
+	// directory, not the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	src := `use "./lib/twice.pluto"
+
+print (twice 2)
+print (thrice 2)
+`
+
+	warnings, err := Check(src, filepath.Join(root, "main.pluto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Pos.Line != 4 || warnings[0].Check != Undefined {
+		t.Fatalf("expected only an undefined warning on line 4, got %v", warnings)
+	}
+
+	if msg := "no function matches the pattern 'thrice $'"; warnings[0].Message != msg {
+		t.Errorf("expected the message %q, got %q", msg, warnings[0].Message)
+	}
+}
+
+// A lint:ignore comment after some code only covers its own
+// line, but one on its own line covers the line below too
+func TestSuppress(t *testing.T) {
+	t.Setenv("PLUTO", t.TempDir())
+
+	src := `x = 1 # lint:ignore
+break
+
+# lint:ignore
+y = 2
+`
+
+	warnings, err := Check(src, "test.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Pos.Line != 2 || warnings[0].Check != Loop {
+		t.Errorf("expected only a loop warning on line 2, got %v", warnings)
+	}
+}
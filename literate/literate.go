@@ -0,0 +1,126 @@
+package literate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Extension is the file extension of literate Pluto files
+const Extension = ".lpluto"
+
+// IsLiterate checks if file is a literate Pluto file,
+// going by its extension.
+func IsLiterate(file string) bool {
+	return filepath.Ext(file) == Extension
+}
+
+// Extract returns the Pluto code in a literate source. A
+// literate source is Markdown, and its code is in fenced
+// blocks, opened and closed with ``` or ~~~. A block is
+// run if its info string is empty or "pluto":
+//
+//	Adding two numbers:
+//
+//	```pluto
+//	print (1 + 2)
+//	```
+//
+// Blocks tagged with any other language are ignored, so
+// examples of other code, or output, can be written too.
+//
+// Every other line is replaced with an empty one, and the
+// code lines are kept as they are, so a line and column in
+// the returned code is the same in the original file.
+func Extract(src string) string {
+	var (
+		lines = strings.Split(src, "\n")
+		fence string
+		code  bool
+	)
+
+	for i, line := range lines {
+		if fence == "" {
+			if f, info, ok := openingFence(line); ok {
+				fence = f
+				code = info == "" || info == "pluto"
+			}
+
+			lines[i] = ""
+			continue
+		}
+
+		if closesFence(line, fence) {
+			fence = ""
+			lines[i] = ""
+			continue
+		}
+
+		if !code {
+			lines[i] = ""
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ReadFile reads a source file. If it's a literate file,
+// its code is extracted.
+func ReadFile(file string) (string, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	if IsLiterate(file) {
+		return Extract(string(src)), nil
+	}
+
+	return string(src), nil
+}
+
+// openingFence checks if line opens a fenced block. If so,
+// it returns the fence and the first word of the info
+// string.
+func openingFence(line string) (fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return "", "", false
+	}
+
+	char := trimmed[0]
+	if char != '`' && char != '~' {
+		return "", "", false
+	}
+
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, string(char)))
+	if n < 3 {
+		return "", "", false
+	}
+
+	rest := strings.TrimSpace(trimmed[n:])
+	if char == '`' && strings.Contains(rest, "`") {
+		return "", "", false
+	}
+
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		info = strings.ToLower(fields[0])
+	}
+
+	return trimmed[:n], info, true
+}
+
+// closesFence checks if line closes a block opened with
+// fence. It must use the same character, at least as many
+// times, and have nothing after it.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+
+	trimmed = strings.TrimRight(trimmed, " \t\r")
+	rest := strings.TrimLeft(trimmed, fence[:1])
+
+	return len(trimmed)-len(rest) >= len(fence) && rest == ""
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/literate"
+)
+
+func TestExtract(t *testing.T) {
+	src := "# Title\n" +
+		"\n" +
+		"```pluto\n" +
+		"a = 1\n" +
+		"```\n" +
+		"\n" +
+		"```text\n" +
+		"not code\n" +
+		"```\n" +
+		"~~~~\n" +
+		"b = 2\n" +
+		"~~~\n" +
+		"c = 3\n" +
+		"~~~~\n" +
+		"Some `inline` code."
+
+	expected := []string{
+		"", "", "", "a = 1", "", "", "", "", "", "", "b = 2", "~~~", "c = 3", "", "",
+	}
+
+	lines := strings.Split(Extract(src), "\n")
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %d", len(expected), len(lines))
+	}
+
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line %d: expected '%s', got '%s'", i+1, expected[i], line)
+		}
+	}
+}
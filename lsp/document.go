@@ -0,0 +1,310 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// A document is a file open in the editor. Its program
+// and functions are from the last time it parsed without
+// errors, so that hovering and completion keep working
+// while an edit is half-typed.
+type document struct {
+	uri, path string
+	version   int
+	text      string
+
+	prog      ast.Program
+	functions *store.FunctionStore
+}
+
+func newDocument(uri, text string) (*document, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{
+		uri:       uri,
+		path:      path,
+		text:      text,
+		functions: &store.FunctionStore{},
+	}, nil
+}
+
+// source returns the document's Pluto code
+func (d *document) source() string {
+	if literate.IsLiterate(d.path) {
+		return literate.Extract(d.text)
+	}
+
+	return d.text
+}
+
+// apply applies a change sent by the editor
+func (d *document) apply(change contentChange) {
+	if change.Range == nil {
+		d.text = change.Text
+		return
+	}
+
+	var (
+		start = offset(d.text, change.Range.Start)
+		end   = offset(d.text, change.Range.End)
+	)
+
+	if end < start {
+		start, end = end, start
+	}
+
+	d.text = d.text[:start] + change.Text + d.text[end:]
+}
+
+// analyse parses and compiles a document, publishes the
+// errors as diagnostics, and finds the functions in scope.
+func (s *Server) analyse(d *document) error {
+	var (
+		parse       = parser.New(d.source(), d.path)
+		prog        = parse.Parse()
+		diagnostics = []diagnostic{}
+	)
+
+	for _, err := range parse.Errors {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    tokenRange(d.text, err.Start, err.End),
+			Severity: severityError,
+			Source:   "pluto",
+			Message:  err.Message,
+		})
+	}
+
+	if len(parse.Errors) == 0 {
+		cmp := compiler.New()
+
+		for _, stmt := range prog.Statements {
+			if err := cmp.CompileStatement(stmt); err != nil {
+				tok := stmt.Token()
+
+				diagnostics = append(diagnostics, diagnostic{
+					Range:    tokenRange(d.text, tok.Start, tok.End),
+					Severity: severityError,
+					Source:   "pluto",
+					Message:  err.Error(),
+				})
+			}
+		}
+
+		fs := &store.FunctionStore{}
+		fs.Define(s.functionsIn(preludeSources(d.path)...)...)
+
+		for _, stmt := range prog.Statements {
+			if use, ok := stmt.(*ast.UseStatement); ok {
+				sources, _ := pkg.LocateUse(use.Package, d.path)
+				fs.Define(s.functionsIn(sources...)...)
+			}
+		}
+
+		fs.Define(cmp.Functions...)
+
+		d.prog = prog
+		d.functions = fs
+	}
+
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         d.uri,
+		Version:     d.version,
+		Diagnostics: diagnostics,
+	})
+}
+
+// preludeSources finds the prelude, which is loaded before
+// every program, as it's found for a file.
+func preludeSources(file string) []string {
+	sources, _ := pkg.LocateUse("std/prelude/*.pluto", file)
+	return sources
+}
+
+// functionsIn returns the functions defined by some source
+// files. If one is open, the editor's version is used.
+func (s *Server) functionsIn(files ...string) []object.Function {
+	var fns []object.Function
+
+	for _, file := range files {
+		text, err := s.read(file)
+		if err != nil {
+			continue
+		}
+
+		parse := parser.New(text, file)
+		prog := parse.Parse()
+
+		if len(parse.Errors) > 0 {
+			continue
+		}
+
+		cmp := compiler.New()
+		cmp.CompileProgram(prog)
+
+		fns = append(fns, cmp.Functions...)
+	}
+
+	return fns
+}
+
+// read returns the Pluto code in a file, using the
+// editor's buffer if it's open.
+func (s *Server) read(file string) (string, error) {
+	for _, doc := range s.docs {
+		if doc.path == file {
+			return doc.source(), nil
+		}
+	}
+
+	return literate.ReadFile(file)
+}
+
+// tokenRange converts the start and end of a token in text
+// to an LSP range. Token columns start at 1, and the end
+// column is the token's last character.
+func tokenRange(text string, start, end token.Position) lspRange {
+	if end.Line < start.Line || (end.Line == start.Line && end.Column < start.Column) {
+		end = start
+	}
+
+	end.Column++
+
+	return lspRange{
+		Start: toPosition(text, start),
+		End:   toPosition(text, end),
+	}
+}
+
+// toPosition converts a position in text from a token's
+// line and byte column to the zero-based line and UTF-16
+// character used by LSP.
+func toPosition(text string, pos token.Position) position {
+	var (
+		lines = strings.Split(text, "\n")
+		line  = pos.Line - 1
+		col   = pos.Column - 1
+	)
+
+	if line < 0 {
+		return position{}
+	} else if line >= len(lines) {
+		return position{Line: line}
+	}
+
+	if col > len(lines[line]) {
+		col = len(lines[line])
+	} else if col < 0 {
+		col = 0
+	}
+
+	return position{Line: line, Character: utf16Len(lines[line][:col])}
+}
+
+// fromPosition converts an LSP position to a token line
+// and column in text.
+func fromPosition(text string, pos position) (line, col int) {
+	var (
+		lines = strings.Split(text, "\n")
+		start = 0
+	)
+
+	if pos.Line < len(lines) {
+		start = byteIndex(lines[pos.Line], pos.Character)
+	}
+
+	return pos.Line + 1, start + 1
+}
+
+// offset converts an LSP position to a byte offset in text
+func offset(text string, pos position) int {
+	index := 0
+
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(text[index:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+
+		index += next + 1
+	}
+
+	end := strings.IndexByte(text[index:], '\n')
+	if end < 0 {
+		end = len(text) - index
+	}
+
+	return index + byteIndex(text[index:index+end], pos.Character)
+}
+
+// byteIndex finds the byte index in line of the UTF-16
+// character index char.
+func byteIndex(line string, char int) int {
+	units := 0
+
+	for i, r := range line {
+		if units >= char {
+			return i
+		}
+
+		units += utf16Units(r)
+	}
+
+	return len(line)
+}
+
+func utf16Len(s string) int {
+	n := 0
+
+	for _, r := range s {
+		n += utf16Units(r)
+	}
+
+	return n
+}
+
+// utf16Units is the number of UTF-16 code units needed
+// to encode r.
+func utf16Units(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("lsp: only file URIs are supported, not %s", uri)
+	}
+
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
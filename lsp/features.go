@@ -0,0 +1,354 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// hover shows the pattern and doc comment of the function
+// being called, or defined, under the cursor.
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, word, ok := doc.patternAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	fn := doc.functions.SearchString(searchString(pattern))
+	if fn == nil {
+		return nil, nil
+	}
+
+	text := fmt.Sprintf("```pluto\ndef %s\n```", patternString(fn.Pattern))
+
+	if fn.Doc != "" {
+		text += "\n\n" + fn.Doc
+	}
+
+	if pos := fn.Pattern[0].Token().Start; pos.File != "" {
+		text += fmt.Sprintf("\n\nDefined in %s, line %d", filepath.Base(pos.File), pos.Line)
+	}
+
+	r := tokenRange(doc.text, word.Start, word.End)
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: text},
+		Range:    &r,
+	}, nil
+}
+
+// definition finds where the function under the cursor
+// is defined, or the files used by a use statement.
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if use, ok := doc.useAt(p.Position); ok {
+		sources, err := pkg.LocateUse(use.Package, doc.path)
+		if err != nil {
+			return nil, nil
+		}
+
+		locations := make([]location, len(sources))
+		for i, source := range sources {
+			locations[i] = location{URI: pathToURI(source)}
+		}
+
+		return locations, nil
+	}
+
+	pattern, _, ok := doc.patternAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	fn := doc.functions.SearchString(searchString(pattern))
+	if fn == nil {
+		return nil, nil
+	}
+
+	file := fn.Pattern[0].Token().Start.File
+	if file == "" || strings.HasPrefix(file, "<") {
+		return nil, nil
+	}
+
+	text, err := s.read(file)
+	if err != nil {
+		return nil, nil
+	}
+
+	return []location{{
+		URI:   pathToURI(file),
+		Range: patternRange(text, fn.Pattern),
+	}}, nil
+}
+
+// documentSymbol lists the functions defined in a document,
+// and the names assigned at the top level.
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p documentSymbolParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		symbols = []symbolInformation{}
+		seen    = make(map[string]bool)
+	)
+
+	for _, stmt := range doc.prog.Statements {
+		if name, tok, ok := assignedName(stmt); ok && !seen[name] {
+			seen[name] = true
+
+			symbols = append(symbols, symbolInformation{
+				Name: name,
+				Kind: symbolVariable,
+				Location: location{
+					URI:   doc.uri,
+					Range: tokenRange(doc.text, tok.Start, tok.End),
+				},
+			})
+		}
+
+		ast.Walk(stmt, func(n ast.Node) bool {
+			if def, ok := n.(*ast.FunctionDefinition); ok && len(def.Pattern) > 0 {
+				symbols = append(symbols, symbolInformation{
+					Name: patternString(def.Pattern),
+					Kind: symbolFunction,
+					Location: location{
+						URI:   doc.uri,
+						Range: patternRange(doc.text, def.Pattern),
+					},
+				})
+			}
+
+			return true
+		})
+	}
+
+	return symbols, nil
+}
+
+// completion suggests keywords, the names assigned in the
+// document, and the patterns of the functions in scope.
+// Function patterns are inserted as snippets, with a tab
+// stop for each parameter.
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		items []completionItem
+		names = make(map[string]bool)
+	)
+
+	for keyword := range token.Keywords {
+		items = append(items, completionItem{Label: keyword, Kind: completionKeyword})
+	}
+
+	for _, stmt := range doc.prog.Statements {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			if assign, ok := n.(*ast.AssignExpression); ok {
+				if id, ok := assign.Name.(*ast.Identifier); ok {
+					names[id.Value] = true
+				}
+			}
+
+			return true
+		})
+	}
+
+	for name := range names {
+		items = append(items, completionItem{Label: name, Kind: completionVariable})
+	}
+
+	for _, fn := range doc.functions.Functions {
+		item := completionItem{
+			Label:            patternString(fn.Pattern),
+			Kind:             completionFunction,
+			InsertText:       patternSnippet(fn.Pattern),
+			InsertTextFormat: snippetFormat,
+		}
+
+		if file := fn.Pattern[0].Token().Start.File; file != "" {
+			item.Detail = filepath.Base(file)
+		}
+
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+
+	return completionList{Items: items}, nil
+}
+
+// patternAt finds the pattern of the function call or
+// definition with a word under the cursor, and the word.
+func (d *document) patternAt(pos position) ([]ast.Expression, token.Token, bool) {
+	var (
+		line, col = fromPosition(d.text, pos)
+		pattern   []ast.Expression
+		word      token.Token
+	)
+
+	find := func(items []ast.Expression) {
+		for _, item := range items {
+			id, ok := item.(*ast.Identifier)
+			if !ok {
+				continue
+			}
+
+			tok := id.Tok
+			if tok.Start.Line == line && tok.Start.Column <= col && col <= tok.End.Column+1 {
+				pattern, word = items, tok
+			}
+		}
+	}
+
+	for _, stmt := range d.prog.Statements {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FunctionCall:
+				find(node.Pattern)
+			case *ast.FunctionDefinition:
+				find(node.Pattern)
+			}
+
+			return pattern == nil
+		})
+	}
+
+	return pattern, word, pattern != nil
+}
+
+// useAt finds the use statement on the cursor's line
+func (d *document) useAt(pos position) (*ast.UseStatement, bool) {
+	for _, stmt := range d.prog.Statements {
+		if use, ok := stmt.(*ast.UseStatement); ok && use.Tok.Start.Line == pos.Line+1 {
+			return use, true
+		}
+	}
+
+	return nil, false
+}
+
+// assignedName returns the name assigned to by a top
+// level statement such as 'x = 5'.
+func assignedName(stmt ast.Statement) (string, token.Token, bool) {
+	expr, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return "", token.Token{}, false
+	}
+
+	assign, ok := expr.Expr.(*ast.AssignExpression)
+	if !ok {
+		return "", token.Token{}, false
+	}
+
+	id, ok := assign.Name.(*ast.Identifier)
+	if !ok {
+		return "", token.Token{}, false
+	}
+
+	return id.Value, id.Tok, true
+}
+
+// searchString converts a pattern to the format used by
+// store.FunctionStore.SearchString, e.g. "print $".
+func searchString(pattern []ast.Expression) string {
+	words := make([]string, len(pattern))
+
+	for i, item := range pattern {
+		if id, ok := item.(*ast.Identifier); ok {
+			words[i] = id.Value
+		} else {
+			words[i] = "$"
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// patternString returns a function's pattern with its
+// parameter names, e.g. "print $obj".
+func patternString(pattern []ast.Expression) string {
+	words := make([]string, len(pattern))
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words[i] = "$" + param.Name
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// patternSnippet returns a snippet which calls a function
+// with the pattern, e.g. "print ${1:obj}".
+func patternSnippet(pattern []ast.Expression) string {
+	var (
+		words = make([]string, len(pattern))
+		stop  = 0
+	)
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			stop++
+			words[i] = fmt.Sprintf("${%d:%s}", stop, param.Name)
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// patternRange is the range from the start of a pattern's
+// first item to the end of its last.
+func patternRange(text string, pattern []ast.Expression) lspRange {
+	var (
+		first = pattern[0].Token()
+		last  = pattern[len(pattern)-1].Token()
+	)
+
+	return tokenRange(text, first.Start, last.End)
+}
@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/Zac-Garby/pluto/rename"
+)
+
+// prepareRename finds the variable or function word under
+// the cursor, and what it's called, for the editor to ask
+// for the new name.
+func (s *Server) prepareRename(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	line, col := fromPosition(doc.text, p.Position)
+
+	tok, name, err := rename.Target(doc.source(), doc.path, line, col)
+	if err != nil {
+		return nil, &rpcError{codeRequestFailed, err.Error()}
+	}
+
+	return prepareRenameResult{
+		Range:       tokenRange(doc.text, tok.Start, tok.End),
+		Placeholder: name,
+	}, nil
+}
+
+// rename renames the variable under the cursor in its scope,
+// or changes the pattern of the function under the cursor
+// throughout the project, using the open documents' text.
+func (s *Server) rename(params json.RawMessage) (interface{}, error) {
+	var p renameParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	project := rename.FindProject(doc.path)
+	project.Read = s.read
+
+	line, col := fromPosition(doc.text, p.Position)
+
+	edits, err := project.Rename(doc.path, line, col, p.NewName)
+	if err != nil {
+		return nil, &rpcError{codeRequestFailed, err.Error()}
+	}
+
+	var (
+		changes = make(map[string][]textEdit)
+		texts   = make(map[string]string)
+	)
+
+	for _, e := range edits {
+		text, ok := texts[e.File]
+		if !ok {
+			text = s.text(e.File)
+			texts[e.File] = text
+		}
+
+		uri := pathToURI(e.File)
+
+		changes[uri] = append(changes[uri], textEdit{
+			Range: lspRange{
+				Start: toPosition(text, e.Start),
+				End:   toPosition(text, e.End),
+			},
+			NewText: e.Text,
+		})
+	}
+
+	return workspaceEdit{Changes: changes}, nil
+}
+
+// text returns the whole text of a file, from its document
+// if it's open. Unlike read, literate files aren't
+// extracted, since edits are made to the whole file.
+func (s *Server) text(file string) string {
+	for _, doc := range s.docs {
+		if doc.path == file {
+			return doc.text
+		}
+	}
+
+	data, _ := ioutil.ReadFile(file)
+	return string(data)
+}
@@ -0,0 +1,245 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Server is a language server for Pluto, which talks to
+// an editor using the Language Server Protocol. Documents
+// are synced incrementally, so the server always works
+// on the editor's buffers rather than the saved files.
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	docs     map[string]*document // open documents, by URI
+	shutdown bool
+}
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+// requests are the methods which need a response, and
+// notifications are those which don't.
+var (
+	requests = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"shutdown":                    (*Server).shutdownRequest,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+		"textDocument/completion":     (*Server).completion,
+		"textDocument/prepareRename":  (*Server).prepareRename,
+		"textDocument/rename":         (*Server).rename,
+	}
+
+	notifications = map[string]handler{
+		"textDocument/didOpen":   (*Server).didOpen,
+		"textDocument/didChange": (*Server).didChange,
+		"textDocument/didClose":  (*Server).didClose,
+	}
+)
+
+// ErrNoShutdown is returned by Serve if the client sends
+// an exit notification without first asking the server to
+// shut down.
+var ErrNoShutdown = errors.New("lsp: exit before shutdown")
+
+// NewServer creates a server which reads messages from in
+// and writes them to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client sends an exit
+// notification, or the input ends.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			if err := s.respondErr(nil, &rpcError{codeParseError, err.Error()}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+
+			return nil
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle calls the handler for a request or notification,
+// and sends the response. The returned error is only set
+// if the response couldn't be written.
+func (s *Server) handle(req request) error {
+	if req.ID == nil {
+		if h, ok := notifications[req.Method]; ok && !s.shutdown {
+			s.call(h, req.Params)
+		}
+
+		return nil
+	}
+
+	if s.shutdown {
+		return s.respondErr(req.ID, &rpcError{codeInvalidRequest, "the server has been shut down"})
+	}
+
+	h, ok := requests[req.Method]
+	if !ok {
+		return s.respondErr(req.ID, &rpcError{codeMethodNotFound, "method not found: " + req.Method})
+	}
+
+	result, err := s.call(h, req.Params)
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{codeInternalError, err.Error()}
+		}
+
+		return s.respondErr(req.ID, rerr)
+	}
+
+	return writeMessage(s.out, response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// call calls a handler, turning a panic into an error so
+// that a bug in one feature doesn't stop the server.
+func (s *Server) call(h handler, params json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("lsp: internal error: %v", r)
+		}
+	}()
+
+	return h(s, params)
+}
+
+func (s *Server) respondErr(id *json.RawMessage, err *rpcError) error {
+	return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// decode unmarshals a handler's params
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{codeInvalidParams, err.Error()}
+	}
+
+	return nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    2, // incremental
+			},
+			"hoverProvider":          true,
+			"definitionProvider":     true,
+			"documentSymbolProvider": true,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{"\\"},
+			},
+			"renameProvider": map[string]interface{}{
+				"prepareProvider": true,
+			},
+		},
+		"serverInfo": map[string]string{
+			"name": "pluto",
+		},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p didOpenParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := newDocument(p.TextDocument.URI, p.TextDocument.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.version = p.TextDocument.Version
+	s.docs[doc.uri] = doc
+
+	return nil, s.analyse(doc)
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p didChangeParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, fmt.Errorf("lsp: %s isn't open", p.TextDocument.URI)
+	}
+
+	for _, change := range p.ContentChanges {
+		doc.apply(change)
+	}
+
+	doc.version = p.TextDocument.Version
+
+	return nil, s.analyse(doc)
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p didCloseParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	delete(s.docs, p.TextDocument.URI)
+
+	return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// document finds an open document
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &rpcError{codeInvalidParams, uri + " isn't open"}
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,248 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/lsp"
+)
+
+const uri = "file:///project/main.pluto"
+
+const source = `def greet $name {
+    print ("hello, " + $name)
+}
+
+greeting = \greet "world"
+`
+
+// session writes a series of messages for the server to
+// read, followed by a shutdown request and an exit.
+type session struct {
+	buf bytes.Buffer
+	id  int
+}
+
+func (s *session) send(method string, params interface{}, request bool) int {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+
+	if request {
+		s.id++
+		msg["id"] = s.id
+	}
+
+	content, _ := json.Marshal(msg)
+	fmt.Fprintf(&s.buf, "Content-Length: %d\r\n\r\n%s", len(content), content)
+
+	return s.id
+}
+
+func (s *session) run(t *testing.T) []map[string]interface{} {
+	s.send("shutdown", nil, true)
+	s.send("exit", nil, false)
+
+	var out bytes.Buffer
+
+	if err := NewServer(&s.buf, &out).Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		r        = bufio.NewReader(&out)
+		messages []map[string]interface{}
+	)
+
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		content := make([]byte, length)
+
+		if _, err := io.ReadFull(r, content); err != nil {
+			t.Fatal(err)
+		}
+
+		var msg map[string]interface{}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			t.Fatal(err)
+		}
+
+		messages = append(messages, msg)
+	}
+}
+
+func at(line, char int) map[string]interface{} {
+	return atIn(uri, line, char)
+}
+
+func atIn(uri string, line, char int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": char},
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := &session{}
+
+	s.send("initialize", map[string]interface{}{}, true)
+	s.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "version": 1, "text": source},
+	}, false)
+
+	var (
+		hover      = s.send("textDocument/hover", at(4, 13), true)
+		definition = s.send("textDocument/definition", at(4, 13), true)
+		symbols    = s.send("textDocument/documentSymbol", map[string]interface{}{
+			"textDocument": map[string]string{"uri": uri},
+		}, true)
+		prepare = s.send("textDocument/prepareRename", at(0, 5), true)
+	)
+
+	renameParam := at(1, 24)
+	renameParam["newName"] = "who"
+	renameParamID := s.send("textDocument/rename", renameParam, true)
+
+	renameFunction := at(4, 13)
+	renameFunction["newName"] = "welcome $x"
+	renameFunctionID := s.send("textDocument/rename", renameFunction, true)
+
+	// Break the call, which should give a diagnostic but
+	// keep the last good program for hovering.
+	s.send("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{
+			"range": map[string]interface{}{
+				"start": map[string]int{"line": 4, "character": 11},
+				"end":   map[string]int{"line": 4, "character": 11},
+			},
+			"text": "(",
+		}},
+	}, false)
+
+	staleHover := s.send("textDocument/hover", at(4, 14), true)
+
+	var (
+		results     = make(map[float64]string)
+		diagnostics []int
+	)
+
+	for _, msg := range s.run(t) {
+		if msg["method"] == "textDocument/publishDiagnostics" {
+			params := msg["params"].(map[string]interface{})
+			diagnostics = append(diagnostics, len(params["diagnostics"].([]interface{})))
+			continue
+		}
+
+		if id, ok := msg["id"].(float64); ok {
+			result, _ := json.Marshal(msg["result"])
+			results[id] = string(result)
+		}
+	}
+
+	if len(diagnostics) != 2 || diagnostics[0] != 0 || diagnostics[1] == 0 {
+		t.Errorf("expected no diagnostics, then some after the change. got %v", diagnostics)
+	}
+
+	expect := func(id int, substr string) {
+		if !strings.Contains(results[float64(id)], substr) {
+			t.Errorf("expected the result of request %d to contain %s, got %s", id, substr, results[float64(id)])
+		}
+	}
+
+	expect(hover, "def greet $name")
+	expect(definition, `"start":{"character":4,"line":0}`)
+	expect(symbols, `"name":"greet $name"`)
+	expect(symbols, `"name":"greeting"`)
+	expect(staleHover, "def greet $name")
+	expect(prepare, `"placeholder":"greet $name"`)
+	expect(renameParamID, `{"newText":"$who","range":{"end":{"character":28,"line":1},"start":{"character":23,"line":1}}}`)
+	expect(renameFunctionID, `{"newText":"welcome","range":{"end":{"character":17,"line":4},"start":{"character":12,"line":4}}}`)
+}
+
+// A use starting with ./ is found from the document's
+// directory, as the compiler finds it, wherever the server
+// is run from
+func TestRelativeUse(t *testing.T) {
+	var (
+		root = t.TempDir()
+		lib  = filepath.Join(root, "lib", "twice.pluto")
+		main = filepath.Join(root, "main.pluto")
+		uri  = "file://" + filepath.ToSlash(main)
+		text = "use \"./lib/twice.pluto\"\n\nx = twice 2\n"
+	)
+
+	t.Setenv("PLUTO", t.TempDir())
+
+	if err := os.MkdirAll(filepath.Dir(lib), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(lib, []byte("def twice $n {\n    return $n * 2\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	s := &session{}
+
+	s.send("initialize", map[string]interface{}{}, true)
+	s.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "version": 1, "text": text},
+	}, false)
+
+	var (
+		hover      = s.send("textDocument/hover", atIn(uri, 2, 5), true)
+		definition = s.send("textDocument/definition", atIn(uri, 2, 5), true)
+		use        = s.send("textDocument/definition", atIn(uri, 0, 8), true)
+		completion = s.send("textDocument/completion", atIn(uri, 2, 4), true)
+		results    = make(map[float64]string)
+	)
+
+	for _, msg := range s.run(t) {
+		if id, ok := msg["id"].(float64); ok {
+			result, _ := json.Marshal(msg["result"])
+			results[id] = string(result)
+		}
+	}
+
+	expect := func(id int, substr string) {
+		if !strings.Contains(results[float64(id)], substr) {
+			t.Errorf("expected the result of request %d to contain %s, got %s", id, substr, results[float64(id)])
+		}
+	}
+
+	expect(hover, "def twice $n")
+	expect(definition, "twice.pluto")
+	expect(definition, `"start":{"character":4,"line":0}`)
+	expect(use, "twice.pluto")
+	expect(completion, `"label":"twice $n"`)
+}
@@ -0,0 +1,165 @@
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// AssemblyExtension is the extension of bytecode assembly
+// files, which are written by 'pluto disasm'
+const AssemblyExtension = ".plasm"
+
+// Assemble assembles bytecode written as text into a module
+func Assemble(src, file string) (*Module, error) {
+	u, err := bytecode.Assemble(src, file)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Module{
+		Code:     u.Code,
+		Names:    u.Names,
+		Patterns: u.Patterns,
+	}
+
+	if m.Constants, err = constants(u); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	for _, nested := range u.Units {
+		if nested.Kind != bytecode.UnitFunction {
+			continue
+		}
+
+		fn, err := function(nested)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+
+		m.Functions = append(m.Functions, fn)
+	}
+
+	return m, nil
+}
+
+func function(u bytecode.Unit) (object.Function, error) {
+	fn := object.Function{
+		Body:     u.Code,
+		Names:    u.Names,
+		Patterns: u.Patterns,
+	}
+
+	for _, word := range strings.Fields(u.Name) {
+		tok := token.Token{Type: token.ID, Literal: word}
+
+		if strings.HasPrefix(word, "$") {
+			tok.Type, tok.Literal = token.Param, word[1:]
+			fn.Pattern = append(fn.Pattern, &ast.Parameter{Tok: tok, Name: tok.Literal})
+		} else {
+			fn.Pattern = append(fn.Pattern, &ast.Identifier{Tok: tok, Value: word})
+		}
+	}
+
+	var err error
+	fn.Constants, err = constants(u)
+
+	return fn, err
+}
+
+func block(u bytecode.Unit) (*object.Block, error) {
+	b := &object.Block{
+		Body:     u.Code,
+		Names:    u.Names,
+		Patterns: u.Patterns,
+	}
+
+	for _, param := range strings.Split(u.Name, ",") {
+		if param = strings.TrimSpace(param); param != "" {
+			tok := token.Token{Type: token.ID, Literal: param}
+			b.Params = append(b.Params, &ast.Identifier{Tok: tok, Value: param})
+		}
+	}
+
+	var err error
+	b.Constants, err = constants(u)
+
+	return b, err
+}
+
+// constants makes the objects of a unit's constants, and
+// of the blocks defined in it
+func constants(u bytecode.Unit) ([]object.Object, error) {
+	objs := make([]object.Object, len(u.Constants))
+
+	for _, nested := range u.Units {
+		switch nested.Kind {
+		case bytecode.UnitBlock:
+			b, err := block(nested)
+			if err != nil {
+				return nil, err
+			}
+
+			objs[nested.Index] = b
+
+		case bytecode.UnitFunction:
+			// Functions can only be defined at the top level,
+			// like in source code
+			if u.Kind != bytecode.UnitMain {
+				return nil, fmt.Errorf("function %s is defined inside .%s %s", nested.Name, u.Kind, u.Name)
+			}
+		}
+	}
+
+	for i, text := range u.Constants {
+		if objs[i] != nil {
+			continue
+		}
+
+		obj, err := constant(text)
+		if err != nil {
+			return nil, err
+		}
+
+		objs[i] = obj
+	}
+
+	return objs, nil
+}
+
+// constant makes the object for a constant's source text,
+// which is a number, string, character, boolean or null
+func constant(text string) (object.Object, error) {
+	switch text {
+	case "null":
+		return object.NullObj, nil
+	case "true":
+		return object.TrueObj, nil
+	case "false":
+		return object.FalseObj, nil
+	}
+
+	switch text[0] {
+	case '"':
+		if s, err := strconv.Unquote(text); err == nil {
+			return &object.String{Value: s}, nil
+		}
+
+	case '\'':
+		if s, err := strconv.Unquote(text); err == nil {
+			return &object.Char{Value: []rune(s)[0]}, nil
+		}
+
+	default:
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return &object.Number{Value: n}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid constant %s", text)
+}
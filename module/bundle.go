@@ -0,0 +1,290 @@
+package module
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// Prelude is what's used to load the prelude
+const Prelude = "std/prelude/*.pluto"
+
+// bundleMagic ends an executable with a bundle appended to
+// it, after the bundle's length
+const bundleMagic = "\x00pluto bundle\x00"
+
+// A Bundle is a program, with every package it uses
+// compiled, so it can be run without their sources
+type Bundle struct {
+	Main *Module
+
+	// Packages maps each string given to 'use', including
+	// the prelude, to the package's compiled sources
+	Packages map[string]*Module
+}
+
+// NewBundle bundles a program with the prelude, and every
+// package it uses, and every package they use. They're
+// found the same way as when the program is run.
+func NewBundle(main *Module) (*Bundle, error) {
+	b := &Bundle{
+		Main:     main,
+		Packages: make(map[string]*Module),
+	}
+
+	queue := append([]Use{{Package: Prelude}}, Uses(main)...)
+
+	for len(queue) > 0 {
+		use := queue[0]
+		queue = queue[1:]
+
+		if _, ok := b.Packages[use.Package]; ok {
+			continue
+		}
+
+		sources, err := pkg.LocateUse(use.Package, use.File)
+		if err != nil {
+			return nil, fmt.Errorf("use %s: %s", use.Package, err)
+		}
+
+		m, err := CompileFiles(sources)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Packages[use.Package] = m
+		queue = append(queue, Uses(m)...)
+	}
+
+	return b, nil
+}
+
+// CompileFiles compiles some source files together into
+// one module, which is how a package is used
+func CompileFiles(files []string) (*Module, error) {
+	var merged ast.Program
+
+	for _, file := range files {
+		src, err := literate.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		parse := parser.New(src, file)
+		prog := parse.Parse()
+
+		if len(parse.Errors) > 0 {
+			return nil, parse.Errors[0]
+		}
+
+		merged.Statements = append(merged.Statements, prog.Statements...)
+	}
+
+	return compile(merged)
+}
+
+// A Use is the package a use instruction uses, and the
+// file it's in, which the package is found from
+type Use struct {
+	Package, File string
+}
+
+// Uses returns the packages a module uses, in its code, its
+// functions, and its blocks
+func Uses(m *Module) []Use {
+	found := usesIn(m.Code, m.Constants)
+
+	for _, fn := range m.Functions {
+		found = append(found, usesIn(fn.Body, fn.Constants)...)
+	}
+
+	return found
+}
+
+func usesIn(code bytecode.Code, constants []object.Object) []Use {
+	var found []Use
+
+	for _, instr := range code {
+		if instr.Code != bytecode.Use || int(instr.Arg) >= len(constants) {
+			continue
+		}
+
+		if str, ok := constants[instr.Arg].(*object.String); ok {
+			found = append(found, Use{Package: str.Value, File: instr.Pos.File})
+		}
+	}
+
+	for _, c := range constants {
+		switch c := c.(type) {
+		case *object.Block:
+			found = append(found, usesIn(c.Body, c.Constants)...)
+		case *object.Function:
+			found = append(found, usesIn(c.Body, c.Constants)...)
+		}
+	}
+
+	return found
+}
+
+// Write writes the bundle in the compiled format
+func (b *Bundle) Write(w io.Writer) error {
+	bw := bytecode.NewWriter(w)
+
+	bw.WriteHeader()
+
+	if err := b.Main.write(bw); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(b.Packages))
+	for name := range b.Packages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	bw.Uint(uint64(len(names)))
+
+	for _, name := range names {
+		bw.String(name)
+
+		if err := b.Packages[name].write(bw); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReadBundle reads a bundle written by Write
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	br := bytecode.NewReader(r)
+
+	if err := br.ReadHeader(); err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{
+		Main:     read(br),
+		Packages: make(map[string]*Module),
+	}
+
+	for n := br.Len(); n > 0 && br.Err() == nil; n-- {
+		name := br.String()
+		b.Packages[name] = read(br)
+	}
+
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// WriteExecutable writes a copy of the executable runtime,
+// with the bundle appended to it, to path. If runtime
+// already has a bundle, it's replaced.
+func (b *Bundle) WriteExecutable(runtime, path string) error {
+	exe, err := ioutil.ReadFile(runtime)
+	if err != nil {
+		return err
+	}
+
+	if size, ok := bundleSize(exe); ok {
+		exe = exe[:len(exe)-size]
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		return err
+	}
+
+	length := make([]byte, 8)
+	binary.LittleEndian.PutUint64(length, uint64(buf.Len()))
+
+	exe = append(exe, buf.Bytes()...)
+	exe = append(exe, length...)
+	exe = append(exe, bundleMagic...)
+
+	return ioutil.WriteFile(path, exe, 0755)
+}
+
+// ReadExecutable reads the bundle appended to an executable,
+// returning nil if there isn't one
+func ReadExecutable(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	trailer := make([]byte, 8+len(bundleMagic))
+	if stat.Size() < int64(len(trailer)) {
+		return nil, nil
+	}
+
+	if _, err := f.ReadAt(trailer, stat.Size()-int64(len(trailer))); err != nil {
+		return nil, err
+	}
+
+	length, ok := bundleLength(trailer)
+	if !ok {
+		return nil, nil
+	}
+
+	start := stat.Size() - int64(len(trailer)) - int64(length)
+	if start < 0 {
+		return nil, fmt.Errorf("%s: invalid bundle", path)
+	}
+
+	section := io.NewSectionReader(f, start, int64(length))
+
+	b, err := ReadBundle(section)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return b, nil
+}
+
+// bundleLength returns the length of the bundle before the
+// trailer at the end of exe, if there is one
+func bundleLength(exe []byte) (uint64, bool) {
+	trailer := 8 + len(bundleMagic)
+
+	if len(exe) < trailer || string(exe[len(exe)-len(bundleMagic):]) != bundleMagic {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint64(exe[len(exe)-trailer:]), true
+}
+
+// bundleSize returns the size of the bundle at the end of
+// an executable, including its trailer, if there is one
+func bundleSize(exe []byte) (int, bool) {
+	trailer := 8 + len(bundleMagic)
+
+	length, ok := bundleLength(exe)
+	if !ok || length > uint64(len(exe)-trailer) {
+		return 0, false
+	}
+
+	return int(length) + trailer, true
+}
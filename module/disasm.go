@@ -0,0 +1,99 @@
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/object"
+)
+
+// Unit returns the module as a unit to be disassembled, with
+// its functions, and the blocks in its constants, as nested
+// units
+func (m *Module) Unit(file string) bytecode.Unit {
+	u := unit(bytecode.UnitMain, file, m.Code, m.Constants, m.Names, m.Patterns)
+
+	var fns []bytecode.Unit
+	for _, fn := range m.Functions {
+		fns = append(fns, functionUnit(fn))
+	}
+
+	u.Units = append(fns, u.Units...)
+
+	return u
+}
+
+func functionUnit(fn object.Function) bytecode.Unit {
+	return unit(bytecode.UnitFunction, patternText(fn.Pattern), fn.Body, fn.Constants, fn.Names, fn.Patterns)
+}
+
+func unit(kind, name string, code bytecode.Code, constants []object.Object, names, patterns []string) bytecode.Unit {
+	u := bytecode.Unit{
+		Kind:      kind,
+		Name:      name,
+		Code:      code,
+		Constants: make([]string, len(constants)),
+		Names:     names,
+		Patterns:  patterns,
+	}
+
+	for i, c := range constants {
+		u.Constants[i] = constantText(c)
+
+		switch c := c.(type) {
+		case *object.Block:
+			block := unit(bytecode.UnitBlock, blockParams(c), c.Body, c.Constants, c.Names, c.Patterns)
+			block.Index = i
+
+			u.Units = append(u.Units, block)
+		case *object.Function:
+			u.Units = append(u.Units, functionUnit(*c))
+		}
+	}
+
+	return u
+}
+
+// constantText shows a constant as it would be written in
+// source code, where it can be
+func constantText(obj object.Object) string {
+	switch o := obj.(type) {
+	case *object.String:
+		return strconv.Quote(o.Value)
+	case *object.Char:
+		return strconv.QuoteRune(o.Value)
+	case *object.Block:
+		return fmt.Sprintf("<block |%s|>", blockParams(o))
+	}
+
+	return obj.String()
+}
+
+func blockParams(block *object.Block) string {
+	params := make([]string, len(block.Params))
+
+	for i, p := range block.Params {
+		params[i] = p.Token().Literal
+	}
+
+	return strings.Join(params, ", ")
+}
+
+// patternText returns a function's pattern as it was
+// defined, such as "add $a to $b"
+func patternText(pattern []ast.Expression) string {
+	words := make([]string, len(pattern))
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words[i] = "$" + param.Name
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,448 @@
+package module
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// Extension is the extension of compiled files
+const Extension = ".plc"
+
+// A Module is a compiled program: everything the compiler
+// produces, so it can be run without being parsed or
+// compiled again.
+//
+// The bytecode package can't refer to objects, so it only
+// knows how to write the values constants are made of. The
+// constants themselves are written here.
+type Module struct {
+	Code      bytecode.Code
+	Constants []object.Object
+	Functions []object.Function
+	Names     []string
+	Patterns  []string
+}
+
+// Compile parses and compiles some source code into a
+// module
+func Compile(src, file string) (*Module, error) {
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		return nil, parse.Errors[0]
+	}
+
+	return compile(prog)
+}
+
+func compile(prog ast.Program) (*Module, error) {
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return nil, err
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Module{
+		Code:      code,
+		Constants: cmp.Constants,
+		Functions: cmp.Functions,
+		Names:     cmp.Names,
+		Patterns:  cmp.Patterns,
+	}, nil
+}
+
+// The tags written before each constant, saying which type
+// it is
+const (
+	tagNull byte = iota
+	tagBoolean
+	tagNumber
+	tagString
+	tagChar
+	tagTuple
+	tagArray
+	tagMap
+	tagBlock
+	tagFunction
+)
+
+// The tags written before each item in a pattern
+const (
+	tagWord byte = iota
+	tagParam
+)
+
+// Write writes the module in the compiled format
+func (m *Module) Write(w io.Writer) error {
+	bw := bytecode.NewWriter(w)
+
+	bw.WriteHeader()
+
+	if err := m.write(bw); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (m *Module) write(w *bytecode.Writer) error {
+	w.Code(m.Code)
+
+	if err := writeObjects(w, m.Constants); err != nil {
+		return err
+	}
+
+	if err := writeFunctions(w, m.Functions); err != nil {
+		return err
+	}
+
+	w.Strings(m.Names)
+	w.Strings(m.Patterns)
+
+	return nil
+}
+
+// Read reads a module written by Write
+func Read(r io.Reader) (*Module, error) {
+	br := bytecode.NewReader(r)
+
+	if err := br.ReadHeader(); err != nil {
+		return nil, err
+	}
+
+	m := read(br)
+
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func read(r *bytecode.Reader) *Module {
+	return &Module{
+		Code:      r.Code(),
+		Constants: readObjects(r),
+		Functions: readFunctions(r),
+		Names:     r.Strings(),
+		Patterns:  r.Strings(),
+	}
+}
+
+// ReadFile reads the module in a compiled file
+func ReadFile(path string) (*Module, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	m, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return m, nil
+}
+
+// WriteFile writes the module to a compiled file
+func (m *Module) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeObjects(w *bytecode.Writer, objs []object.Object) error {
+	w.Uint(uint64(len(objs)))
+
+	for _, obj := range objs {
+		if err := writeObject(w, obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeObject(w *bytecode.Writer, obj object.Object) error {
+	switch o := obj.(type) {
+	case *object.Null:
+		w.Byte(tagNull)
+
+	case *object.Boolean:
+		w.Byte(tagBoolean)
+
+		if o.Value {
+			w.Byte(1)
+		} else {
+			w.Byte(0)
+		}
+
+	case *object.Number:
+		w.Byte(tagNumber)
+		w.Float(o.Value)
+
+	case *object.String:
+		w.Byte(tagString)
+		w.String(o.Value)
+
+	case *object.Char:
+		w.Byte(tagChar)
+		w.Int(int64(o.Value))
+
+	case *object.Tuple:
+		w.Byte(tagTuple)
+		return writeObjects(w, o.Value)
+
+	case *object.Array:
+		w.Byte(tagArray)
+		return writeObjects(w, o.Value)
+
+	case *object.Map:
+		w.Byte(tagMap)
+
+		// The pairs are written in order of their hashes,
+		// so compiling the same code gives the same file
+		hashes := make([]string, 0, len(o.Keys))
+		for hash := range o.Keys {
+			hashes = append(hashes, hash)
+		}
+
+		sort.Strings(hashes)
+
+		w.Uint(uint64(len(hashes)))
+
+		for _, hash := range hashes {
+			if err := writeObject(w, o.Keys[hash]); err != nil {
+				return err
+			}
+
+			if err := writeObject(w, o.Values[hash]); err != nil {
+				return err
+			}
+		}
+
+	case *object.Block:
+		w.Byte(tagBlock)
+
+		if err := writePattern(w, o.Params); err != nil {
+			return err
+		}
+
+		return writeBody(w, o.Body, o.Constants, o.Names, o.Patterns)
+
+	case *object.Function:
+		w.Byte(tagFunction)
+		return writeFunction(w, *o)
+
+	default:
+		return fmt.Errorf("module: can't write a constant of type %s", obj.Type())
+	}
+
+	return nil
+}
+
+func readObjects(r *bytecode.Reader) []object.Object {
+	objs := make([]object.Object, r.Len())
+
+	for i := range objs {
+		objs[i] = readObject(r)
+	}
+
+	return objs
+}
+
+func readObject(r *bytecode.Reader) object.Object {
+	switch tag := r.Byte(); tag {
+	case tagNull:
+		return object.NullObj
+
+	case tagBoolean:
+		if r.Byte() != 0 {
+			return object.TrueObj
+		}
+
+		return object.FalseObj
+
+	case tagNumber:
+		return &object.Number{Value: r.Float()}
+
+	case tagString:
+		return &object.String{Value: r.String()}
+
+	case tagChar:
+		return &object.Char{Value: rune(r.Int())}
+
+	case tagTuple:
+		return &object.Tuple{Value: readObjects(r)}
+
+	case tagArray:
+		return &object.Array{Value: readObjects(r)}
+
+	case tagMap:
+		m := &object.Map{
+			Keys:   make(map[string]object.Object),
+			Values: make(map[string]object.Object),
+		}
+
+		for n := r.Len(); n > 0 && r.Err() == nil; n-- {
+			m.Set(readObject(r), readObject(r))
+		}
+
+		return m
+
+	case tagBlock:
+		block := &object.Block{Params: readPattern(r)}
+		block.Body, block.Constants, block.Names, block.Patterns = readBody(r)
+
+		return block
+
+	case tagFunction:
+		fn := readFunction(r)
+		return &fn
+
+	default:
+		if r.Err() == nil {
+			r.Fail(fmt.Errorf("module: unknown constant tag %d", tag))
+		}
+
+		return object.NullObj
+	}
+}
+
+func writeFunctions(w *bytecode.Writer, fns []object.Function) error {
+	w.Uint(uint64(len(fns)))
+
+	for _, fn := range fns {
+		if err := writeFunction(w, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFunction(w *bytecode.Writer, fn object.Function) error {
+	if fn.OnCall != nil {
+		return fmt.Errorf("module: can't write the builtin function %s", fn.String())
+	}
+
+	if err := writePattern(w, fn.Pattern); err != nil {
+		return err
+	}
+
+	w.String(fn.Doc)
+
+	return writeBody(w, fn.Body, fn.Constants, fn.Names, fn.Patterns)
+}
+
+func readFunctions(r *bytecode.Reader) []object.Function {
+	fns := make([]object.Function, r.Len())
+
+	for i := range fns {
+		fns[i] = readFunction(r)
+	}
+
+	return fns
+}
+
+func readFunction(r *bytecode.Reader) object.Function {
+	fn := object.Function{
+		Pattern: readPattern(r),
+		Doc:     r.String(),
+	}
+
+	fn.Body, fn.Constants, fn.Names, fn.Patterns = readBody(r)
+
+	return fn
+}
+
+// writeBody writes the code of a function or a block, with
+// the data it refers to
+func writeBody(w *bytecode.Writer, code bytecode.Code, constants []object.Object, names, patterns []string) error {
+	w.Code(code)
+
+	if err := writeObjects(w, constants); err != nil {
+		return err
+	}
+
+	w.Strings(names)
+	w.Strings(patterns)
+
+	return nil
+}
+
+func readBody(r *bytecode.Reader) (bytecode.Code, []object.Object, []string, []string) {
+	return r.Code(), readObjects(r), r.Strings(), r.Strings()
+}
+
+// writePattern writes a function's pattern, or a block's
+// parameters, as a list of words and parameter names
+func writePattern(w *bytecode.Writer, pattern []ast.Expression) error {
+	w.Uint(uint64(len(pattern)))
+
+	for _, item := range pattern {
+		switch i := item.(type) {
+		case *ast.Identifier:
+			w.Byte(tagWord)
+			w.String(i.Value)
+
+		case *ast.Parameter:
+			w.Byte(tagParam)
+			w.String(i.Name)
+
+		default:
+			return fmt.Errorf("module: can't write a pattern containing %s", item.Token().Literal)
+		}
+	}
+
+	return nil
+}
+
+func readPattern(r *bytecode.Reader) []ast.Expression {
+	pattern := make([]ast.Expression, r.Len())
+
+	for i := range pattern {
+		var (
+			tag  = r.Byte()
+			name = r.String()
+		)
+
+		if tag == tagParam {
+			pattern[i] = &ast.Parameter{
+				Tok:  token.Token{Type: token.Param, Literal: name},
+				Name: name,
+			}
+		} else {
+			pattern[i] = &ast.Identifier{
+				Tok:   token.Token{Type: token.ID, Literal: name},
+				Value: name,
+			}
+		}
+	}
+
+	return pattern
+}
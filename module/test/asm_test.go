@@ -0,0 +1,119 @@
+package test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	. "github.com/Zac-Garby/pluto/module"
+)
+
+const program = `def greet $name {
+    print ("hello, " + $name)
+}
+
+b = { |x, y| -> x * y }
+xs = [1.25, 'c', true, null]
+
+i = 0
+while (i < 3) {
+    if (i == 1) { i = i + 1; next }
+    greet "world"
+    i = i + 1
+}
+
+use "std/io"
+`
+
+// encode returns a module in the compiled format, which
+// includes its instructions' positions
+func encode(t *testing.T, m *Module) []byte {
+	var buf bytes.Buffer
+
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDisassembleAndAssemble(t *testing.T) {
+	compiled, err := Compile(program, "test.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listing bytes.Buffer
+	if err := bytecode.Disassemble(&listing, compiled.Unit("test.pluto")); err != nil {
+		t.Fatal(err)
+	}
+
+	assembled, err := Assemble(listing.String(), "test.plasm")
+	if err != nil {
+		t.Fatalf("%s\n%s", err, listing.String())
+	}
+
+	if !reflect.DeepEqual(compiled.Code, assembled.Code) {
+		t.Errorf("the main code differs after assembling:\n%s", listing.String())
+	}
+
+	if !bytes.Equal(encode(t, compiled), encode(t, assembled)) {
+		t.Errorf("the compiled module differs after assembling:\n%s", listing.String())
+	}
+
+	if !reflect.DeepEqual(compiled.Names, assembled.Names) || !reflect.DeepEqual(compiled.Patterns, assembled.Patterns) {
+		t.Errorf("names and patterns differ: %v %v, got %v %v", compiled.Names, compiled.Patterns, assembled.Names, assembled.Patterns)
+	}
+
+	if len(compiled.Constants) != len(assembled.Constants) {
+		t.Fatalf("expected %d constants, got %d", len(compiled.Constants), len(assembled.Constants))
+	}
+
+	for i, c := range compiled.Constants {
+		if !c.Equals(assembled.Constants[i]) {
+			t.Errorf("constant %d: expected %s, got %s", i, c, assembled.Constants[i])
+		}
+	}
+
+	if len(assembled.Functions) != 1 {
+		t.Fatalf("expected 1 function, got %d", len(assembled.Functions))
+	}
+
+	fn := assembled.Functions[0]
+	if fn.String() != "<function: greet $>" || !reflect.DeepEqual(fn.Body, compiled.Functions[0].Body) {
+		t.Errorf("function: expected %s, got %s", &compiled.Functions[0], &fn)
+	}
+
+	// Disassembling the assembled module should give the
+	// same listing
+	var again bytes.Buffer
+	if err := bytecode.Disassemble(&again, assembled.Unit("test.pluto")); err != nil {
+		t.Fatal(err)
+	}
+
+	if again.String() != listing.String() {
+		t.Errorf("expected:\n%s\ngot:\n%s", listing.String(), again.String())
+	}
+}
+
+func TestAssemblyErrors(t *testing.T) {
+	sources := map[string]string{
+		"unknown instruction": "FOO",
+		"undefined label":     "JUMP nowhere",
+		"missing argument":    "LOAD_CONST",
+		"unexpected argument": "POP 1",
+		"undefined constant":  "LOAD_CONST 2",
+		"missing end":         ".def f\nRETURN_FN",
+		"invalid constant":    "LOAD_CONST (foo)",
+		"huge index":          ".const 2000000000 \"x\"",
+		"huge argument":       "LOAD_NAME 70000 (x)",
+		"position as offset":  "1:1 2:2 POP",
+	}
+
+	for name, src := range sources {
+		if _, err := Assemble(src, "test.plasm"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
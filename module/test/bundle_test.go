@@ -0,0 +1,98 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/module"
+)
+
+func write(t *testing.T, path, text string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	packages := filepath.Join(home, "packages")
+	write(t, filepath.Join(packages, "std", "prelude", "io.pluto"), "def print $x {\n    return $x\n}\n")
+	write(t, filepath.Join(packages, "shapes", "shapes.pluto"), "use \"colours\"\n\ndef area $r {\n    return $r * $r\n}\n")
+	write(t, filepath.Join(packages, "colours", "colours.pluto"), "red = \"red\"\n")
+
+	main, err := Compile("use \"shapes\"\nprint (area 2)\n", "main.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBundle(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for name := range b.Packages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if expected := []string{"colours", "shapes", Prelude}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected the packages %v, got %v", expected, names)
+	}
+
+	// A fake runtime, which already has a bundle, which
+	// should be replaced
+	var (
+		dir     = t.TempDir()
+		runtime = filepath.Join(dir, "runtime")
+		exe     = filepath.Join(dir, "app")
+	)
+
+	write(t, runtime, "#!/bin/false\n")
+
+	if b, err := ReadExecutable(runtime); b != nil || err != nil {
+		t.Fatalf("expected no bundle in the runtime, got %v, %v", b, err)
+	}
+
+	if err := b.WriteExecutable(runtime, runtime+"2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.WriteExecutable(runtime+"2", exe); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadExecutable(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read == nil || len(read.Packages) != len(b.Packages) || len(read.Main.Code) != len(main.Code) {
+		t.Fatalf("the bundle read from the executable is different: %+v", read)
+	}
+
+	data, err := ioutil.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data[:13]) != "#!/bin/false\n" {
+		t.Errorf("expected the executable to start with the runtime")
+	}
+
+	first, _ := ioutil.ReadFile(runtime + "2")
+	if len(first) != len(data) {
+		t.Errorf("expected the old bundle to be replaced, but the size changed from %d to %d", len(first), len(data))
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	. "github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/object"
+)
+
+const source = `def add $a to $b {
+    sum = $a + $b
+    return sum
+}
+
+x = add 1.5 to 2
+b = { |n| -> n + "!" }
+c = 'c'
+m = ["k": [true, null, (1, 2)]]
+`
+
+func TestRoundTrip(t *testing.T) {
+	m, err := Compile(source, "test.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp := &object.Map{
+		Keys:   make(map[string]object.Object),
+		Values: make(map[string]object.Object),
+	}
+
+	mp.Set(&object.String{Value: "a"}, &object.Number{Value: 1})
+
+	// Every type of constant, including those which the
+	// compiler doesn't make itself
+	m.Constants = append(m.Constants,
+		object.NullObj,
+		object.TrueObj,
+		&object.Tuple{Value: []object.Object{&object.Char{Value: 'x'}}},
+		&object.Array{Value: []object.Object{&object.Number{Value: -0.25}}},
+		mp,
+		&m.Functions[0],
+	)
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	written := buf.Bytes()
+
+	read, err := Read(bytes.NewReader(written))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m.Code, read.Code) {
+		t.Errorf("code: expected %v, got %v", m.Code, read.Code)
+	}
+
+	if !reflect.DeepEqual(m.Names, read.Names) || !reflect.DeepEqual(m.Patterns, read.Patterns) {
+		t.Errorf("names and patterns differ: %v %v, got %v %v", m.Names, m.Patterns, read.Names, read.Patterns)
+	}
+
+	if len(m.Constants) != len(read.Constants) {
+		t.Fatalf("expected %d constants, got %d", len(m.Constants), len(read.Constants))
+	}
+
+	for i, c := range m.Constants {
+		if !c.Equals(read.Constants[i]) || c.String() != read.Constants[i].String() {
+			t.Errorf("constant %d: expected %s, got %s", i, c, read.Constants[i])
+		}
+	}
+
+	for _, c := range read.Constants {
+		if block, ok := c.(*object.Block); ok {
+			if p, ok := block.Params[0].(*ast.Identifier); !ok || p.Value != "n" {
+				t.Errorf("expected the block to have the parameter n, got %v", block.Params)
+			}
+
+			if len(block.Body) == 0 {
+				t.Errorf("expected the block to have a body")
+			}
+		}
+	}
+
+	fn := read.Functions[0]
+	if fn.String() != "<function: add $ to $>" || !reflect.DeepEqual(fn.Body, m.Functions[0].Body) {
+		t.Errorf("function: expected %s, got %s with body %v", &m.Functions[0], &fn, fn.Body)
+	}
+
+	// Writing it again should give exactly the same file
+	var again bytes.Buffer
+	if err := read.Write(&again); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(written, again.Bytes()) {
+		t.Errorf("writing a read module gave a different file")
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("x = 1"))); err != bytecode.ErrNotCompiled {
+		t.Errorf("expected ErrNotCompiled, got %v", err)
+	}
+
+	m, err := Compile(source, "test.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); err == nil {
+		t.Errorf("expected an error reading a truncated file")
+	}
+}
@@ -0,0 +1,200 @@
+package native
+
+import (
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/object"
+)
+
+// An Emission runs the instructions in an emission
+// expression, such as <$obj, PRINT_LINE>, on a stack of its
+// own
+type Emission struct {
+	rt    *Runtime
+	stack []object.Object
+}
+
+// emittable are the instructions which can be emitted in
+// transpiled code. The others refer to constants, names or
+// jumps, which only exist in bytecode.
+var emittable = map[byte]bool{
+	bytecode.Pop:        true,
+	bytecode.Dup:        true,
+	bytecode.LoadField:  true,
+	bytecode.StoreField: true,
+
+	bytecode.UnaryInvert: true,
+	bytecode.UnaryNegate: true,
+	bytecode.UnaryNoOp:   true,
+
+	bytecode.BinaryAdd:      true,
+	bytecode.BinarySubtract: true,
+	bytecode.BinaryMultiply: true,
+	bytecode.BinaryDivide:   true,
+	bytecode.BinaryExponent: true,
+	bytecode.BinaryFloorDiv: true,
+	bytecode.BinaryMod:      true,
+	bytecode.BinaryBitOr:    true,
+	bytecode.BinaryBitAnd:   true,
+	bytecode.BinaryEquals:   true,
+	bytecode.BinaryNotEqual: true,
+	bytecode.BinaryLessThan: true,
+	bytecode.BinaryMoreThan: true,
+	bytecode.BinaryLessEq:   true,
+	bytecode.BinaryMoreEq:   true,
+
+	bytecode.CallFn:  true,
+	bytecode.DoBlock: true,
+
+	bytecode.Print:   true,
+	bytecode.Println: true,
+	bytecode.Length:  true,
+	bytecode.Exit:    true,
+
+	bytecode.MakeArray: true,
+	bytecode.MakeTuple: true,
+	bytecode.MakeMap:   true,
+}
+
+// CanEmit checks if an instruction can be emitted in
+// transpiled code
+func CanEmit(op byte) bool {
+	return emittable[op]
+}
+
+// Emission starts an emission expression
+func (rt *Runtime) Emission() *Emission {
+	return &Emission{rt: rt}
+}
+
+// Push pushes the value of an expression
+func (e *Emission) Push(obj object.Object) {
+	e.stack = append(e.stack, e.rt.Value(obj))
+}
+
+// Value returns the value left on top of the stack, or nil
+// if there isn't one
+func (e *Emission) Value() object.Object {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	return e.stack[len(e.stack)-1]
+}
+
+func (e *Emission) pop() object.Object {
+	if len(e.stack) == 0 {
+		e.rt.Throw(ErrInternal, "nothing on the stack to pop in an emission")
+	}
+
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+
+	return top
+}
+
+// popN pops n values, returning them in the order they were
+// pushed
+func (e *Emission) popN(n int) []object.Object {
+	objs := make([]object.Object, n)
+
+	for i := n - 1; i >= 0; i-- {
+		objs[i] = e.pop()
+	}
+
+	return objs
+}
+
+// Do runs an instruction
+func (e *Emission) Do(op byte, arg int) {
+	rt := e.rt
+
+	switch op {
+	case bytecode.Pop:
+		e.pop()
+
+	case bytecode.Dup:
+		top := e.pop()
+		e.Push(top)
+		e.Push(top)
+
+	case bytecode.LoadField:
+		field, obj := e.pop(), e.pop()
+		e.Push(rt.LoadField(obj, field))
+
+	case bytecode.StoreField:
+		field, obj := e.pop(), e.pop()
+		rt.StoreField(e.Value(), obj, field)
+
+	case bytecode.UnaryInvert, bytecode.UnaryNegate, bytecode.UnaryNoOp:
+		e.Push(rt.Unary(op, e.pop()))
+
+	case bytecode.CallFn:
+		fn, ok := e.pop().(*object.Function)
+		if !ok {
+			rt.Throw(ErrWrongType, "cannot call a non-function")
+		}
+
+		e.push(rt.call(fn, e.popN(params(fn.Pattern))))
+
+	case bytecode.DoBlock:
+		top := e.pop()
+
+		block, ok := top.(*object.Block)
+		if !ok {
+			rt.Throw(ErrWrongType, "cannot 'do' a non-block. got %s", top.Type())
+		}
+
+		e.push(rt.doBlock(block, e.popN(len(block.Params))))
+
+	case bytecode.Print:
+		rt.Print(e.pop())
+
+	case bytecode.Println:
+		rt.Println(e.pop())
+
+	case bytecode.Length:
+		e.Push(rt.Length(e.pop()))
+
+	case bytecode.Exit:
+		rt.Exit(e.pop())
+
+	case bytecode.MakeArray:
+		e.Push(&object.Array{Value: e.popN(arg)})
+
+	case bytecode.MakeTuple:
+		e.Push(&object.Tuple{Value: e.popN(arg)})
+
+	case bytecode.MakeMap:
+		e.Push(rt.MakeMap(e.popN(arg * 2)...))
+
+	default:
+		if !emittable[op] {
+			rt.Throw(ErrNoInstruction, "bytecode instruction %s can't be run natively", bytecode.Instructions[op].Name)
+		}
+
+		right, left := e.pop(), e.pop()
+		e.Push(rt.Binary(op, left, right))
+	}
+}
+
+// push pushes the value a function or a block returned, if
+// it returned one
+func (e *Emission) push(obj object.Object) {
+	if obj != nil {
+		e.stack = append(e.stack, obj)
+	}
+}
+
+// params counts the parameters in a pattern
+func params(pattern []ast.Expression) int {
+	n := 0
+
+	for _, item := range pattern {
+		if _, ok := item.(*ast.Parameter); ok {
+			n++
+		}
+	}
+
+	return n
+}
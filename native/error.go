@@ -0,0 +1,72 @@
+package native
+
+import (
+	"fmt"
+
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// ErrType is a runtime error type. They're the same as the
+// virtual machine's, so errors read the same either way.
+type ErrType string
+
+const (
+	// ErrInternal is thrown for problems in the runtime itself
+	ErrInternal = "Internal"
+
+	// ErrUnknown is thrown when there's an error, but it isn't
+	// clear what nature it is
+	ErrUnknown = "Unknown"
+
+	// ErrNoInstruction is thrown by instructions which can't
+	// be run natively
+	ErrNoInstruction = "NoInstruction"
+
+	// ErrNotFound is thrown if a name, symbol, or index isn't found
+	ErrNotFound = "NotFound"
+
+	// ErrWrongType is thrown if an object is of the wrong type to be
+	// operated on
+	ErrWrongType = "WrongType"
+
+	// ErrNoOp is thrown if an operator isn't defined for the given
+	// operands
+	ErrNoOp = "NoOp"
+
+	// ErrSyntax is thrown for syntax errors which couldn't be
+	// found before running, such as a break outside a loop
+	ErrSyntax = "Syntax"
+)
+
+// Error is a runtime error thrown in transpiled code
+type Error struct {
+	Type    ErrType
+	Message string
+
+	// Pos is the position of the statement being executed
+	// when the error was thrown
+	Pos token.Position
+}
+
+func (e *Error) Error() string {
+	if e.Pos.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", e.Pos.File, e.Pos.Line, e.Type, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// Throw stops the program with a runtime error, at the
+// current statement
+func (rt *Runtime) Throw(t ErrType, msg string, format ...interface{}) {
+	panic(&Error{
+		Type:    t,
+		Message: fmt.Sprintf(msg, format...),
+		Pos:     rt.pos,
+	})
+}
+
+// exit is thrown to stop the program with an exit status
+type exit struct {
+	code int
+}
@@ -0,0 +1,308 @@
+// Package native is the runtime of programs transpiled to Go
+// by 'pluto build --go'. The generated code calls into it for
+// everything the virtual machine would do with an instruction,
+// using the same objects and stores, so a program behaves the
+// same either way.
+package native
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// A Body is the code of a program, a package, a function or
+// a block. It returns the value it left on the stack, if it
+// left one, like a frame in the virtual machine.
+type Body func(rt *Runtime) object.Object
+
+// Runtime runs a transpiled program
+type Runtime struct {
+	// Locals is the store names are defined in. Functions
+	// and blocks share their caller's store, as they do in
+	// the virtual machine.
+	Locals *store.Store
+
+	// Out is where PRINT and PRINT_LINE write to
+	Out io.Writer
+
+	// ExitCode is the status passed to the EXIT
+	// instruction, if it was executed
+	ExitCode int
+
+	// packages maps the strings given to 'use' to the
+	// packages' code
+	packages map[string]Body
+
+	// pos is the position of the current statement
+	pos token.Position
+}
+
+// New returns a runtime, which uses the given packages
+func New(packages map[string]Body) *Runtime {
+	return &Runtime{
+		Locals:   store.New(),
+		Out:      os.Stdout,
+		packages: packages,
+	}
+}
+
+// Main runs a program as 'pluto run' would, with its
+// arguments in 'args', and exits
+func Main(program Body, packages map[string]Body) {
+	var (
+		rt  = New(packages)
+		out = bufio.NewWriter(os.Stdout)
+	)
+
+	rt.Out = out
+	rt.Locals.Set("args", stringArray(os.Args[1:]), false)
+
+	err := rt.Run(program)
+	out.Flush()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(rt.ExitCode)
+}
+
+// Run runs a program in the runtime, returning the error it
+// threw, if it threw one
+func (rt *Runtime) Run(program Body) (err error) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case *Error:
+			err = r
+		case exit:
+			rt.ExitCode = r.code
+		default:
+			panic(r)
+		}
+	}()
+
+	program(rt)
+
+	return nil
+}
+
+// At sets the position of the statement being executed
+func (rt *Runtime) At(file string, line int) {
+	rt.pos.File, rt.pos.Line = file, line
+}
+
+// Use imports a package. As in the virtual machine, it runs
+// in its own store, and an error in it only stops the
+// package.
+func (rt *Runtime) Use(src string) {
+	body, ok := rt.packages[src]
+	if !ok {
+		rt.Throw(ErrUnknown, "use: %s wasn't built into the program", src)
+	}
+
+	var (
+		locals = rt.Locals
+		pos    = rt.pos
+		pkg    = store.New()
+	)
+
+	func() {
+		defer func() {
+			switch r := recover().(type) {
+			case nil, *Error, exit:
+			default:
+				panic(r)
+			}
+		}()
+
+		rt.Locals = pkg
+		body(rt)
+	}()
+
+	rt.Locals, rt.pos = locals, pos
+	locals.ImportModule(pkg, src)
+}
+
+// Def defines a function in the current store. Its pattern
+// is written as it's defined, such as "add $a to $b".
+func (rt *Runtime) Def(pattern string, body Body) {
+	fn := object.Function{
+		OnCall: func(*object.Function) object.Object {
+			return body(rt)
+		},
+	}
+
+	for _, word := range strings.Fields(pattern) {
+		tok := token.Token{Type: token.ID, Literal: word}
+
+		if strings.HasPrefix(word, "$") {
+			tok.Type, tok.Literal = token.Param, word[1:]
+			fn.Pattern = append(fn.Pattern, &ast.Parameter{Tok: tok, Name: tok.Literal})
+		} else {
+			fn.Pattern = append(fn.Pattern, &ast.Identifier{Tok: tok, Value: word})
+		}
+	}
+
+	rt.Locals.FunctionStore.Define(fn)
+}
+
+// LoadName returns the value of a name
+func (rt *Runtime) LoadName(name string) object.Object {
+	val := rt.Locals.GetName(name)
+	if val == nil {
+		rt.Throw(ErrNotFound, "name %s not found in the current scope", name)
+	}
+
+	return val
+}
+
+// StoreName assigns a value to a name, returning the value
+func (rt *Runtime) StoreName(name string, val object.Object) object.Object {
+	rt.Locals.Set(name, val, true)
+	return val
+}
+
+// Value returns val, which is only nil if a function or an
+// emission didn't leave a value to use
+func (rt *Runtime) Value(val object.Object) object.Object {
+	if val == nil {
+		rt.Throw(ErrInternal, "no value was returned to use")
+	}
+
+	return val
+}
+
+// Call calls the function matching a pattern, in the format
+// "print $ and $", with its arguments
+func (rt *Runtime) Call(pattern string, args ...object.Object) object.Object {
+	fn := rt.Locals.FunctionStore.SearchString(pattern)
+	if fn == nil {
+		rt.Throw(ErrNotFound, "function '%s' not found in the current scope", pattern)
+	}
+
+	return rt.call(fn, args)
+}
+
+// CallMethod calls the function matching a pattern in the
+// _methods of a package's map
+func (rt *Runtime) CallMethod(pattern string, args []object.Object, base object.Object) object.Object {
+	if base.Type() != object.MapType {
+		rt.Throw(ErrWrongType, "cannot call a method of non-map type %s", base.Type())
+	}
+
+	methods := base.(*object.Map).Get(&object.String{Value: "_methods"})
+
+	if methods == nil {
+		rt.Throw(ErrWrongType, "_methods key not found")
+	}
+
+	if methods.Type() != object.ArrayType {
+		rt.Throw(ErrWrongType, "_methods is not an array")
+	}
+
+	fns := &store.FunctionStore{}
+
+	for _, obj := range methods.(*object.Array).Elements() {
+		if fn, ok := obj.(*object.Function); ok {
+			fns.Functions = append(fns.Functions, *fn)
+		}
+	}
+
+	fn := fns.SearchString(pattern)
+	if fn == nil {
+		rt.Throw(ErrNotFound, "no method was found matching the pattern: '%s'", pattern)
+	}
+
+	return rt.call(fn, args)
+}
+
+// call binds a function's arguments to its parameters, in
+// the caller's store, and calls it
+func (rt *Runtime) call(fn *object.Function, args []object.Object) object.Object {
+	if fn.OnCall == nil {
+		rt.Throw(ErrNoInstruction, "function %s was compiled to bytecode, so can't be called natively", fn)
+	}
+
+	// Parameters are defined from the last, as the virtual
+	// machine pops them off the stack
+	n := len(args)
+
+	for i := len(fn.Pattern) - 1; i >= 0; i-- {
+		if param, ok := fn.Pattern[i].(*ast.Parameter); ok {
+			n--
+
+			if n < 0 {
+				rt.Throw(ErrInternal, "not enough arguments to call %s", fn)
+			}
+
+			rt.Locals.Set(param.Name, args[n], true)
+		}
+	}
+
+	pos := rt.pos
+	ret := fn.OnCall(fn)
+	rt.pos = pos
+
+	return ret
+}
+
+// blocks holds the code of the blocks made by NewBlock
+var blocks = make(map[*object.Block]Body)
+
+// NewBlock makes a block with some parameters, which runs
+// body when it's done
+func NewBlock(body Body, params ...string) *object.Block {
+	block := &object.Block{}
+
+	for _, param := range params {
+		block.Params = append(block.Params, &ast.Identifier{
+			Tok:   token.Token{Type: token.ID, Literal: param},
+			Value: param,
+		})
+	}
+
+	blocks[block] = body
+
+	return block
+}
+
+// doBlock runs a block, with its arguments, in the current
+// store
+func (rt *Runtime) doBlock(block *object.Block, args []object.Object) object.Object {
+	body, ok := blocks[block]
+	if !ok {
+		rt.Throw(ErrNoInstruction, "block was compiled to bytecode, so can't be done natively")
+	}
+
+	for i := len(block.Params) - 1; i >= 0; i-- {
+		rt.Locals.Set(block.Params[i].Token().Literal, args[i], true)
+	}
+
+	pos := rt.pos
+	ret := body(rt)
+	rt.pos = pos
+
+	return ret
+}
+
+func stringArray(strs []string) *object.Array {
+	arr := &object.Array{
+		Value: make([]object.Object, len(strs)),
+	}
+
+	for i, str := range strs {
+		arr.Value[i] = &object.String{Value: str}
+	}
+
+	return arr
+}
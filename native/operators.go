@@ -0,0 +1,286 @@
+package native
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/object"
+)
+
+// LoadField gets a field from a collection or a container
+func (rt *Runtime) LoadField(obj, field object.Object) object.Object {
+	if col, ok := obj.(object.Collection); ok {
+		index, ok := field.(object.Numeric)
+		if !ok {
+			rt.Throw(ErrWrongType, "non-numeric type %s used to index a collection", field.Type())
+		}
+
+		return col.GetIndex(int(index.Float64()))
+	} else if cont, ok := obj.(object.Container); ok {
+		return cont.Get(field)
+	}
+
+	rt.Throw(ErrNotFound, "cannot index type %s", obj.Type())
+	return nil
+}
+
+// StoreField sets a field of a collection or a container,
+// returning the value. The value comes first, since it's
+// evaluated first.
+func (rt *Runtime) StoreField(val, obj, field object.Object) object.Object {
+	if col, ok := obj.(object.Collection); ok {
+		index, ok := field.(object.Numeric)
+		if !ok {
+			rt.Throw(ErrWrongType, "non-numeric type %s used to index a collection", field.Type())
+		}
+
+		col.SetIndex(int(index.Float64()), val)
+	} else if cont, ok := obj.(object.Container); ok {
+		cont.Set(field, val)
+	} else {
+		rt.Throw(ErrWrongType, "cannot index type %s", obj.Type())
+	}
+
+	return val
+}
+
+// MakeMap makes a map from its keys and values, given in
+// turn
+func (rt *Runtime) MakeMap(pairs ...object.Object) object.Object {
+	m := &object.Map{
+		Keys:   make(map[string]object.Object, len(pairs)/2),
+		Values: make(map[string]object.Object, len(pairs)/2),
+	}
+
+	// The pairs are set from the last, as the virtual
+	// machine pops them off the stack
+	for i := len(pairs) - 2; i >= 0; i -= 2 {
+		key, val := pairs[i], pairs[i+1]
+
+		hasher, ok := key.(object.Hasher)
+		if !ok {
+			rt.Throw(ErrWrongType, "non-hashable type as map key: %s", key.Type())
+		}
+
+		hash := hasher.Hash()
+
+		m.Keys[hash] = key
+		m.Values[hash] = val
+	}
+
+	return m
+}
+
+// Unary applies a prefix operator, given as its opcode
+func (rt *Runtime) Unary(op byte, right object.Object) object.Object {
+	if op == bytecode.UnaryInvert {
+		return object.BoolObj(!object.IsTruthy(right))
+	}
+
+	n, ok := right.(object.Numeric)
+	if !ok {
+		rt.Throw(ErrWrongType, "prefix r-value of invalid type")
+	}
+
+	val := n.Float64()
+
+	if op == bytecode.UnaryNegate {
+		val = -val
+	}
+
+	return &object.Number{Value: val}
+}
+
+// Binary applies an infix operator, given as its opcode
+func (rt *Runtime) Binary(op byte, left, right object.Object) object.Object {
+	switch op {
+	case bytecode.BinaryEquals:
+		return object.BoolObj(left.Equals(right))
+	case bytecode.BinaryNotEqual:
+		return object.BoolObj(!left.Equals(right))
+	case bytecode.BinaryLessThan, bytecode.BinaryMoreThan, bytecode.BinaryLessEq, bytecode.BinaryMoreEq:
+		return rt.compare(op, left, right)
+	case bytecode.BinaryOr, bytecode.BinaryAnd:
+		rt.Throw(ErrNoInstruction, "bytecode instruction %s not implemented", bytecode.Instructions[op].Name)
+	}
+
+	if n, ok := left.(object.Numeric); ok {
+		if m, ok := right.(object.Numeric); ok {
+			return rt.numInfix(op, n.Float64(), m.Float64())
+		} else if m, ok := right.(object.Collection); ok {
+			return rt.numColInfix(op, n.Float64(), m)
+		}
+
+		rt.Throw(ErrWrongType, "infix r-value of invalid type when l-value is <number>")
+	} else if n, ok := left.(object.Collection); ok {
+		if m, ok := right.(object.Numeric); ok {
+			return rt.numColInfix(op, m.Float64(), n)
+		} else if m, ok := right.(object.Collection); ok {
+			return rt.colInfix(op, n, m)
+		}
+
+		rt.Throw(ErrWrongType, "infix r-value of invalid type when l-value is a collection")
+	}
+
+	rt.Throw(ErrWrongType, "infix l-value of invalid type")
+	return nil
+}
+
+func (rt *Runtime) numInfix(op byte, left, right float64) object.Object {
+	var val float64
+
+	switch op {
+	case bytecode.BinaryAdd:
+		val = left + right
+	case bytecode.BinarySubtract:
+		val = left - right
+	case bytecode.BinaryMultiply:
+		val = left * right
+	case bytecode.BinaryDivide:
+		val = left / right
+	case bytecode.BinaryExponent:
+		val = math.Pow(left, right)
+	case bytecode.BinaryFloorDiv:
+		val = math.Floor(left / right)
+	case bytecode.BinaryMod:
+		val = math.Mod(left, right)
+	case bytecode.BinaryBitOr:
+		val = float64(int64(left) | int64(right))
+	case bytecode.BinaryBitAnd:
+		val = float64(int64(left) & int64(right))
+	default:
+		rt.Throw(ErrNoOp, "operator %s not supported for two numbers", operator(op))
+	}
+
+	return &object.Number{Value: val}
+}
+
+func (rt *Runtime) numColInfix(op byte, left float64, right object.Collection) object.Object {
+	if op != bytecode.BinaryMultiply {
+		rt.Throw(ErrNoOp, "operator %s not supported for a collection and a number", operator(op))
+	}
+
+	var (
+		result   []object.Object
+		elements = right.Elements()
+	)
+
+	for i := 0; i < int(left); i++ {
+		result = append(result, elements...)
+	}
+
+	col, _ := object.MakeCollection(right.Type(), result)
+	return col
+}
+
+func (rt *Runtime) colInfix(op byte, left, right object.Collection) object.Object {
+	var (
+		lefts  = left.Elements()
+		rights = right.Elements()
+		elems  []object.Object
+	)
+
+	switch op {
+	case bytecode.BinaryAdd:
+		elems = append(lefts, rights...)
+	case bytecode.BinarySubtract:
+		for _, el := range lefts {
+			if !contains(rights, el) {
+				elems = append(elems, el)
+			}
+		}
+	case bytecode.BinaryBitOr:
+		for _, el := range append(lefts, rights...) {
+			if !contains(elems, el) {
+				elems = append(elems, el)
+			}
+		}
+	case bytecode.BinaryBitAnd:
+		for _, el := range lefts {
+			if contains(rights, el) {
+				elems = append(elems, el)
+			}
+		}
+	default:
+		rt.Throw(ErrNoOp, "operator %s not supported for two collections", operator(op))
+	}
+
+	col, _ := object.MakeCollection(left.Type(), elems)
+	return col
+}
+
+func (rt *Runtime) compare(op byte, left, right object.Object) object.Object {
+	n, ok := left.(object.Numeric)
+	if !ok {
+		rt.Throw(ErrWrongType, "non-numeric value in numeric binary expression")
+	}
+
+	m, ok := right.(object.Numeric)
+	if !ok {
+		rt.Throw(ErrWrongType, "non-numeric value in numeric binary expression")
+	}
+
+	var (
+		lval = n.Float64()
+		rval = m.Float64()
+	)
+
+	switch op {
+	case bytecode.BinaryLessThan:
+		return object.BoolObj(lval < rval)
+	case bytecode.BinaryMoreThan:
+		return object.BoolObj(lval > rval)
+	case bytecode.BinaryLessEq:
+		return object.BoolObj(lval <= rval)
+	default:
+		return object.BoolObj(lval >= rval)
+	}
+}
+
+// Length returns the length of a collection
+func (rt *Runtime) Length(obj object.Object) object.Object {
+	col, ok := obj.(object.Collection)
+	if !ok {
+		rt.Throw(ErrWrongType, "cannot get the length of type %s", obj.Type())
+	}
+
+	return &object.Number{Value: float64(len(col.Elements()))}
+}
+
+// Print writes an object to the runtime's output
+func (rt *Runtime) Print(obj object.Object) {
+	fmt.Fprint(rt.Out, obj)
+}
+
+// Println writes an object and a newline to the runtime's
+// output
+func (rt *Runtime) Println(obj object.Object) {
+	fmt.Fprintln(rt.Out, obj)
+}
+
+// Exit stops the program with a numeric exit status
+func (rt *Runtime) Exit(obj object.Object) {
+	code, ok := obj.(object.Numeric)
+	if !ok {
+		rt.Throw(ErrWrongType, "cannot exit with a status of type %s", obj.Type())
+	}
+
+	panic(exit{code: int(code.Float64())})
+}
+
+func contains(objs []object.Object, obj object.Object) bool {
+	for _, o := range objs {
+		if obj.Equals(o) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// operator returns the name of a binary operator's opcode,
+// without the "BINARY_" prefix
+func operator(op byte) string {
+	return bytecode.Instructions[op].Name[7:]
+}
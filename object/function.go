@@ -18,6 +18,9 @@ type (
 		Names     []string
 		Patterns  []string
 		OnCall    func(self *Function) Object
+
+		// Doc is the function's doc comment
+		Doc string
 	}
 )
 
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// docBefore returns the text of the doc comment ending on
+// the line before line, i.e. of the comments on their own
+// lines directly above it.
+func (p *Parser) docBefore(line int) string {
+	end := len(p.docs)
+	for end > 0 && p.docs[end-1].Start.Line >= line {
+		end--
+	}
+
+	start := end
+	for start > 0 && p.docs[start-1].Start.Line == line-(end-start)-1 {
+		start--
+	}
+
+	return docText(p.docs[start:end])
+}
+
+// fileDoc returns the text of the comments at the top of
+// a file, not including a '#!' line. If there's no blank
+// line between them and the first statement, they're the
+// statement's doc comment instead.
+func (p *Parser) fileDoc(prog ast.Program) string {
+	comments := p.docs
+	if len(comments) > 0 && comments[0].Start.Line == 1 && strings.HasPrefix(comments[0].Literal, "!") {
+		comments = comments[1:]
+	}
+
+	if len(comments) == 0 {
+		return ""
+	}
+
+	end := 1
+	for end < len(comments) && comments[end].Start.Line == comments[end-1].Start.Line+1 {
+		end++
+	}
+
+	if len(prog.Statements) > 0 {
+		first := prog.Statements[0].Token().Start.Line
+
+		if comments[end-1].Start.Line+1 >= first {
+			return ""
+		}
+	}
+
+	return docText(comments[:end])
+}
+
+// docText joins the text of some comments into lines,
+// removing the space after each #.
+func docText(comments []token.Token) string {
+	lines := make([]string, len(comments))
+
+	for i, c := range comments {
+		lines[i] = strings.TrimRight(strings.TrimPrefix(c.Literal, " "), " \t\r")
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -14,6 +14,10 @@ type Error struct {
 	Start, End token.Position
 }
 
+func (e Error) Error() string {
+	return fmt.Sprintf("%s:%s: %s", e.Start.File, e.Start.String(), e.Message)
+}
+
 // Err creates an Error instance with the given arguments
 func (p *Parser) Err(msg string, start, end token.Position) {
 	err := Error{
@@ -22,7 +26,7 @@ func (p *Parser) Err(msg string, start, end token.Position) {
 		End:     end,
 	}
 
-	p.Errors = append(p.Errors, err)
+	p.addErr(err)
 }
 
 func (p *Parser) defaultErr(msg string) {
@@ -32,9 +36,54 @@ func (p *Parser) defaultErr(msg string) {
 		End:     p.cur.End,
 	}
 
+	p.addErr(err)
+}
+
+func (p *Parser) addErr(err Error) {
+	if len(p.Errors) == 0 {
+		p.incomplete = p.curIs(token.EOF) || p.peekIs(token.EOF) || p.atEnd(err.Start) || p.unclosedString()
+	}
+
 	p.Errors = append(p.Errors, err)
 }
 
+// atEnd checks if there's only space from a position to
+// the end of the input, such as at the semicolon inserted
+// after the last line
+func (p *Parser) atEnd(pos token.Position) bool {
+	lines := strings.SplitAfter(p.text, "\n")
+	switch {
+	case pos.Line < 1:
+		return false
+	case pos.Line > len(lines):
+		return true
+	}
+
+	rest := lines[pos.Line-1]
+	if pos.Column-1 < len(rest) {
+		rest = rest[pos.Column-1:]
+	} else {
+		rest = ""
+	}
+
+	return strings.TrimSpace(rest+strings.Join(lines[pos.Line:], "")) == ""
+}
+
+// unclosedString checks if the next token is an illegal
+// quote, which is only illegal if the string it starts
+// isn't closed before the end of the input
+func (p *Parser) unclosedString() bool {
+	return p.peekIs(token.Illegal) && (p.peek.Literal == "\"" || p.peek.Literal == "`")
+}
+
+// Incomplete checks if the first parse error was caused
+// by the input ending too early, for example in the
+// middle of a block, a bracket or a string. If so, more
+// input might fix it.
+func (p *Parser) Incomplete() bool {
+	return p.incomplete
+}
+
 func (p *Parser) peekErr(ts ...token.Type) {
 	if len(ts) > 1 {
 		msg := "expected either "
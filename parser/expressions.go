@@ -278,15 +278,16 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	} else if p.peekIs(token.Elif) {
 		p.next()
 
-		expr.Alternative = &ast.BlockStatement{
+		stmt := &ast.ExpressionStatement{
 			Tok: p.cur,
-			Statements: []ast.Statement{
-				&ast.ExpressionStatement{
-					Tok:  p.cur,
-					Expr: p.parseIfExpression(),
-				},
-			},
 		}
+
+		expr.Alternative = &ast.BlockStatement{
+			Tok:        p.cur,
+			Statements: []ast.Statement{stmt},
+		}
+
+		stmt.Expr = p.parseIfExpression()
 	}
 
 	return expr
@@ -16,12 +16,23 @@ type infixParser func(ast.Expression) ast.Expression
 type Parser struct {
 	Errors []Error
 
+	// Comments are the comment tokens found so far,
+	// which are otherwise ignored by the parser
+	Comments []token.Token
+
+	// docs are the comments which are on their own
+	// lines, which can be doc comments
+	docs []token.Token
+
 	lex       func() token.Token
 	text      string
 	cur, peek token.Token
 	prefixes  map[token.Type]prefixParser
 	infixes   map[token.Type]infixParser
 	argTokens []token.Type
+
+	// whether the first error was at the end of the input
+	incomplete bool
 }
 
 // New returns a new parser for the
@@ -126,6 +137,16 @@ func (p *Parser) next() {
 	p.cur = p.peek
 	p.peek = p.lex()
 
+	for p.peek.Type == token.Comment {
+		p.Comments = append(p.Comments, p.peek)
+
+		if p.cur.End.Line != p.peek.Start.Line {
+			p.docs = append(p.docs, p.peek)
+		}
+
+		p.peek = p.lex()
+	}
+
 	if p.peek.Type == token.Illegal {
 		p.Err(
 			fmt.Sprintf("illegal token found: `%s`", p.peek.Literal),
@@ -151,5 +172,7 @@ func (p *Parser) Parse() ast.Program {
 		p.next()
 	}
 
+	prog.Doc = p.fileDoc(prog)
+
 	return prog
 }
@@ -58,10 +58,11 @@ func (p *Parser) parseBlockStatement() ast.Statement {
 
 func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{
-		Tok:  p.cur,
-		Expr: p.parseExpression(lowest),
+		Tok: p.cur,
 	}
 
+	stmt.Expr = p.parseExpression(lowest)
+
 	if stmt.Expr == nil {
 		return nil
 	}
@@ -101,6 +102,7 @@ func (p *Parser) parseBreakStatement() ast.Statement {
 func (p *Parser) parseDefStatement() ast.Statement {
 	stmt := &ast.FunctionDefinition{
 		Tok: p.cur,
+		Doc: p.docBefore(p.cur.Start.Line),
 	}
 
 	p.next()
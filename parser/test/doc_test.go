@@ -0,0 +1,64 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/Zac-Garby/pluto/ast"
+	. "github.com/Zac-Garby/pluto/parser"
+)
+
+const documented = `#!/usr/bin/env pluto
+# Maths helpers.
+#
+# They're very simple.
+
+# double returns twice x.
+# It works on numbers.
+def double $x {
+    return $x * 2 # not a doc comment
+}
+
+x = 1 # not a doc comment either
+def half $x {
+    return $x / 2
+}
+
+# Separated by a blank line, so not a doc comment
+
+def triple $x {
+    # Nor is this
+    return $x * 3
+}
+`
+
+func TestDocComments(t *testing.T) {
+	parse := New(documented, "<test suite>")
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		parse.PrintErrors()
+		t.Fatal("a parse error occured")
+	}
+
+	if expected := "Maths helpers.\n\nThey're very simple."; prog.Doc != expected {
+		t.Errorf("expected the file's doc to be %q, got %q", expected, prog.Doc)
+	}
+
+	docs := map[string]string{
+		"double": "double returns twice x.\nIt works on numbers.",
+		"half":   "",
+		"triple": "",
+	}
+
+	for _, stmt := range prog.Statements {
+		def, ok := stmt.(*ast.FunctionDefinition)
+		if !ok {
+			continue
+		}
+
+		name := def.Pattern[0].Token().Literal
+		if def.Doc != docs[name] {
+			t.Errorf("expected the doc of '%s' to be %q, got %q", name, docs[name], def.Doc)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/parser"
+)
+
+func TestIncomplete(t *testing.T) {
+	cases := map[string]bool{
+		// Input which ends too early
+		"def double $n {":             true,
+		"def double $n {\n    $n * 2": true,
+		"while (true) {":              true,
+		"x = [1, 2":                   true,
+		"x = (1 + ":                   true,
+		`x = "hello`:                  true,
+
+		// Complete input
+		"def double $n { $n * 2 }": false,
+		"while (true) { break }":   false,
+		"x = [1, 2]":               false,
+		`x = "hello"`:              false,
+		"":                         false,
+
+		// Errors which more input can't fix
+		"x = ]":    false,
+		"} else {": false,
+	}
+
+	for src, expected := range cases {
+		parse := New(src, "test")
+		parse.Parse()
+
+		if actual := parse.Incomplete(); actual != expected {
+			t.Errorf("%q: expected Incomplete() to be %t, got %t (errors: %v)", src, expected, actual, parse.Errors)
+		}
+	}
+}
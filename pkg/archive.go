@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zac-Garby/pluto/dir"
+)
+
+// Archive packs a package's manifest and Pluto sources
+// into a gzipped tarball. The output only depends on the
+// files' paths and contents, so archiving the same package
+// twice gives the same bytes, and therefore the same
+// checksum. Hidden files and directories are left out.
+func Archive(root string) ([]byte, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if file != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+
+		if rel == ManifestFile || dir.IsSource(rel) {
+			files = append(files, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var (
+		buf bytes.Buffer
+		gz  = gzip.NewWriter(&buf)
+		tw  = tar.NewWriter(gz)
+	)
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+
+		hdr := &tar.Header{
+			Name:     file,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatUSTAR,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Checksum returns the checksum of an archive, in the
+// form used in lockfiles
+func Checksum(archive []byte) string {
+	sum := sha256.Sum256(archive)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ReadArchive calls fn with the name and contents of
+// each file in an archive. Names always use slashes.
+func ReadArchive(archive []byte, fn func(name string, data []byte) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive: invalid file name '%s'", hdr.Name)
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// Extract unpacks an archive into a directory
+func Extract(archive []byte, dir string) error {
+	return ReadArchive(archive, func(name string, data []byte) error {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(file, data, 0644)
+	})
+}
+
+// ArchiveManifest reads the manifest in an archive. If
+// there isn't one, an empty manifest is returned.
+func ArchiveManifest(archive []byte) (*Manifest, error) {
+	var found *Manifest
+
+	err := ReadArchive(archive, func(name string, data []byte) error {
+		if name != ManifestFile {
+			return nil
+		}
+
+		m, err := ParseManifest(data)
+		found = m
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		found = &Manifest{Dependencies: make(map[string]string)}
+	}
+
+	return found, nil
+}
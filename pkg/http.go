@@ -0,0 +1,239 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenVariable is the environment variable holding the
+// token sent to HTTP registries when publishing
+const TokenVariable = "PLUTO_REGISTRY_TOKEN"
+
+const (
+	// checksumHeader carries an archive's checksum when it's
+	// published, so the server can check it arrived intact
+	checksumHeader = "X-Pluto-Checksum"
+
+	// maxArchiveSize is the largest archive a registry
+	// handler accepts
+	maxArchiveSize = 32 << 20
+
+	// registryTimeout is how long an HTTPRegistry waits
+	// for each request
+	registryTimeout = 30 * time.Second
+)
+
+// HTTPRegistry is a registry served over HTTP, such as by
+// RegistryHandler. The protocol is:
+//
+//	GET /name/                  a JSON array of the package's versions
+//	GET /name/version.tar.gz    the archive of a version
+//	PUT /name/version.tar.gz    publishes a version
+type HTTPRegistry struct {
+	URL   string
+	Token string
+
+	client http.Client
+}
+
+// Versions returns the available versions of a package
+func (r *HTTPRegistry) Versions(name string) ([]Version, error) {
+	data, err := r.get(r.URL + "/" + name + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []string
+
+	if err := json.Unmarshal(data, &strs); err != nil {
+		return nil, fmt.Errorf("registry: %s", err)
+	}
+
+	var versions []Version
+
+	for _, str := range strs {
+		if v, err := ParseVersion(str); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	sortVersions(versions)
+
+	return versions, nil
+}
+
+// Fetch downloads the archive of a version of a package
+func (r *HTTPRegistry) Fetch(name string, version Version) ([]byte, error) {
+	return r.get(r.URL + "/" + name + "/" + version.String() + archiveExt)
+}
+
+// Publish uploads an archive to the registry
+func (r *HTTPRegistry) Publish(name string, version Version, archive []byte) error {
+	url := r.URL + "/" + name + "/" + version.String() + archiveExt
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set(checksumHeader, Checksum(archive))
+
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry: %s", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		return ErrExists
+	default:
+		return responseError(resp)
+	}
+}
+
+func (r *HTTPRegistry) get(url string) ([]byte, error) {
+	resp, err := r.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	msg := strings.TrimSpace(string(body))
+
+	if msg == "" {
+		msg = resp.Status
+	}
+
+	return fmt.Errorf("registry: %s %s: %s", resp.Request.Method, resp.Request.URL, msg)
+}
+
+// RegistryHandler serves a registry over HTTP, so that an
+// HTTPRegistry can use it. If token isn't empty, it must
+// be given to publish packages.
+func RegistryHandler(reg Registry, token string) http.Handler {
+	return &registryHandler{reg: reg, token: token}
+}
+
+type registryHandler struct {
+	reg   Registry
+	token string
+}
+
+func (h *registryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	if !validName.MatchString(parts[0]) || len(parts) > 2 {
+		http.NotFound(w, req)
+		return
+	}
+
+	name := parts[0]
+
+	if len(parts) == 1 {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		h.versions(w, name)
+		return
+	}
+
+	version, err := ParseVersion(strings.TrimSuffix(parts[1], archiveExt))
+	if err != nil || !strings.HasSuffix(parts[1], archiveExt) {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		archive, err := h.reg.Fetch(name, version)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(archive)
+	case http.MethodPut:
+		h.publish(w, req, name, version)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *registryHandler) versions(w http.ResponseWriter, name string) {
+	versions, err := h.reg.Versions(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	strs := make([]string, len(versions))
+	for i, v := range versions {
+		strs[i] = v.String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(strs)
+}
+
+func (h *registryHandler) publish(w http.ResponseWriter, req *http.Request, name string, version Version) {
+	if h.token != "" && req.Header.Get("Authorization") != "Bearer "+h.token {
+		http.Error(w, "a valid token is needed to publish", http.StatusUnauthorized)
+		return
+	}
+
+	archive, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxArchiveSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if sum := req.Header.Get(checksumHeader); sum != "" && sum != Checksum(archive) {
+		http.Error(w, "the archive doesn't match its checksum", http.StatusBadRequest)
+		return
+	}
+
+	m, err := ArchiveManifest(archive)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if m.Name != name || m.Version != version.String() {
+		http.Error(w, "the archive's manifest doesn't match the URL", http.StatusBadRequest)
+		return
+	}
+
+	switch err := h.reg.Publish(name, version, archive); err {
+	case nil:
+		w.WriteHeader(http.StatusCreated)
+	case ErrExists:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
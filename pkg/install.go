@@ -0,0 +1,183 @@
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/dir"
+)
+
+// InstallDir returns the directory a version of a package
+// is installed in, which is $PLUTO/packages/name@version.
+// Several versions of a package can be installed at once.
+func InstallDir(name, version string) (string, error) {
+	path, err := dir.GetPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(path, "packages", name+"@"+version), nil
+}
+
+// Install downloads and unpacks every package in the lock
+// which isn't already installed. The archives' checksums
+// must match the ones in the lock. Returns the names of
+// the newly installed packages.
+func Install(lock *Lock, reg Registry) ([]string, error) {
+	var (
+		names     []string
+		installed []string
+	)
+
+	for name := range lock.Packages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		locked := lock.Packages[name]
+
+		target, err := InstallDir(name, locked.Version)
+		if err != nil {
+			return installed, err
+		}
+
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+
+		v, err := ParseVersion(locked.Version)
+		if err != nil {
+			return installed, fmt.Errorf("%s: %s: %s", LockFile, name, err)
+		}
+
+		archive, err := reg.Fetch(name, v)
+		if err != nil {
+			return installed, err
+		}
+
+		if sum := Checksum(archive); sum != locked.Checksum {
+			return installed, fmt.Errorf(
+				"%s %s: checksum mismatch\n  locked:     %s\n  downloaded: %s",
+				name, v, locked.Checksum, sum,
+			)
+		}
+
+		if err := extractInto(archive, target); err != nil {
+			return installed, fmt.Errorf("%s %s: %s", name, v, err)
+		}
+
+		installed = append(installed, name+"@"+locked.Version)
+	}
+
+	return installed, nil
+}
+
+// extractInto unpacks an archive into a temporary
+// directory first, so that a failed install doesn't
+// leave a half-written package behind.
+func extractInto(archive []byte, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir(filepath.Dir(target), ".install-")
+	if err != nil {
+		return err
+	}
+
+	if err := Extract(archive, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, target)
+}
+
+// LocateSources finds the source files for a use
+// statement in the working directory's project. See
+// LocateSourcesFrom.
+func LocateSources(src string) ([]string, error) {
+	return LocateSourcesFrom(".", src)
+}
+
+// Glob returns what a use statement in a file looks for.
+// A path starting with "./" is relative to the file's
+// directory, and is made absolute, so it's found whatever
+// the working directory is when the file is run.
+func Glob(src, file string) string {
+	if !strings.HasPrefix(src, "./") {
+		return src
+	}
+
+	dir, _ := filepath.Split(file)
+	src = filepath.Join(dir, src)
+
+	if abs, err := filepath.Abs(src); err == nil {
+		src = abs
+	}
+
+	return src
+}
+
+// LocateUse finds the source files for a use statement in
+// a file, as the virtual machine does: what Glob returns,
+// found from the file's directory.
+func LocateUse(src, file string) ([]string, error) {
+	return LocateSourcesFrom(filepath.Dir(file), Glob(src, file))
+}
+
+// LocateSourcesFrom finds the source files for a use
+// statement in a file in the directory from. If from is in
+// a project with a lockfile, and the first part of src
+// names a locked package, the locked version is used:
+// "maths" finds maths.pluto (or maths.lpluto) in that
+// version's directory, and "maths/vectors" finds the
+// vectors package inside it. Otherwise, it falls back to
+// dir.LocateAnySources.
+func LocateSourcesFrom(from, src string) ([]string, error) {
+	var (
+		parts = strings.SplitN(filepath.ToSlash(src), "/", 2)
+		name  = parts[0]
+	)
+
+	project, ok := FindProject(from)
+	if !ok {
+		return dir.LocateAnySources(src)
+	}
+
+	lock, err := ReadLock(project)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, ok := lock.Packages[name]
+	if !ok {
+		return dir.LocateAnySources(src)
+	}
+
+	installed, err := InstallDir(name, locked.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(installed); err != nil {
+		return nil, fmt.Errorf("use: %s %s is in %s but isn't installed. run 'pluto get'", name, locked.Version, LockFile)
+	}
+
+	if len(parts) == 1 {
+		main, ok := dir.MainSource(installed, name)
+		if !ok {
+			return nil, fmt.Errorf("use: %s %s has no %s.pluto", name, locked.Version, name)
+		}
+
+		return []string{main}, nil
+	}
+
+	return dir.LocateSources(installed, parts[1])
+}
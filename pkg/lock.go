@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// LockFile is the name of the file recording the exact
+// versions a project's dependencies were resolved to
+const LockFile = "pluto.lock"
+
+// Lock is the contents of a lockfile. It contains every
+// package needed by a project, including indirect
+// dependencies.
+type Lock struct {
+	Packages map[string]LockedPackage `json:"packages"`
+}
+
+// LockedPackage is an exact version of a package, along
+// with the checksum of its archive and the constraints
+// it puts on its own dependencies.
+type LockedPackage struct {
+	Version      string            `json:"version"`
+	Checksum     string            `json:"checksum"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// ReadLock reads the lockfile in a directory
+func ReadLock(dir string) (*Lock, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("%s: %s", LockFile, err)
+	}
+
+	if lock.Packages == nil {
+		lock.Packages = make(map[string]LockedPackage)
+	}
+
+	return lock, nil
+}
+
+// Write writes the lockfile to a directory. The packages
+// are sorted by name, so the output is deterministic.
+func (l *Lock) Write(dir string) error {
+	data, err := json.MarshalIndent(l, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, LockFile), append(data, '\n'), 0644)
+}
+
+// Satisfies checks if the lock still contains a suitable
+// version of every dependency in the manifest, and of
+// every locked package's dependencies.
+func (l *Lock) Satisfies(m *Manifest) bool {
+	if !l.satisfiesAll(m.Dependencies) {
+		return false
+	}
+
+	for _, pkg := range l.Packages {
+		if !l.satisfiesAll(pkg.Dependencies) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (l *Lock) satisfiesAll(deps map[string]string) bool {
+	for name, str := range deps {
+		locked, ok := l.Packages[name]
+		if !ok {
+			return false
+		}
+
+		c, err := ParseConstraint(str)
+		if err != nil {
+			return false
+		}
+
+		v, err := ParseVersion(locked.Version)
+		if err != nil || !c.Allows(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindProject searches start and its parent directories
+// for one containing a lockfile, and returns it.
+func FindProject(start string) (string, bool) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, LockFile)); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
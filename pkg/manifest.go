@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+)
+
+// ManifestFile is the name of the file describing a
+// package and its dependencies
+const ManifestFile = "pluto.json"
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Manifest is the contents of a pluto.json file. For
+// example:
+//
+//	{
+//	    "name": "shapes",
+//	    "version": "1.0.0",
+//	    "dependencies": {
+//	        "maths": "^2.1.0"
+//	    }
+//	}
+//
+// The registry is optional, and is where dependencies
+// are fetched from if one isn't given to 'pluto get'.
+type Manifest struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description,omitempty"`
+	Registry     string            `json:"registry,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// ValidName checks if name can be used as a package name.
+// It must start with a lowercase letter, and only contain
+// lowercase letters, digits, hyphens and underscores.
+func ValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// ReadManifest reads the manifest in a directory
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseManifest(data)
+}
+
+// ParseManifest parses the contents of a manifest and
+// checks that its dependencies are valid.
+func ParseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %s", ManifestFile, err)
+	}
+
+	if m.Dependencies == nil {
+		m.Dependencies = make(map[string]string)
+	}
+
+	for name, constraint := range m.Dependencies {
+		if !validName.MatchString(name) {
+			return nil, fmt.Errorf("%s: invalid package name '%s'", ManifestFile, name)
+		}
+
+		if _, err := ParseConstraint(constraint); err != nil {
+			return nil, fmt.Errorf("%s: dependency %s: %s", ManifestFile, name, err)
+		}
+	}
+
+	return m, nil
+}
+
+// Write writes the manifest to a directory
+func (m *Manifest) Write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, ManifestFile), append(data, '\n'), 0644)
+}
+
+// constraints parses the manifest's dependencies
+func (m *Manifest) constraints() (map[string]Constraint, error) {
+	cs := make(map[string]Constraint, len(m.Dependencies))
+
+	for name, str := range m.Dependencies {
+		c, err := ParseConstraint(str)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %s", name, err)
+		}
+
+		cs[name] = c
+	}
+
+	return cs, nil
+}
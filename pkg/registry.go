@@ -0,0 +1,183 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/dir"
+)
+
+const archiveExt = ".tar.gz"
+
+// Registry is somewhere packages are published to and
+// downloaded from
+type Registry interface {
+	// Versions returns the available versions of a
+	// package, from oldest to newest
+	Versions(name string) ([]Version, error)
+
+	// Fetch returns the archive of a version of a package
+	Fetch(name string, version Version) ([]byte, error)
+
+	// Publish adds a new version of a package. Published
+	// versions can't be replaced, so that checksums in
+	// lockfiles stay valid.
+	Publish(name string, version Version, archive []byte) error
+}
+
+// ErrExists is returned by Registry.Publish if the
+// version has already been published
+var ErrExists = errors.New("registry: that version has already been published")
+
+// DirRegistry is a registry in a local directory. Each
+// package has a directory, containing either an archive
+// or a directory for each version:
+//
+//	registry/
+//	    maths/
+//	        1.0.0.tar.gz
+//	        1.1.0/
+//	            pluto.json
+//	            maths.pluto
+//
+// Directories are archived when they're fetched.
+type DirRegistry struct {
+	Root string
+}
+
+// OpenRegistry returns the registry at a location, which
+// is either a directory or the URL of an HTTP registry. An
+// empty location means the default registry, which is
+// $PLUTO/registry.
+func OpenRegistry(location string) (Registry, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &HTTPRegistry{
+			URL:    strings.TrimSuffix(location, "/"),
+			Token:  os.Getenv(TokenVariable),
+			client: http.Client{Timeout: registryTimeout},
+		}, nil
+	}
+
+	if location == "" {
+		path, err := dir.GetPath()
+		if err != nil {
+			return nil, err
+		}
+
+		location = filepath.Join(path, "registry")
+	}
+
+	stat, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %s", err)
+	}
+
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("registry: %s is not a directory", location)
+	}
+
+	return &DirRegistry{Root: location}, nil
+}
+
+// Versions returns the available versions of a package
+func (r *DirRegistry) Versions(name string) ([]Version, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(r.Root, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("registry: package %s not found", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+
+	for _, entry := range entries {
+		str := entry.Name()
+
+		if !entry.IsDir() {
+			if !strings.HasSuffix(str, archiveExt) {
+				continue
+			}
+
+			str = strings.TrimSuffix(str, archiveExt)
+		}
+
+		if v, err := ParseVersion(str); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	sortVersions(versions)
+
+	return versions, nil
+}
+
+func sortVersions(versions []Version) {
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+}
+
+// Fetch returns the archive of a version of a package
+func (r *DirRegistry) Fetch(name string, version Version) ([]byte, error) {
+	base := filepath.Join(r.Root, name, version.String())
+
+	if stat, err := os.Stat(base); err == nil && stat.IsDir() {
+		return Archive(base)
+	}
+
+	data, err := ioutil.ReadFile(base + archiveExt)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("registry: %s %s not found", name, version)
+	}
+
+	return data, err
+}
+
+// Publish writes an archive to the registry directory
+func (r *DirRegistry) Publish(name string, version Version, archive []byte) error {
+	var (
+		pkgDir = filepath.Join(r.Root, name)
+		base   = filepath.Join(pkgDir, version.String())
+	)
+
+	for _, path := range []string{base, base + archiveExt} {
+		if _, err := os.Stat(path); err == nil {
+			return ErrExists
+		}
+	}
+
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		return err
+	}
+
+	// Written to a temporary file first, so that nothing
+	// can fetch a partly written archive
+	tmp, err := ioutil.TempFile(pkgDir, ".publish-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(archive); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), base+archiveExt)
+}
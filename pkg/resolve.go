@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxRounds limits how many times the resolver can change
+// its mind before giving up
+const maxRounds = 100
+
+// requirement is a constraint on a package, and which
+// package it comes from
+type requirement struct {
+	from       string
+	constraint Constraint
+}
+
+type resolver struct {
+	registry Registry
+	root     map[string]Constraint
+	versions map[string][]Version
+	packages map[string]*resolved
+}
+
+// resolved is a version of a package which has been
+// fetched from the registry
+type resolved struct {
+	version  Version
+	checksum string
+	deps     map[string]string
+}
+
+// Resolve chooses a version of each of a manifest's
+// dependencies, and of their dependencies, and so on.
+// Only one version of each package is used, which is the
+// newest one allowed by everything depending on it.
+func Resolve(m *Manifest, reg Registry) (*Lock, error) {
+	root, err := m.constraints()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &resolver{
+		registry: reg,
+		root:     root,
+		versions: make(map[string][]Version),
+		packages: make(map[string]*resolved),
+	}
+
+	chosen := make(map[string]Version)
+
+	for round := 0; round < maxRounds; round++ {
+		reqs, err := r.requirements(chosen)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make(map[string]Version, len(reqs))
+
+		for _, name := range sortedNames(reqs) {
+			v, err := r.choose(name, reqs[name])
+			if err != nil {
+				return nil, err
+			}
+
+			next[name] = v
+		}
+
+		if sameVersions(chosen, next) {
+			return r.lock(chosen), nil
+		}
+
+		chosen = next
+	}
+
+	return nil, fmt.Errorf("resolve: gave up after %d rounds; the dependencies might be circular in an unsatisfiable way", maxRounds)
+}
+
+// requirements collects the constraints put on each package
+// by the root manifest and the currently chosen versions.
+func (r *resolver) requirements(chosen map[string]Version) (map[string][]requirement, error) {
+	reqs := make(map[string][]requirement)
+
+	for name, c := range r.root {
+		reqs[name] = append(reqs[name], requirement{from: ManifestFile, constraint: c})
+	}
+
+	for name, v := range chosen {
+		pkg, err := r.fetch(name, v)
+		if err != nil {
+			return nil, err
+		}
+
+		for dep, str := range pkg.deps {
+			c, err := ParseConstraint(str)
+			if err != nil {
+				return nil, fmt.Errorf("resolve: %s %s: dependency %s: %s", name, v, dep, err)
+			}
+
+			reqs[dep] = append(reqs[dep], requirement{
+				from:       name + " " + v.String(),
+				constraint: c,
+			})
+		}
+	}
+
+	return reqs, nil
+}
+
+// choose returns the newest version of a package which
+// satisfies all the requirements on it
+func (r *resolver) choose(name string, reqs []requirement) (Version, error) {
+	versions, ok := r.versions[name]
+	if !ok {
+		var err error
+
+		versions, err = r.registry.Versions(name)
+		if err != nil {
+			return Version{}, err
+		}
+
+		r.versions[name] = versions
+	}
+
+outer:
+	for i := len(versions) - 1; i >= 0; i-- {
+		for _, req := range reqs {
+			if !req.constraint.Allows(versions[i]) {
+				continue outer
+			}
+		}
+
+		return versions[i], nil
+	}
+
+	var wanted []string
+	for _, req := range reqs {
+		wanted = append(wanted, fmt.Sprintf("%s (from %s)", req.constraint, req.from))
+	}
+
+	return Version{}, fmt.Errorf("resolve: no version of %s satisfies %s", name, strings.Join(wanted, " and "))
+}
+
+func (r *resolver) fetch(name string, v Version) (*resolved, error) {
+	key := name + "@" + v.String()
+
+	if pkg, ok := r.packages[key]; ok {
+		return pkg, nil
+	}
+
+	archive, err := r.registry.Fetch(name, v)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := ArchiveManifest(archive)
+	if err != nil {
+		return nil, fmt.Errorf("resolve: %s %s: %s", name, v, err)
+	}
+
+	pkg := &resolved{
+		version:  v,
+		checksum: Checksum(archive),
+		deps:     m.Dependencies,
+	}
+
+	r.packages[key] = pkg
+
+	return pkg, nil
+}
+
+func (r *resolver) lock(chosen map[string]Version) *Lock {
+	lock := &Lock{Packages: make(map[string]LockedPackage, len(chosen))}
+
+	for name, v := range chosen {
+		pkg := r.packages[name+"@"+v.String()]
+
+		lock.Packages[name] = LockedPackage{
+			Version:      v.String(),
+			Checksum:     pkg.checksum,
+			Dependencies: pkg.deps,
+		}
+	}
+
+	return lock
+}
+
+func sortedNames(reqs map[string][]requirement) []string {
+	var names []string
+
+	for name := range reqs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func sameVersions(a, b map[string]Version) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, v := range a {
+		if w, ok := b[name]; !ok || v.Compare(w) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,242 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, such as 1.4.2 or
+// 2.0.0-beta. Build metadata isn't supported.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// ParseVersion parses a version of the form
+// major.minor.patch, optionally followed by a
+// hyphen and a pre-release identifier.
+func ParseVersion(str string) (Version, error) {
+	var v Version
+
+	str = strings.TrimPrefix(strings.TrimSpace(str), "v")
+
+	if i := strings.Index(str, "-"); i >= 0 {
+		v.Pre = str[i+1:]
+		str = str[:i]
+
+		if v.Pre == "" {
+			return Version{}, fmt.Errorf("invalid version '%s': empty pre-release", str)
+		}
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version '%s': expected major.minor.patch", str)
+	}
+
+	nums := make([]int, 3)
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version '%s': '%s' is not a number", str, part)
+		}
+
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+func (v Version) String() string {
+	str := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Pre != "" {
+		str += "-" + v.Pre
+	}
+
+	return str
+}
+
+// Compare returns -1, 0, or 1 if v is less than, equal
+// to, or greater than o. A pre-release comes before the
+// release it's for.
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return sign(v.Major - o.Major)
+	case v.Minor != o.Minor:
+		return sign(v.Minor - o.Minor)
+	case v.Patch != o.Patch:
+		return sign(v.Patch - o.Patch)
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	case v.Pre < o.Pre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	} else if n > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// A comparison is a single condition in a constraint,
+// such as >=1.2.0
+type comparison struct {
+	op      string
+	version Version
+}
+
+func (c comparison) allows(v Version) bool {
+	cmp := v.Compare(c.version)
+
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+
+	return false
+}
+
+// Constraint is a set of allowed versions. It's written
+// as alternatives separated by ||, each of which is a
+// space-separated list of comparisons which must all
+// hold. As well as =, >, >=, < and <=, there are:
+//
+//	^1.2.3  any version compatible with 1.2.3, i.e. >=1.2.3 <2.0.0
+//	~1.2.3  any patch of 1.2, i.e. >=1.2.3 <1.3.0
+//	*       any version
+//
+// A version on its own must match exactly. Pre-releases
+// are only allowed if a comparison mentions one.
+type Constraint struct {
+	source string
+	alts   [][]comparison
+}
+
+// ParseConstraint parses a version constraint, such as
+// "^1.2.0" or ">=1.0.0 <1.5.0 || ^2.0.0".
+func ParseConstraint(str string) (Constraint, error) {
+	c := Constraint{source: strings.TrimSpace(str)}
+
+	for _, alt := range strings.Split(str, "||") {
+		var comparisons []comparison
+
+		for _, field := range strings.Fields(alt) {
+			cmps, err := parseComparison(field)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid constraint '%s': %s", c.source, err)
+			}
+
+			comparisons = append(comparisons, cmps...)
+		}
+
+		if len(comparisons) == 0 {
+			return Constraint{}, fmt.Errorf("invalid constraint '%s': empty alternative", c.source)
+		}
+
+		c.alts = append(c.alts, comparisons)
+	}
+
+	return c, nil
+}
+
+func parseComparison(field string) ([]comparison, error) {
+	if field == "*" {
+		return []comparison{{op: ">=", version: Version{}}}, nil
+	}
+
+	op := "="
+
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(field, prefix) {
+			op = prefix
+			field = field[len(prefix):]
+			break
+		}
+	}
+
+	v, err := ParseVersion(field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		var upper Version
+
+		switch {
+		case v.Major > 0:
+			upper = Version{Major: v.Major + 1}
+		case v.Minor > 0:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Patch: v.Patch + 1}
+		}
+
+		return []comparison{{">=", v}, {"<", upper}}, nil
+	case "~":
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		return []comparison{{">=", v}, {"<", upper}}, nil
+	}
+
+	return []comparison{{op, v}}, nil
+}
+
+// Allows checks if v satisfies the constraint.
+func (c Constraint) Allows(v Version) bool {
+	for _, alt := range c.alts {
+		if allowsAll(alt, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func allowsAll(comparisons []comparison, v Version) bool {
+	mentionsPre := false
+
+	for _, cmp := range comparisons {
+		if !cmp.allows(v) {
+			return false
+		}
+
+		if cmp.version.Pre != "" && sameRelease(cmp.version, v) {
+			mentionsPre = true
+		}
+	}
+
+	return v.Pre == "" || mentionsPre
+}
+
+func sameRelease(a, b Version) bool {
+	return a.Major == b.Major && a.Minor == b.Minor && a.Patch == b.Patch
+}
+
+func (c Constraint) String() string {
+	return c.source
+}
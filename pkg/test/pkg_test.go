@@ -0,0 +1,183 @@
+package test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/pkg"
+)
+
+func TestConstraints(t *testing.T) {
+	cases := map[string]map[string]bool{
+		"1.2.3": {"1.2.3": true, "1.2.4": false},
+		"^1.2.3": {
+			"1.2.3": true, "1.9.0": true, "2.0.0": false, "1.2.2": false, "1.3.0-beta": false,
+		},
+		"^0.2.3":                 {"0.2.9": true, "0.3.0": false},
+		"~1.2.3":                 {"1.2.9": true, "1.3.0": false},
+		">=1.0.0 <1.5.0":         {"1.4.9": true, "1.5.0": false, "0.9.0": false},
+		"^1.0.0 || ^3.0.0":       {"1.1.0": true, "2.0.0": false, "3.2.1": true},
+		"*":                      {"0.0.1": true, "10.0.0": true, "1.0.0-rc1": false},
+		">=2.0.0-beta":           {"2.0.0-rc": true, "2.0.0": true, "2.1.0-beta": false},
+		"2.0.0-beta || >=2.0.0-": nil,
+	}
+
+	for str, versions := range cases {
+		c, err := ParseConstraint(str)
+		if versions == nil {
+			if err == nil {
+				t.Errorf("expected an error parsing constraint '%s'", str)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("could not parse constraint '%s': %s", str, err)
+			continue
+		}
+
+		for vstr, expected := range versions {
+			v, err := ParseVersion(vstr)
+			if err != nil {
+				t.Errorf("could not parse version '%s': %s", vstr, err)
+				continue
+			}
+
+			if c.Allows(v) != expected {
+				t.Errorf("'%s' allowing %s: expected %t", str, vstr, expected)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	packages := map[string]string{
+		"maths/1.0.0":   `{"name": "maths", "version": "1.0.0"}`,
+		"maths/1.4.0":   `{"name": "maths", "version": "1.4.0"}`,
+		"maths/2.0.0":   `{"name": "maths", "version": "2.0.0"}`,
+		"vectors/1.0.0": `{"name": "vectors", "version": "1.0.0", "dependencies": {"maths": "~1.0.0"}}`,
+		"vectors/1.1.0": `{"name": "vectors", "version": "1.1.0", "dependencies": {"maths": "^1.2.0"}}`,
+	}
+
+	for path, manifest := range packages {
+		dir := filepath.Join(root, path)
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reg := &DirRegistry{Root: root}
+
+	cases := []struct {
+		deps     map[string]string
+		expected map[string]string
+	}{
+		{map[string]string{"maths": "*"}, map[string]string{"maths": "2.0.0"}},
+		{map[string]string{"vectors": "^1.0.0"}, map[string]string{"vectors": "1.1.0", "maths": "1.4.0"}},
+		{map[string]string{"vectors": "1.0.0"}, map[string]string{"vectors": "1.0.0", "maths": "1.0.0"}},
+		{map[string]string{"vectors": "^1.0.0", "maths": "^2.0.0"}, nil},
+	}
+
+	for _, c := range cases {
+		lock, err := Resolve(&Manifest{Dependencies: c.deps}, reg)
+		if c.expected == nil {
+			if err == nil {
+				t.Errorf("expected %v to be unresolvable", c.deps)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("could not resolve %v: %s", c.deps, err)
+			continue
+		}
+
+		if len(lock.Packages) != len(c.expected) {
+			t.Errorf("resolving %v gave %d packages, expected %d", c.deps, len(lock.Packages), len(c.expected))
+		}
+
+		for name, version := range c.expected {
+			if got := lock.Packages[name].Version; got != version {
+				t.Errorf("resolving %v chose %s %s, expected %s", c.deps, name, got, version)
+			}
+		}
+	}
+}
+
+func TestHTTPRegistry(t *testing.T) {
+	root, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pkgDir := filepath.Join(root, "src")
+
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &Manifest{Name: "maths", Version: "1.0.0"}
+	if err := manifest.Write(pkgDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(pkgDir, "maths.pluto"), []byte("x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Archive(pkgDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again, _ := Archive(pkgDir); Checksum(again) != Checksum(archive) {
+		t.Errorf("archiving the same package twice gave different checksums")
+	}
+
+	server := httptest.NewServer(RegistryHandler(&DirRegistry{Root: filepath.Join(root, "registry")}, "token"))
+	defer server.Close()
+
+	reg := &HTTPRegistry{URL: server.URL}
+	v := Version{Major: 1}
+
+	if err := reg.Publish("maths", v, archive); err == nil {
+		t.Errorf("expected publishing without a token to fail")
+	}
+
+	reg.Token = "token"
+
+	if err := reg.Publish("maths", v, archive); err != nil {
+		t.Fatalf("could not publish: %s", err)
+	}
+
+	if err := reg.Publish("maths", v, archive); err != ErrExists {
+		t.Errorf("expected publishing twice to give ErrExists, got %v", err)
+	}
+
+	versions, err := reg.Versions("maths")
+	if err != nil || len(versions) != 1 || versions[0].Compare(v) != 0 {
+		t.Errorf("expected versions [1.0.0], got %v (%v)", versions, err)
+	}
+
+	fetched, err := reg.Fetch("maths", v)
+	if err != nil || Checksum(fetched) != Checksum(archive) {
+		t.Errorf("the fetched archive is different to the published one (%v)", err)
+	}
+}
@@ -0,0 +1,57 @@
+package playground
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// maxSourceSize is the largest program the /run endpoint
+// accepts
+const maxSourceSize = 1 << 20
+
+// A Request is the body of a request to /run
+type Request struct {
+	Code string `json:"code"`
+}
+
+// Handler serves the playground. The routes are:
+//
+//	GET  /       the editor page
+//	POST /run    runs the Request in the body, responding with
+//	             its Result as JSON
+func Handler(limits Limits) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+
+	mux.HandleFunc("/run", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var r Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxSourceSize)).Decode(&r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Run(r.Code, limits))
+	})
+
+	return mux
+}
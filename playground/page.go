@@ -0,0 +1,98 @@
+package playground
+
+// page is the editor page served at /. Ctrl-Enter runs the
+// code, as does the button.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Pluto Playground</title>
+<style>
+	body { margin: 0; font-family: sans-serif; display: flex; flex-direction: column; height: 100vh; }
+	header { padding: 8px 12px; background: #223; color: #eef; display: flex; align-items: center; gap: 12px; }
+	header h1 { font-size: 18px; margin: 0; flex: 1; }
+	main { flex: 1; display: flex; min-height: 0; }
+	textarea, pre { flex: 1; margin: 0; padding: 12px; font: 14px monospace; box-sizing: border-box; overflow: auto; }
+	textarea { border: none; border-right: 1px solid #ccc; resize: none; tab-size: 4; }
+	pre { background: #f7f7f9; white-space: pre-wrap; }
+	.error { color: #b00; }
+	.status { color: #667; }
+</style>
+</head>
+<body>
+<header>
+	<h1>Pluto Playground</h1>
+	<button id="run">Run</button>
+</header>
+<main>
+	<textarea id="code" spellcheck="false">def greet $name {
+    print ("Hello, " + $name + "!")
+}
+
+greet "world"
+</textarea>
+	<pre id="output"></pre>
+</main>
+<script>
+	var code = document.getElementById("code");
+	var output = document.getElementById("output");
+	var button = document.getElementById("run");
+
+	function line(text, cls) {
+		var span = document.createElement("span");
+		span.className = cls;
+		span.textContent = text + "\n";
+		output.appendChild(span);
+	}
+
+	function run() {
+		button.disabled = true;
+		output.textContent = "";
+		line("running...", "status");
+
+		fetch("/run", {
+			method: "POST",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify({ code: code.value })
+		}).then(function (resp) {
+			if (!resp.ok) {
+				return resp.text().then(function (text) { throw new Error(text); });
+			}
+
+			return resp.json();
+		}).then(function (res) {
+			output.textContent = res.output;
+
+			(res.parseErrors || []).forEach(function (err) {
+				line(err.start.line + ":" + err.start.column + ": " + err.message, "error");
+			});
+
+			if (res.error) {
+				var at = res.error.line ? res.error.line + ": " : "";
+				line(at + res.error.type + ": " + res.error.message, "error");
+			}
+
+			line("exited with status " + res.exitCode, "status");
+		}).catch(function (err) {
+			line(err.message, "error");
+		}).then(function () {
+			button.disabled = false;
+		});
+	}
+
+	button.addEventListener("click", run);
+
+	code.addEventListener("keydown", function (e) {
+		if (e.key === "Enter" && (e.ctrlKey || e.metaKey)) {
+			e.preventDefault();
+			run();
+		} else if (e.key === "Tab") {
+			e.preventDefault();
+			var start = code.selectionStart;
+			code.setRangeText("    ", start, code.selectionEnd, "end");
+		}
+	});
+</script>
+</body>
+</html>
+`
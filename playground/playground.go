@@ -0,0 +1,236 @@
+// Package playground runs code submitted over HTTP, so that
+// pluto can be tried from a browser without installing it.
+// Each program is run in a fresh virtual machine, with limits
+// on how long it can run and how much it can print.
+//
+// It isn't a sandbox: programs can use the packages installed
+// where it's served, so it should only be served locally.
+package playground
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+// File is the file name programs are run as, which appears in
+// their errors
+const File = "playground.pluto"
+
+// Limits are the limits a program runs under. A limit which
+// is zero is replaced with the default.
+type Limits struct {
+	// Time is how long a program can run for
+	Time time.Duration
+
+	// Output is the most bytes a program can print
+	Output int
+
+	// Depth is how deeply functions and blocks can be
+	// called inside each other
+	Depth int
+}
+
+// DefaultLimits are the limits used in place of zeros
+var DefaultLimits = Limits{
+	Time:   5 * time.Second,
+	Output: 64 << 10,
+	Depth:  1000,
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.Time <= 0 {
+		l.Time = DefaultLimits.Time
+	}
+
+	if l.Output <= 0 {
+		l.Output = DefaultLimits.Output
+	}
+
+	if l.Depth <= 0 {
+		l.Depth = DefaultLimits.Depth
+	}
+
+	return l
+}
+
+// Result is what running a program gave, as it's sent back
+// from the /run endpoint
+type Result struct {
+	// Output is what the program printed, up to the limit
+	Output string `json:"output"`
+
+	// ParseErrors are the errors found parsing the program,
+	// in which case it isn't run
+	ParseErrors []ParseError `json:"parseErrors,omitempty"`
+
+	// Error is the error which stopped the program, if any
+	Error *Error `json:"error,omitempty"`
+
+	// ExitCode is the status the program exited with
+	ExitCode int `json:"exitCode"`
+}
+
+// A ParseError is a parser.Error, with its positions
+type ParseError struct {
+	Message string   `json:"message"`
+	Start   Position `json:"start"`
+	End     Position `json:"end"`
+}
+
+// A Position is a line and a column, both counted from 1
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// An Error is an error from compiling the program, or a
+// runtime error thrown by the virtual machine
+type Error struct {
+	// Type is the runtime error's type, such as NotFound,
+	// or Compile for a compile error
+	Type string `json:"type"`
+
+	Message string `json:"message"`
+
+	// Line is where the error was thrown, or 0 if that
+	// isn't known
+	Line int `json:"line,omitempty"`
+}
+
+// ErrCompile is the type of compile errors
+const ErrCompile = "Compile"
+
+// Run parses, compiles and runs a program in a fresh virtual
+// machine, with the prelude, under the limits. If the virtual
+// machine panics, it's returned as an Internal error.
+func Run(src string, limits Limits) (res *Result) {
+	limits = limits.withDefaults()
+
+	defer func() {
+		if r := recover(); r != nil {
+			res = &Result{
+				Error:    &Error{Type: vm.ErrInternal, Message: fmt.Sprint(r)},
+				ExitCode: 1,
+			}
+		}
+	}()
+
+	parse := parser.New(src, File)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		errs := make([]ParseError, len(parse.Errors))
+		for i, err := range parse.Errors {
+			errs[i] = parseError(err)
+		}
+
+		return &Result{ParseErrors: errs}
+	}
+
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return &Result{Error: &Error{Type: ErrCompile, Message: err.Error()}, ExitCode: 1}
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		return &Result{Error: &Error{Type: ErrCompile, Message: err.Error()}, ExitCode: 1}
+	}
+
+	var (
+		machine = vm.New()
+		locals  = store.New()
+		out     = &limitedWriter{max: limits.Output, machine: machine}
+		depth   = 0
+		deep    = false
+	)
+
+	machine.Out = out
+
+	// The main frame counts as a call, so it's allowed one
+	// more than the limit
+	machine.CallHook = func(*vm.Frame) {
+		if depth++; depth > limits.Depth+1 {
+			deep = true
+			machine.Interrupt()
+		}
+	}
+
+	machine.ReturnHook = func(*vm.Frame) {
+		depth--
+	}
+
+	locals.Names = cmp.Names
+	locals.Patterns = cmp.Patterns
+	locals.FunctionStore.Define(cmp.Functions...)
+
+	timer := time.AfterFunc(limits.Time, machine.Interrupt)
+	machine.Run(code, locals, cmp.Constants, true)
+	timedOut := !timer.Stop()
+
+	res = &Result{Output: out.buf.String(), ExitCode: machine.ExitCode}
+
+	if e := machine.Error; e != nil {
+		res.Error = &Error{Type: string(e.Type), Message: e.Message}
+		res.ExitCode = 1
+
+		if len(e.Trace) > 0 {
+			res.Error.Line = e.Trace[0].Line
+		}
+
+		// Interrupted programs are stopped by a limit, so
+		// the error says which one
+		if e.Type == vm.ErrInterrupted {
+			switch {
+			case out.exceeded:
+				res.Error.Message = fmt.Sprintf("printed more than the limit of %d bytes", limits.Output)
+			case deep:
+				res.Error.Message = fmt.Sprintf("called more than %d functions deep", limits.Depth)
+			case timedOut:
+				res.Error.Message = fmt.Sprintf("ran for longer than the limit of %s", limits.Time)
+			}
+		}
+	}
+
+	return res
+}
+
+func parseError(err parser.Error) ParseError {
+	return ParseError{
+		Message: err.Message,
+		Start:   position(err.Start),
+		End:     position(err.End),
+	}
+}
+
+func position(pos token.Position) Position {
+	return Position{Line: pos.Line, Column: pos.Column}
+}
+
+// limitedWriter collects what a program prints, interrupting
+// it when it prints more than max bytes
+type limitedWriter struct {
+	buf      bytes.Buffer
+	max      int
+	exceeded bool
+	machine  *vm.VirtualMachine
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if room := w.max - w.buf.Len(); len(p) > room {
+		w.buf.Write(p[:room])
+		w.exceeded = true
+		w.machine.Interrupt()
+
+		return len(p), nil
+	}
+
+	return w.buf.Write(p)
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/Zac-Garby/pluto/playground"
+)
+
+const prelude = `def print $obj {
+    <$obj, PRINT_LINE>
+}
+
+def exit $code {
+    <$code, EXIT>
+}
+`
+
+func TestServer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	dir := filepath.Join(home, "packages", "std", "prelude")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "io.pluto"), []byte(prelude), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(Handler(Limits{Time: 200 * time.Millisecond, Output: 20, Depth: 50}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("the editor page gave %s, %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+
+	cases := map[string]struct {
+		output, errType string
+		parseErrors     int
+		line, status    int
+	}{
+		"print (1 + 2)\nprint (\"hi\")":    {output: "3\nhi\n"},
+		"print (1)\nexit 4\nprint (2)":     {output: "1\n", status: 4},
+		"print (1)\nprint (x)":             {output: "1\n", errType: "NotFound", line: 2, status: 1},
+		"x = (1 +\ny = ]":                  {parseErrors: 2},
+		"while (true) {}":                  {errType: "Interrupted", status: 1},
+		"while (true) { print (\"abc\") }": {output: "abc\nabc\nabc\nabc\nabc\n", errType: "Interrupted", status: 1},
+		"def f $n { f ($n + 1) }\nf 0":     {errType: "Interrupted", status: 1},
+	}
+
+	for code, expected := range cases {
+		res := run(t, server.URL, code)
+
+		if res.Output != expected.output || res.ExitCode != expected.status {
+			t.Errorf("%q: got output %q and status %d", code, res.Output, res.ExitCode)
+		}
+
+		if len(res.ParseErrors) != expected.parseErrors {
+			t.Errorf("%q: expected %d parse errors, got %+v", code, expected.parseErrors, res.ParseErrors)
+		}
+
+		switch {
+		case res.Error == nil && expected.errType != "":
+			t.Errorf("%q: expected a %s error", code, expected.errType)
+		case res.Error != nil && res.Error.Type != expected.errType:
+			t.Errorf("%q: expected a %s error, got %+v", code, expected.errType, res.Error)
+		case res.Error != nil && expected.line != 0 && res.Error.Line != expected.line:
+			t.Errorf("%q: expected the error on line %d, got %d", code, expected.line, res.Error.Line)
+		}
+	}
+
+	resp, err = http.Get(server.URL + "/run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /run gave %s", resp.Status)
+	}
+}
+
+func run(t *testing.T, url, code string) *Result {
+	body, err := json.Marshal(Request{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(url+"/run", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%q: /run gave %s", code, resp.Status)
+	}
+
+	res := &Result{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
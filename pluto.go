@@ -1,42 +1,116 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
 
-	"github.com/Zac-Garby/pluto/bytecode"
 	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/module"
 	"github.com/Zac-Garby/pluto/object"
 	"github.com/Zac-Garby/pluto/parser"
 	"github.com/Zac-Garby/pluto/store"
 	"github.com/Zac-Garby/pluto/vm"
-
-	"github.com/fatih/color"
 )
 
+const usage = `usage: pluto [command] [arguments]
+
+commands:
+  repl                  start an interactive session (the default)
+  run <file> [args...]  execute a Pluto source file, or a compiled .plc file
+  compile <files...>    compile source or .plasm files into .plc files
+  disasm <files...>     print the bytecode compiled from source or .plc files
+  bundle <file>         make an executable which runs a program and its packages
+  build --go <file>     transpile a program to Go, and build it natively
+  fmt [-w] [files...]   format source files in the canonical style
+  highlight <file>      highlight a source file as HTML, or for a terminal
+  test [-run regexp]    run the tests in *_test.pluto files
+  bench [-run regexp]   run the benchmarks in *_bench.pluto files
+  doc [package]         print a package's documentation
+  lint [files...]       report likely mistakes, without running (or 'check')
+  deps <file>           print the files a program loads, as a tree or a graph
+  rename <pos> <name>   rename a variable, or a function across a project
+  get [packages...]     install the dependencies in pluto.json
+  publish [directory]   check a package and publish it to a registry
+  registry [directory]  serve a registry directory over HTTP
+  serve                 serve a playground, to edit and run code in a browser
+  lsp                   run a language server for editors, over stdio
+  dap                   run a debug adapter for editors, over stdio
+  debug <file>          debug a program from the command line
+
+'pluto <file> [args...]' is shorthand for 'pluto run <file> [args...]'
+`
+
+// errParse is returned by execute if the source
+// couldn't be parsed. The parse errors themselves
+// will have already been printed.
+var errParse = errors.New("parse error")
+
+// commands maps each subcommand to the function
+// which handles it. The function is given the
+// arguments after the subcommand's name, and
+// returns the process' exit status.
+var commands = map[string]func([]string) int{
+	"repl":      replCommand,
+	"run":       runCommand,
+	"compile":   compileCommand,
+	"disasm":    disasmCommand,
+	"bundle":    bundleCommand,
+	"build":     buildCommand,
+	"fmt":       fmtCommand,
+	"highlight": highlightCommand,
+	"test":      testCommand,
+	"bench":     benchCommand,
+	"doc":       docCommand,
+	"lint":      lintCommand,
+	"check":     lintCommand,
+	"deps":      depsCommand,
+	"rename":    renameCommand,
+	"get":       getCommand,
+	"publish":   publishCommand,
+	"registry":  registryCommand,
+	"serve":     serveCommand,
+	"lsp":       lspCommand,
+	"dap":       dapCommand,
+	"debug":     debugCommand,
+}
+
 func main() {
-	store := store.New()
-	usePrelude := true
-
-	for {
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print(">> ")
-		text, _ := reader.ReadString('\n')
-		text = strings.TrimRight(text, "\n")
-
-		if obj, err := execute(text, "<repl>", store, usePrelude); err != nil {
-			color.Red("  %s", err)
-		} else if obj != nil {
-			color.Cyan("  %s", obj)
-		}
-
-		usePrelude = false
+	args := os.Args[1:]
+
+	// A bundled program runs instead of pluto's commands
+	if b, err := bundled(); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		os.Exit(1)
+	} else if b != nil {
+		os.Exit(runBundle(b, args))
+	}
+
+	if len(args) == 0 {
+		os.Exit(replCommand(args))
+	}
+
+	if cmd, ok := commands[args[0]]; ok {
+		os.Exit(cmd(args[1:]))
 	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		os.Exit(0)
+	}
+
+	// Allows scripts to be run as 'pluto script.pluto',
+	// which is what a '#!/usr/bin/env pluto' line does
+	if stat, err := os.Stat(args[0]); err == nil && !stat.IsDir() {
+		os.Exit(runCommand(args))
+	}
+
+	fmt.Fprintf(os.Stderr, "pluto: unknown command '%s'\n\n%s", args[0], usage)
+	os.Exit(2)
 }
 
-func execute(text, file string, store *store.Store, prelude bool) (object.Object, error) {
+func execute(machine *vm.VirtualMachine, text, file string, store *store.Store, prelude bool) (object.Object, error) {
 	var (
 		cmp   = compiler.New()
 		parse = parser.New(text, file)
@@ -45,7 +119,7 @@ func execute(text, file string, store *store.Store, prelude bool) (object.Object
 
 	if len(parse.Errors) > 0 {
 		parse.PrintErrors()
-		return nil, nil
+		return nil, errParse
 	}
 
 	err := cmp.CompileProgram(prog)
@@ -53,18 +127,29 @@ func execute(text, file string, store *store.Store, prelude bool) (object.Object
 		return nil, err
 	}
 
-	code, err := bytecode.Read(cmp.Bytes)
+	code, err := cmp.Code()
 	if err != nil {
 		return nil, err
 	}
 
-	store.Names = cmp.Names
-	store.FunctionStore.Define(cmp.Functions...)
+	return runModule(machine, &module.Module{
+		Code:      code,
+		Constants: cmp.Constants,
+		Functions: cmp.Functions,
+		Names:     cmp.Names,
+		Patterns:  cmp.Patterns,
+	}, store, prelude)
+}
+
+// runModule runs a compiled module in the store, returning
+// the value left on the stack
+func runModule(machine *vm.VirtualMachine, m *module.Module, store *store.Store, prelude bool) (object.Object, error) {
+	store.Names = m.Names
+	store.FunctionStore.Define(m.Functions...)
 
-	store.Patterns = cmp.Patterns
+	store.Patterns = m.Patterns
 
-	machine := vm.New()
-	machine.Run(code, store, cmp.Constants, prelude)
+	machine.Run(m.Code, store, m.Constants, prelude)
 
 	if machine.Error != nil {
 		return nil, machine.Error
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// prelude is a small prelude for the tests, which print
+const prelude = `def print $obj {
+    <$obj, PRINT_LINE>
+}
+`
+
+// setup makes PLUTO a new directory with the prelude in it,
+// and writes the files, which are relative to a new
+// directory, which is returned
+func setup(t *testing.T, files map[string]string) string {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	writeFiles(t, filepath.Join(home, "packages", "std", "prelude"), map[string]string{"io.pluto": prelude})
+
+	root := t.TempDir()
+	writeFiles(t, root, files)
+
+	return root
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, text := range files {
+		path := filepath.Join(root, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// chdir changes the working directory until the test ends
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// capture returns what f writes to os.Stdout
+func capture(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		done <- out
+	}()
+
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	f()
+
+	w.Close()
+	return string(<-done)
+}
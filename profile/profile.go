@@ -0,0 +1,203 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+// Function is what a profile records about a function,
+// which is identified by its pattern.
+type Function struct {
+	Pattern string
+	Calls   int
+
+	// Inclusive is the time spent in the function and
+	// the functions it called. Recursive calls are only
+	// counted once. Exclusive is the time spent in just
+	// the function itself.
+	Inclusive, Exclusive time.Duration
+}
+
+// call is a frame which is being run
+type call struct {
+	frame    *vm.Frame
+	start    time.Time
+	children time.Duration
+}
+
+// Profile records the calls and instructions run by a
+// virtual machine. Main frames are recorded as "main",
+// and blocks as "block".
+type Profile struct {
+	Functions map[string]*Function
+
+	// Opcodes is the number of times each instruction
+	// was run, by its name
+	Opcodes map[string]int
+
+	// Stacks is the exclusive time spent in each stack
+	// of calls, outermost first and separated by ';'
+	Stacks map[string]time.Duration
+
+	calls []call
+}
+
+// New makes an empty profile
+func New() *Profile {
+	return &Profile{
+		Functions: make(map[string]*Function),
+		Opcodes:   make(map[string]int),
+		Stacks:    make(map[string]time.Duration),
+	}
+}
+
+// Attach makes the profile record what machine runs, by
+// setting its call, return and instruction hooks.
+func (p *Profile) Attach(machine *vm.VirtualMachine) {
+	machine.CallHook = p.enter
+	machine.ReturnHook = p.exit
+	machine.InstructionHook = p.instruction
+}
+
+func (p *Profile) enter(f *vm.Frame) {
+	fn, ok := p.Functions[f.Name()]
+	if !ok {
+		fn = &Function{Pattern: f.Name()}
+		p.Functions[f.Name()] = fn
+	}
+
+	fn.Calls++
+
+	p.calls = append(p.calls, call{frame: f, start: time.Now()})
+}
+
+func (p *Profile) exit(f *vm.Frame) {
+	if len(p.calls) == 0 {
+		return
+	}
+
+	var (
+		c       = p.calls[len(p.calls)-1]
+		elapsed = time.Now().Sub(c.start)
+		self    = elapsed - c.children
+		fn      = p.Functions[f.Name()]
+	)
+
+	fn.Exclusive += self
+	p.Stacks[p.stack()] += self
+
+	p.calls = p.calls[:len(p.calls)-1]
+
+	if !p.running(f.Name()) {
+		fn.Inclusive += elapsed
+	}
+
+	if len(p.calls) > 0 {
+		p.calls[len(p.calls)-1].children += elapsed
+	}
+}
+
+func (p *Profile) instruction(f *vm.Frame, i bytecode.Instruction) {
+	p.Opcodes[i.Name]++
+}
+
+// stack returns the names of the frames being run,
+// separated by ';'
+func (p *Profile) stack() string {
+	names := make([]string, len(p.calls))
+
+	for i, c := range p.calls {
+		names[i] = c.frame.Name()
+	}
+
+	return strings.Join(names, ";")
+}
+
+// running checks if a function is being run, further up
+// the stack
+func (p *Profile) running(name string) bool {
+	for _, c := range p.calls {
+		if c.frame.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteReport writes a table of the functions, slowest
+// first, then a table of the opcodes, most run first.
+func (p *Profile) WriteReport(w io.Writer) error {
+	fns := make([]*Function, 0, len(p.Functions))
+	for _, fn := range p.Functions {
+		fns = append(fns, fn)
+	}
+
+	sort.Slice(fns, func(i, j int) bool {
+		if fns[i].Exclusive != fns[j].Exclusive {
+			return fns[i].Exclusive > fns[j].Exclusive
+		}
+
+		return fns[i].Pattern < fns[j].Pattern
+	})
+
+	var err error
+
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("%10s  %12s  %12s  %s\n", "calls", "inclusive", "exclusive", "function")
+	for _, fn := range fns {
+		printf("%10d  %12s  %12s  %s\n", fn.Calls, fn.Inclusive, fn.Exclusive, fn.Pattern)
+	}
+
+	names := make([]string, 0, len(p.Opcodes))
+	for name := range p.Opcodes {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if p.Opcodes[names[i]] != p.Opcodes[names[j]] {
+			return p.Opcodes[names[i]] > p.Opcodes[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	printf("\n%10s  %s\n", "count", "instruction")
+	for _, name := range names {
+		printf("%10d  %s\n", p.Opcodes[name], name)
+	}
+
+	return err
+}
+
+// WriteFolded writes the stacks in the "folded" format
+// which flame graph tools, such as flamegraph.pl, read.
+// Each line is a stack followed by the microseconds spent
+// in it.
+func (p *Profile) WriteFolded(w io.Writer) error {
+	stacks := make([]string, 0, len(p.Stacks))
+	for stack := range p.Stacks {
+		stacks = append(stacks, stack)
+	}
+
+	sort.Strings(stacks)
+
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, p.Stacks[stack].Microseconds()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
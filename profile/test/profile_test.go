@@ -0,0 +1,88 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/parser"
+	. "github.com/Zac-Garby/pluto/profile"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+const program = `def double $x {
+    return $x * 2
+}
+
+def quadruple $x {
+    return double (double $x)
+}
+
+a = quadruple 1
+b = double 3
+`
+
+func TestProfile(t *testing.T) {
+	parse := parser.New(program, "main.pluto")
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		t.Fatal(parse.Errors[0])
+	}
+
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.New()
+	st.Names = cmp.Names
+	st.Patterns = cmp.Patterns
+	st.FunctionStore.Define(cmp.Functions...)
+
+	var (
+		machine = vm.New()
+		prof    = New()
+	)
+
+	prof.Attach(machine)
+	machine.Run(code, st, cmp.Constants, false)
+
+	if machine.Error != nil {
+		t.Fatal(machine.Error)
+	}
+
+	calls := map[string]int{"main": 1, "quadruple $x": 1, "double $x": 3}
+	for pattern, n := range calls {
+		if fn := prof.Functions[pattern]; fn == nil || fn.Calls != n {
+			t.Errorf("expected %d calls to '%s', got %+v", n, pattern, fn)
+		}
+	}
+
+	if n := prof.Opcodes["CALL_FN"]; n != 4 {
+		t.Errorf("expected CALL_FN to run 4 times, got %d", n)
+	}
+
+	main := prof.Functions["main"]
+	if main.Inclusive < prof.Functions["quadruple $x"].Inclusive || main.Exclusive > main.Inclusive {
+		t.Errorf("main's times are inconsistent: %+v", main)
+	}
+
+	var folded bytes.Buffer
+	if err := prof.WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stack := range []string{"main ", "main;quadruple $x ", "main;quadruple $x;double $x ", "main;double $x "} {
+		if !strings.Contains(folded.String(), "\n"+stack) && !strings.HasPrefix(folded.String(), stack) {
+			t.Errorf("expected a line for '%s' in the folded stacks:\n%s", stack, folded.String())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// publishCommand checks that a package is valid, archives
+// it, and publishes the archive to a registry. The package
+// is the working directory, unless another one is given.
+func publishCommand(args []string) int {
+	flags := flag.NewFlagSet("publish", flag.ExitOnError)
+	registry := flags.String("registry", "", "the registry to publish to (default: the manifest's, or $PLUTO/registry)")
+	dryRun := flags.Bool("dry-run", false, "check and archive the package, but don't publish it")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto publish [-registry location] [-dry-run] [directory]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	if err := publish(dir, *registry, *dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func publish(dir, location string, dryRun bool) error {
+	manifest, err := pkg.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	version, err := validatePackage(dir, manifest)
+	if err != nil {
+		return err
+	}
+
+	archive, err := pkg.Archive(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := checkSources(archive, dir); err != nil {
+		return err
+	}
+
+	checksum := pkg.Checksum(archive)
+
+	if dryRun {
+		fmt.Printf("%s %s is ready to publish (%d bytes, %s)\n", manifest.Name, version, len(archive), checksum)
+		return nil
+	}
+
+	project, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	reg, err := projectRegistry(location, project, manifest)
+	if err != nil {
+		return err
+	}
+
+	if err := reg.Publish(manifest.Name, version, archive); err != nil {
+		if err == pkg.ErrExists {
+			return fmt.Errorf("%s %s has already been published. change the version in %s", manifest.Name, version, pkg.ManifestFile)
+		}
+
+		return err
+	}
+
+	fmt.Printf("published %s %s (%s)\n", manifest.Name, version, checksum)
+
+	return nil
+}
+
+// validatePackage checks that a manifest has everything a
+// published package needs, and returns its version.
+func validatePackage(pkgDir string, manifest *pkg.Manifest) (pkg.Version, error) {
+	if !pkg.ValidName(manifest.Name) {
+		return pkg.Version{}, fmt.Errorf("%s: invalid package name '%s'", pkg.ManifestFile, manifest.Name)
+	}
+
+	version, err := pkg.ParseVersion(manifest.Version)
+	if err != nil {
+		return pkg.Version{}, fmt.Errorf("%s: %s", pkg.ManifestFile, err)
+	}
+
+	// 'use "name"' loads name.pluto from the package
+	if _, ok := dir.MainSource(pkgDir, manifest.Name); !ok {
+		main := filepath.Join(pkgDir, manifest.Name+".pluto")
+		return pkg.Version{}, fmt.Errorf("%s not found. it's loaded when the package is used", main)
+	}
+
+	return version, nil
+}
+
+// checkSources parses and compiles each source file in an
+// archive, printing any errors.
+func checkSources(archive []byte, pkgDir string) error {
+	failed := false
+
+	err := pkg.ReadArchive(archive, func(name string, data []byte) error {
+		if !dir.IsSource(name) {
+			return nil
+		}
+
+		src := string(data)
+		if literate.IsLiterate(name) {
+			src = literate.Extract(src)
+		}
+
+		var (
+			file  = filepath.Join(pkgDir, filepath.FromSlash(name))
+			parse = parser.New(src, file)
+			prog  = parse.Parse()
+		)
+
+		if len(parse.Errors) > 0 {
+			parse.PrintErrors()
+			failed = true
+			return nil
+		}
+
+		cmp := compiler.New()
+
+		if err := cmp.CompileProgram(prog); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			failed = true
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		return errors.New("the package has errors, so it wasn't published")
+	}
+
+	return nil
+}
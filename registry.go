@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// registryCommand serves a registry directory over HTTP,
+// so that it can be used and published to by giving its
+// URL as the registry location.
+func registryCommand(args []string) int {
+	flags := flag.NewFlagSet("registry", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	token := flags.String("token", os.Getenv(pkg.TokenVariable), "the token needed to publish (default $"+pkg.TokenVariable+")")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto registry [-addr address] [-token token] [directory]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	var location string
+	if flags.NArg() > 0 {
+		location = flags.Arg(0)
+	}
+
+	reg, err := pkg.OpenRegistry(location)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	if _, ok := reg.(*pkg.DirRegistry); !ok {
+		fmt.Fprintln(os.Stderr, "pluto: only a directory can be served as a registry")
+		return 1
+	}
+
+	fmt.Printf("serving %s on http://%s\n", reg.(*pkg.DirRegistry).Root, *addr)
+
+	if err := http.ListenAndServe(*addr, pkg.RegistryHandler(reg, *token)); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/rename"
+)
+
+// renameCommand renames the variable or function at a
+// position in a file. A variable is renamed in its scope,
+// and a function's pattern in every file in the project. The
+// edits are printed, or made with -w.
+func renameCommand(args []string) int {
+	flags := flag.NewFlagSet("rename", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the changes to the files, instead of printing them")
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto rename [-w] <file>:<line>:<column> <name or pattern>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	file, line, col, err := parseLocation(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 2
+	}
+
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+
+	edits, err := rename.FindProject(file).Rename(file, line, col, flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	rename.Sort(edits)
+
+	// The edits are made to the files as they are, since
+	// a literate file's code keeps its positions
+	var (
+		files = make(map[string][]rename.Edit)
+		order []string
+	)
+
+	for _, e := range edits {
+		if _, ok := files[e.File]; !ok {
+			order = append(order, e.File)
+		}
+
+		files[e.File] = append(files[e.File], e)
+	}
+
+	for _, file := range order {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+
+		text := string(data)
+
+		if *write {
+			if err := ioutil.WriteFile(file, []byte(rename.Apply(text, files[file])), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+				return 1
+			}
+
+			continue
+		}
+
+		name := file
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+
+		for _, e := range files[file] {
+			fmt.Printf("%s:%s: %q -> %q\n", name, e.Start.String(), e.Old(text), e.Text)
+		}
+	}
+
+	return 0
+}
+
+// parseLocation parses a location such as main.pluto:3:5
+func parseLocation(loc string) (file string, line, col int, err error) {
+	parts := strings.Split(loc, ":")
+
+	if len(parts) >= 3 {
+		n := len(parts)
+		line, err = strconv.Atoi(parts[n-2])
+
+		if err == nil {
+			col, err = strconv.Atoi(parts[n-1])
+		}
+
+		if err == nil {
+			return strings.Join(parts[:n-2], ":"), line, col, nil
+		}
+	}
+
+	return "", 0, 0, fmt.Errorf("%q isn't a location, such as main.pluto:3:5", loc)
+}
@@ -0,0 +1,353 @@
+package rename
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/lexer"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// errLeadingWords is returned when a pattern which starts
+// with a parameter would start with words instead, since
+// the start of a call beginning with an argument isn't known
+var errLeadingWords = errors.New("words can't be added before a function's first parameter")
+
+// renameFunction changes the words in a function's pattern,
+// in each definition and call in the project. The parameter
+// names in the new pattern are only placeholders, so the
+// parameters in definitions keep their names. It refuses if
+// a function with the new pattern is defined in the project,
+// the prelude, or a package used by the project.
+func (p *Project) renameFunction(old pattern, name string) ([]Edit, error) {
+	groups, err := parsePattern(name)
+	if err != nil {
+		return nil, err
+	}
+
+	oldGroups, _ := wordGroups(old.items)
+
+	if len(groups) != len(oldGroups) {
+		return nil, fmt.Errorf("%q has %d parameters, but %q has %d", name, len(groups)-1, key(old.items), len(oldGroups)-1)
+	}
+
+	if len(oldGroups[0]) == 0 && len(groups[0]) > 0 {
+		return nil, errLeadingWords
+	}
+
+	oldKey, newKey := key(old.items), groupsKey(groups)
+	if oldKey == newKey {
+		return nil, nil
+	}
+
+	var (
+		edits   []Edit
+		defined = false
+		uses    = []module.Use{{Package: module.Prelude}}
+	)
+
+	for _, file := range p.Files {
+		ix, err := p.index(file)
+		if err != nil {
+			return nil, fmt.Errorf("can't rename a function while a file in the project has errors: %s", err)
+		}
+
+		for _, pat := range ix.patterns {
+			switch key(pat.items) {
+			case newKey:
+				if pat.def {
+					return nil, clash(name, pat, file)
+				}
+
+			case oldKey:
+				defined = defined || pat.def
+
+				es, err := ix.patternEdits(pat, groups)
+				if err != nil {
+					return nil, err
+				}
+
+				edits = append(edits, es...)
+			}
+		}
+
+		uses = append(uses, ix.uses...)
+	}
+
+	if !defined {
+		return nil, fmt.Errorf("%q isn't defined in the project, so it can't be renamed", oldKey)
+	}
+
+	if err := p.checkPackages(uses, name, newKey); err != nil {
+		return nil, err
+	}
+
+	return edits, nil
+}
+
+// checkPackages checks that a pattern isn't defined by any
+// of the packages some files use, outside the project. Each
+// use is found from its file's directory, as it is when the
+// file is run.
+func (p *Project) checkPackages(uses []module.Use, name, newKey string) error {
+	seen := make(map[string]bool)
+
+	for _, use := range uses {
+		files, err := pkg.LocateUse(use.Package, use.File)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if seen[file] || p.has(file) {
+				continue
+			}
+
+			seen[file] = true
+
+			src, err := literate.ReadFile(file)
+			if err != nil {
+				continue
+			}
+
+			prog, _ := parse(src, file)
+
+			for _, pat := range newIndex(file, prog).patterns {
+				if pat.def && key(pat.items) == newKey {
+					return clash(name, pat, file)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func clash(name string, def pattern, file string) error {
+	return fmt.Errorf("%q would clash with the function defined at %s:%d", name, file, def.items[0].Token().Start.Line)
+}
+
+// patternEdits changes the words in a definition's or a
+// call's pattern to the groups of words. The argument a call
+// starts with isn't known to end where its token does, so
+// words aren't added after it.
+func (ix *index) patternEdits(pat pattern, groups [][]string) ([]Edit, error) {
+	var (
+		old, ends = wordGroups(pat.items)
+		edits     []Edit
+	)
+
+	edit := func(start, end token.Position, text string) {
+		edits = append(edits, Edit{File: ix.file, Start: start, End: end, Text: text})
+	}
+
+	for i, words := range old {
+		text := strings.Join(groups[i], " ")
+
+		switch {
+		case len(words) > 0 && text != "":
+			if text != literals(words) {
+				edit(words[0].Start, after(words[len(words)-1].End), text)
+			}
+
+		case len(words) > 0 && i == 0:
+			// An identifier in brackets at the start of a call
+			// is read as a word
+			if arg, ok := pat.items[len(words)].(*ast.Argument); ok {
+				if id, ok := arg.Value.(*ast.Identifier); ok && id.Tok.Type == token.ID {
+					return nil, fmt.Errorf("the call at %s:%d would start with %s, which would be read as a word", ix.file, id.Tok.Start.Line, id.Value)
+				}
+			}
+
+			// The space before the first argument goes too
+			edit(words[0].Start, ix.skipSpace(after(words[len(words)-1].End)), "")
+
+		case len(words) > 0:
+			edit(ix.skipSpaceBack(words[0].Start), after(words[len(words)-1].End), "")
+
+		case text != "":
+			if i == 1 && !pat.def && len(old[0]) == 0 {
+				return nil, fmt.Errorf("can't add words after the argument the call at %s:%d starts with", ix.file, pat.items[0].Token().Start.Line)
+			}
+
+			edit(after(ends[i-1]), after(ends[i-1]), " "+text)
+		}
+	}
+
+	return edits, nil
+}
+
+// skipSpace returns the position after any spaces or tabs
+// from pos, on the same line
+func (ix *index) skipSpace(pos token.Position) token.Position {
+	i := offset(ix.src, lineStarts(ix.src), pos)
+
+	for ; i < len(ix.src) && (ix.src[i] == ' ' || ix.src[i] == '\t'); i++ {
+		pos.Column++
+	}
+
+	return pos
+}
+
+// skipSpaceBack returns the position of the spaces or tabs
+// just before pos, on the same line
+func (ix *index) skipSpaceBack(pos token.Position) token.Position {
+	i := offset(ix.src, lineStarts(ix.src), pos)
+
+	for ; pos.Column > 1 && (ix.src[i-1] == ' ' || ix.src[i-1] == '\t'); i-- {
+		pos.Column--
+	}
+
+	return pos
+}
+
+// wordGroups splits a pattern into the groups of words
+// around its parameters, and finds where each parameter,
+// or argument, ends. There is always one more group than
+// there are parameters, and groups can be empty.
+func wordGroups(items []ast.Expression) ([][]token.Token, []token.Position) {
+	var (
+		groups = [][]token.Token{nil}
+		ends   []token.Position
+	)
+
+	for _, item := range items {
+		if id, ok := item.(*ast.Identifier); ok {
+			groups[len(groups)-1] = append(groups[len(groups)-1], id.Tok)
+			continue
+		}
+
+		ends = append(ends, item.Token().End)
+		groups = append(groups, nil)
+	}
+
+	return groups, ends
+}
+
+func literals(toks []token.Token) string {
+	words := make([]string, len(toks))
+
+	for i, tok := range toks {
+		words[i] = tok.Literal
+	}
+
+	return strings.Join(words, " ")
+}
+
+// parsePattern splits a new pattern, such as "area of
+// $shape", into the groups of words around its parameters
+func parsePattern(name string) ([][]string, error) {
+	var (
+		toks   = tokens(name)
+		groups = [][]string{nil}
+		words  = 0
+	)
+
+	for _, tok := range toks {
+		switch {
+		case tok.Type == token.Param:
+			groups = append(groups, nil)
+
+		case token.IsKeyword(tok.Type) && words == 0:
+			return nil, fmt.Errorf("%q isn't a valid pattern: its first word can't be a keyword", name)
+
+		case tok.Type == token.ID || token.IsKeyword(tok.Type):
+			groups[len(groups)-1] = append(groups[len(groups)-1], tok.Literal)
+			words++
+
+		default:
+			return nil, fmt.Errorf("%q isn't a valid pattern: it can only have words and parameters", name)
+		}
+	}
+
+	if words == 0 {
+		return nil, fmt.Errorf("%q isn't a valid pattern: it needs at least one word", name)
+	}
+
+	return groups, nil
+}
+
+// tokens lexes some text, without the semicolons
+func tokens(text string) []token.Token {
+	var (
+		next = lexer.Lexer(text, "")
+		toks []token.Token
+	)
+
+	for tok := next(); tok.Type != token.EOF; tok = next() {
+		if tok.Type != token.Semi {
+			toks = append(toks, tok)
+		}
+	}
+
+	return toks
+}
+
+// key returns the string which functions are found by,
+// with a $ for each parameter, such as "area of $"
+func key(items []ast.Expression) string {
+	words := make([]string, len(items))
+
+	for i, item := range items {
+		if id, ok := item.(*ast.Identifier); ok {
+			words[i] = id.Value
+		} else {
+			words[i] = "$"
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func groupsKey(groups [][]string) string {
+	var words []string
+
+	for i, group := range groups {
+		if i > 0 {
+			words = append(words, "$")
+		}
+
+		words = append(words, group...)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// patternString returns a pattern with names for its
+// parameters, such as "area of $shape". A call's arguments
+// are named after the parameters of the function's
+// definition in the same file, or are $a, $b, and so on.
+func (ix *index) patternString(pat pattern) string {
+	if !pat.def {
+		for _, other := range ix.patterns {
+			if other.def && key(other.items) == key(pat.items) {
+				pat = other
+				break
+			}
+		}
+	}
+
+	var (
+		words = make([]string, len(pat.items))
+		n     = 0
+	)
+
+	for i, item := range pat.items {
+		switch item := item.(type) {
+		case *ast.Identifier:
+			words[i] = item.Value
+		case *ast.Parameter:
+			words[i] = "$" + item.Name
+		default:
+			words[i] = "$" + string(rune('a'+n%26))
+			n++
+		}
+	}
+
+	return strings.Join(words, " ")
+}
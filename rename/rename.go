@@ -0,0 +1,254 @@
+// Package rename renames variables, within the scope they're
+// assigned in, and functions, across every file in a project.
+// Nothing is written: a rename returns the edits to make.
+package rename
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// An Edit replaces the text of a file from Start up to End
+// with Text. End is just after the last character which is
+// replaced, so an insertion starts and ends at the same
+// position.
+type Edit struct {
+	File       string
+	Start, End token.Position
+	Text       string
+}
+
+// A Project is the files which a function is renamed in
+type Project struct {
+	Root  string
+	Files []string
+
+	// Read returns the Pluto code in a file. It's
+	// literate.ReadFile, unless it's changed, such as by a
+	// language server to use the editor's buffers.
+	Read func(file string) (string, error)
+}
+
+// FindProject finds the project a file is in: every source
+// file under the closest directory above it with a manifest
+// or a lockfile. A file which isn't in a project is in one
+// with the source files next to it.
+func FindProject(file string) *Project {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+
+	var (
+		root      = filepath.Dir(file)
+		recursive = false
+	)
+
+	for d := root; ; d = filepath.Dir(d) {
+		if exists(filepath.Join(d, pkg.ManifestFile)) || exists(filepath.Join(d, pkg.LockFile)) {
+			root, recursive = d, true
+			break
+		}
+
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	p := &Project{Root: root, Read: literate.ReadFile}
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return nil
+		case info.IsDir() && path != root && (!recursive || strings.HasPrefix(info.Name(), ".")):
+			return filepath.SkipDir
+		case !info.IsDir() && dir.IsSource(path):
+			p.Files = append(p.Files, path)
+		}
+
+		return nil
+	})
+
+	// The file might not have been saved yet
+	if !p.has(file) {
+		p.Files = append(p.Files, file)
+	}
+
+	return p
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (p *Project) has(file string) bool {
+	for _, f := range p.Files {
+		if f == file {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rename renames what's at a line and column of a file in
+// the project. A variable is renamed to name, in the scope
+// it's assigned in. A function's pattern is changed to name,
+// such as "area of $shape", in its definitions and calls.
+func (p *Project) Rename(file string, line, col int, name string) ([]Edit, error) {
+	ix, err := p.index(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if pattern, ok := ix.patternAt(line, col); ok {
+		return p.renameFunction(pattern, name)
+	}
+
+	if v, ok := ix.variableAt(line, col); ok {
+		return ix.renameVariable(v, name)
+	}
+
+	return nil, fmt.Errorf("there's no variable or function to rename at %s:%d:%d", file, line, col)
+}
+
+// Target finds what would be renamed at a line and column of
+// some source code. It returns the token there, and the name
+// to start from: a variable's name or a function's pattern.
+func Target(src, file string, line, col int) (token.Token, string, error) {
+	prog, err := parse(src, file)
+	if err != nil {
+		return token.Token{}, "", err
+	}
+
+	ix := newIndex(file, prog)
+
+	if pattern, ok := ix.patternAt(line, col); ok {
+		return pattern.wordAt(line, col), ix.patternString(pattern), nil
+	}
+
+	if v, ok := ix.variableAt(line, col); ok {
+		return v.tok, v.name, nil
+	}
+
+	return token.Token{}, "", fmt.Errorf("there's no variable or function to rename at %s:%d:%d", file, line, col)
+}
+
+// Apply makes the edits to a file's text. The edits must be
+// to the same file, and mustn't overlap.
+func Apply(text string, edits []Edit) string {
+	sorted := append([]Edit{}, edits...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return before(sorted[j].Start, sorted[i].Start)
+	})
+
+	starts := lineStarts(text)
+
+	for _, e := range sorted {
+		start, end := offset(text, starts, e.Start), offset(text, starts, e.End)
+		text = text[:start] + e.Text + text[end:]
+	}
+
+	return text
+}
+
+// Old returns the text of a file which the edit replaces
+func (e Edit) Old(text string) string {
+	starts := lineStarts(text)
+	return text[offset(text, starts, e.Start):offset(text, starts, e.End)]
+}
+
+// Sort sorts edits by their files, then their positions
+func Sort(edits []Edit) {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].File != edits[j].File {
+			return edits[i].File < edits[j].File
+		}
+
+		return before(edits[i].Start, edits[j].Start)
+	})
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+func lineStarts(text string) []int {
+	starts := []int{0}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	return starts
+}
+
+// offset finds the index in text of a line and column
+func offset(text string, starts []int, pos token.Position) int {
+	if pos.Line < 1 || pos.Line > len(starts) {
+		return len(text)
+	}
+
+	if i := starts[pos.Line-1] + pos.Column - 1; i < len(text) {
+		return i
+	}
+
+	return len(text)
+}
+
+// parse parses a file, returning its first error if it has
+// any, since a rename needs the whole file
+func parse(src, file string) (ast.Program, error) {
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		return prog, parse.Errors[0]
+	}
+
+	return prog, nil
+}
+
+// index parses a file in the project and indexes it
+func (p *Project) index(file string) (*index, error) {
+	src, err := p.Read(file)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := parse(src, file)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := newIndex(file, prog)
+	ix.src = src
+
+	return ix, nil
+}
+
+// after is the position after the end of a token
+func after(pos token.Position) token.Position {
+	pos.Column++
+	return pos
+}
+
+// at checks if a line and column is on a token, or just
+// after it, where an editor's cursor is after typing it
+func at(tok token.Token, line, col int) bool {
+	return tok.Start.Line == line && tok.Start.Column <= col && col <= tok.End.Column+1
+}
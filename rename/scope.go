@@ -0,0 +1,256 @@
+package rename
+
+import (
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// A scope is the top level of a file, a function's body or
+// a block's body. Since functions share their caller's
+// variables, a scope's variables are also the ones in the
+// scopes inside it.
+type scope struct {
+	parent *scope
+
+	// names are the variables the scope assigns to itself,
+	// including its parameters
+	names map[string]bool
+}
+
+func newScope(parent *scope, body []ast.Statement, params []string) *scope {
+	s := &scope{
+		parent: parent,
+		names:  make(map[string]bool),
+	}
+
+	for _, p := range params {
+		s.names[p] = true
+	}
+
+	for _, stmt := range body {
+		ast.Walk(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FunctionDefinition, *ast.BlockLiteral:
+				return false
+			case *ast.AssignExpression:
+				if id, ok := node.Name.(*ast.Identifier); ok {
+					s.names[id.Value] = true
+				}
+			}
+
+			return true
+		})
+	}
+
+	return s
+}
+
+// in checks if s is other or inside it
+func (s *scope) in(other *scope) bool {
+	for ; s != nil; s = s.parent {
+		if s == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// owner returns the outermost scope around s, including
+// itself, which assigns to a name, or nil if none do
+func (s *scope) owner(name string) *scope {
+	var owner *scope
+
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			owner = s
+		}
+	}
+
+	return owner
+}
+
+// A variable is where a variable is used or assigned to. If
+// its token is a parameter, its text starts with a $.
+type variable struct {
+	name  string
+	tok   token.Token
+	scope *scope
+}
+
+// text returns what a variable's token would be if it was
+// called name
+func (v variable) text(name string) string {
+	if v.tok.Type == token.Param {
+		return "$" + name
+	}
+
+	return name
+}
+
+// A pattern is a function's pattern, in a definition or a
+// call
+type pattern struct {
+	items []ast.Expression
+	def   bool
+}
+
+// wordAt returns the word in a pattern at a line and column
+func (p pattern) wordAt(line, col int) token.Token {
+	for _, item := range p.items {
+		if id, ok := item.(*ast.Identifier); ok && at(id.Tok, line, col) {
+			return id.Tok
+		}
+	}
+
+	return token.Token{}
+}
+
+// An index is the variables and patterns in a file, and the
+// packages it uses
+type index struct {
+	file, src string
+	root      *scope
+	variables []variable
+	patterns  []pattern
+	uses      []module.Use
+}
+
+func newIndex(file string, prog ast.Program) *index {
+	ix := &index{
+		file: file,
+		root: newScope(nil, prog.Statements, nil),
+	}
+
+	for _, stmt := range prog.Statements {
+		ix.walk(stmt, ix.root)
+	}
+
+	return ix
+}
+
+// walk finds the variables and patterns in a node, in the
+// scope s
+func (ix *index) walk(n ast.Node, s *scope) {
+	if n == nil {
+		return
+	}
+
+	ast.Walk(n, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FunctionDefinition:
+			var names []string
+
+			for _, item := range node.Pattern {
+				if p, ok := item.(*ast.Parameter); ok {
+					names = append(names, p.Name)
+				}
+			}
+
+			inner := newScope(s, statements(node.Body), names)
+
+			for _, item := range node.Pattern {
+				if p, ok := item.(*ast.Parameter); ok {
+					ix.use(p.Name, p.Tok, inner)
+				}
+			}
+
+			ix.patterns = append(ix.patterns, pattern{items: node.Pattern, def: true})
+			ix.walk(node.Body, inner)
+
+			return false
+
+		case *ast.BlockLiteral:
+			var names []string
+
+			for _, p := range node.Params {
+				names = append(names, p.Token().Literal)
+			}
+
+			inner := newScope(s, statements(node.Body), names)
+
+			for _, p := range node.Params {
+				ix.use(p.Token().Literal, p.Token(), inner)
+			}
+
+			ix.walk(node.Body, inner)
+
+			return false
+
+		case *ast.FunctionCall:
+			ix.patterns = append(ix.patterns, pattern{items: node.Pattern})
+			ix.args(node.Pattern, s)
+
+			return false
+
+		case *ast.QualifiedFunctionCall:
+			ix.walk(node.Base, s)
+			ix.patterns = append(ix.patterns, pattern{items: node.Pattern})
+			ix.args(node.Pattern, s)
+
+			return false
+
+		case *ast.DotExpression:
+			// The right of a dot is a field's name
+			ix.walk(node.Left, s)
+			return false
+
+		case *ast.Identifier:
+			ix.use(node.Value, node.Tok, s)
+
+		case *ast.Parameter:
+			ix.use(node.Name, node.Tok, s)
+
+		case *ast.UseStatement:
+			ix.uses = append(ix.uses, module.Use{Package: node.Package, File: ix.file})
+		}
+
+		return true
+	})
+}
+
+func (ix *index) args(items []ast.Expression, s *scope) {
+	for _, item := range items {
+		if arg, ok := item.(*ast.Argument); ok {
+			ix.walk(arg.Value, s)
+		}
+	}
+}
+
+func (ix *index) use(name string, tok token.Token, s *scope) {
+	ix.variables = append(ix.variables, variable{name: name, tok: tok, scope: s})
+}
+
+// statements returns the statements in a function's or a
+// block's body
+func statements(body ast.Statement) []ast.Statement {
+	if block, ok := body.(*ast.BlockStatement); ok {
+		return block.Statements
+	}
+
+	return []ast.Statement{body}
+}
+
+// patternAt finds the pattern with a word at a line and
+// column
+func (ix *index) patternAt(line, col int) (pattern, bool) {
+	for _, p := range ix.patterns {
+		if p.wordAt(line, col).Type != "" {
+			return p, true
+		}
+	}
+
+	return pattern{}, false
+}
+
+// variableAt finds the variable at a line and column
+func (ix *index) variableAt(line, col int) (variable, bool) {
+	for _, v := range ix.variables {
+		if at(v.tok, line, col) {
+			return v, true
+		}
+	}
+
+	return variable{}, false
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/rename"
+)
+
+const main = `use "./lib.pluto"
+
+total = 0
+
+def area of $shape {
+    w = $shape.width
+    total = total + w
+    return w * 2
+}
+
+print (area of (["width": 3]))
+`
+
+const lib = `def twice $n { return (area of $n) * 2 }
+def size of $s { return 1 }
+`
+
+// project writes a project, and a prelude defining print,
+// and returns the main file's path
+func project(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	root := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(home, "packages", "std", "prelude", "io.pluto"): "def print $x {}\n",
+		filepath.Join(root, "pluto.json"):                             "{}\n",
+		filepath.Join(root, "main.pluto"):                             main,
+		filepath.Join(root, "lib.pluto"):                              lib,
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return filepath.Join(root, "main.pluto")
+}
+
+// rename renames what's at a position in the main file, and
+// returns the main file's and the library's new text
+func rename(t *testing.T, file string, line, col int, name string) (string, string, error) {
+	edits, err := FindProject(file).Rename(file, line, col, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	texts := map[string]string{"main.pluto": main, "lib.pluto": lib}
+	byFile := make(map[string][]Edit)
+
+	for _, e := range edits {
+		byFile[filepath.Base(e.File)] = append(byFile[filepath.Base(e.File)], e)
+	}
+
+	return Apply(texts["main.pluto"], byFile["main.pluto"]), Apply(texts["lib.pluto"], byFile["lib.pluto"]), nil
+}
+
+func TestFunction(t *testing.T) {
+	file := project(t)
+
+	tests := []struct {
+		name, main, lib string
+	}{
+		{"surface of $s", "def surface of $shape {", "(surface of $n)"},
+		{"area $s", "def area $shape {", "(area $n)"},
+		{"$s area", `print ((["width": 3]) area)`, "($n area)"},
+	}
+
+	for _, test := range tests {
+		m, l, err := rename(t, file, 5, 5, test.name)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !strings.Contains(m, test.main) || !strings.Contains(l, test.lib) {
+			t.Errorf("%s: expected %q and %q, got:\n%s\n%s", test.name, test.main, test.lib, m, l)
+		}
+	}
+
+	for _, name := range []string{"size of $x", "print $x", "area of $x $y", "the area of", "if $x", "$x"} {
+		if _, _, err := rename(t, file, 11, 9, name); err == nil {
+			t.Errorf("expected an error renaming 'area of $' to %q", name)
+		}
+	}
+}
+
+func TestVariable(t *testing.T) {
+	file := project(t)
+
+	m, _, err := rename(t, file, 6, 5, "width")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(m, "width = $shape.width\n    total = total + width\n    return width * 2") {
+		t.Errorf("expected w to be renamed to width, got:\n%s", m)
+	}
+
+	if m, _, err = rename(t, file, 5, 14, "s"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(m, "def area of $s {\n    w = $s.width") {
+		t.Errorf("expected $shape to be renamed to $s, got:\n%s", m)
+	}
+
+	if m, _, err = rename(t, file, 7, 14, "sum"); err != nil {
+		t.Fatal(err)
+	} else if strings.Count(m, "sum") != 3 || strings.Contains(m, "total") {
+		t.Errorf("expected every total to be renamed to sum, got:\n%s", m)
+	}
+
+	for _, name := range []string{"total", "shape", "two words", "if"} {
+		if _, _, err := rename(t, file, 6, 5, name); err == nil {
+			t.Errorf("expected an error renaming w to %q", name)
+		}
+	}
+}
+
+// A rename is refused if it would clash with a function in
+// the locked version of a package the project uses, even
+// from outside the project
+func TestLockedPackage(t *testing.T) {
+	file := project(t)
+
+	var (
+		home = os.Getenv("PLUTO")
+		root = filepath.Dir(file)
+	)
+
+	files := map[string]string{
+		filepath.Join(home, "packages", "maths", "maths.pluto"):       "def answer {}\n",
+		filepath.Join(home, "packages", "maths@1.0.0", "maths.pluto"): "def surface of $s { return 1 }\n",
+		filepath.Join(root, "pluto.lock"):                             `{"packages": {"maths": {"version": "1.0.0", "checksum": ""}}}`,
+		filepath.Join(root, "shapes.pluto"):                           "use \"maths\"\n",
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	if _, _, err := rename(t, file, 5, 5, "surface of $s"); err == nil {
+		t.Error("expected an error renaming 'area of $' to a function in the locked maths")
+	}
+}
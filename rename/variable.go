@@ -0,0 +1,65 @@
+package rename
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// renameVariable renames a variable in the outermost scope
+// around it which assigns to it, including the scopes inside
+// that one. Uses of the name in other functions which don't
+// assign to it are renamed too, since they can only be
+// reading a caller's variable.
+func (ix *index) renameVariable(v variable, name string) ([]Edit, error) {
+	name = strings.TrimPrefix(name, "$")
+
+	if !validName(name) {
+		return nil, fmt.Errorf("%q isn't a valid variable name", name)
+	}
+
+	owner := v.scope.owner(v.name)
+	if owner == nil {
+		return nil, fmt.Errorf("%s isn't assigned to around %s:%d, so it can't be renamed there", v.name, ix.file, v.tok.Start.Line)
+	}
+
+	if name == v.name {
+		return nil, nil
+	}
+
+	for s := owner.parent; s != nil; s = s.parent {
+		if s.names[name] {
+			return nil, fmt.Errorf("can't rename %s to %s, which is already assigned to around it", v.name, name)
+		}
+	}
+
+	var edits []Edit
+
+	for _, other := range ix.variables {
+		if !other.scope.in(owner) && other.scope.owner(other.name) != nil {
+			continue
+		}
+
+		switch other.name {
+		case name:
+			return nil, fmt.Errorf("can't rename %s to %s, which is already used at %s:%d", v.name, name, ix.file, other.tok.Start.Line)
+		case v.name:
+			edits = append(edits, Edit{
+				File:  ix.file,
+				Start: other.tok.Start,
+				End:   after(other.tok.End),
+				Text:  other.text(name),
+			})
+		}
+	}
+
+	return edits, nil
+}
+
+// validName checks if name can be a variable's name: a
+// single identifier, which isn't a keyword
+func validName(name string) bool {
+	toks := tokens(name)
+	return len(toks) == 1 && toks[0].Type == token.ID && toks[0].Literal == name
+}
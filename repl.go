@@ -0,0 +1,359 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/doc"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+)
+
+const (
+	replPrompt       = ">> "
+	replContinuation = ".. "
+	replFile         = "<repl>"
+)
+
+// repl is an interactive session. The store is kept
+// between inputs, so names and functions defined in
+// one input can be used in the following ones.
+type repl struct {
+	rl      *readline.Instance
+	store   *store.Store
+	inputs  []string // the inputs which ran successfully, for :save
+	running bool
+}
+
+type metaCommand struct {
+	usage, help string
+	run         func(r *repl, arg string)
+}
+
+// metaCommands are the REPL commands starting with a
+// colon. They're assigned in init() because :help
+// needs to refer to the map itself.
+var metaCommands map[string]metaCommand
+
+func init() {
+	metaCommands = map[string]metaCommand{
+		"help":     {":help [pattern]", "show this message, or a function's documentation", (*repl).help},
+		"quit":     {":quit", "exit the REPL (or press Ctrl-D)", (*repl).quit},
+		"reset":    {":reset", "forget everything defined in this session", (*repl).resetCommand},
+		"load":     {":load <file>", "execute a file in this session", (*repl).load},
+		"save":     {":save <file>", "write this session's inputs to a file", (*repl).save},
+		"ast":      {":ast <code>", "show the parse tree of some code", (*repl).ast},
+		"bytecode": {":bytecode <code>", "show the compiled bytecode of some code", (*repl).bytecode},
+	}
+}
+
+func replCommand(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	noHistory := flags.Bool("no-history", false, "don't read or write the history file")
+	flags.Parse(args)
+
+	cfg := &readline.Config{
+		Prompt:          replPrompt,
+		InterruptPrompt: "^C",
+		EOFPrompt:       ":quit",
+	}
+
+	if !*noHistory {
+		if home, err := dir.Home(); err == nil {
+			cfg.HistoryFile = filepath.Join(home, ".pluto_history")
+		}
+	}
+
+	r := &repl{
+		running: true,
+	}
+
+	cfg.AutoComplete = &completer{r: r}
+
+	rl, err := readline.NewEx(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+	defer rl.Close()
+
+	r.rl = rl
+
+	r.reset()
+
+	for r.running {
+		text, ok := r.read()
+		if !ok {
+			break
+		}
+
+		if isMetaCommand(text) {
+			r.command(text)
+		} else if r.eval(text, replFile) {
+			r.inputs = append(r.inputs, text)
+		}
+	}
+
+	return 0
+}
+
+func isMetaCommand(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), ":")
+}
+
+// read reads a complete input, prompting for more lines
+// while the parser says that the input ended too early,
+// e.g. in the middle of a block. Entering a blank line
+// stops it prompting and returns what's been entered.
+func (r *repl) read() (string, bool) {
+	var lines []string
+
+	r.rl.SetPrompt(replPrompt)
+
+	for {
+		line, err := r.rl.Readline()
+		if err == readline.ErrInterrupt {
+			lines = nil
+			r.rl.SetPrompt(replPrompt)
+			continue
+		} else if err != nil {
+			return "", false
+		}
+
+		if len(lines) == 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			if isMetaCommand(line) {
+				return line, true
+			}
+		} else if strings.TrimSpace(line) == "" {
+			return strings.Join(lines, "\n"), true
+		}
+
+		lines = append(lines, line)
+		text := strings.Join(lines, "\n")
+
+		parse := parser.New(text, replFile)
+		parse.Parse()
+
+		if !parse.Incomplete() {
+			return text, true
+		}
+
+		r.rl.SetPrompt(replContinuation)
+	}
+}
+
+// eval executes some code in the session. While it's
+// running, Ctrl-C interrupts the program instead of
+// killing the REPL. Returns whether it ran successfully.
+func (r *repl) eval(text, file string) bool {
+	var (
+		machine    = vm.New()
+		interrupts = make(chan os.Signal, 1)
+		done       = make(chan struct{})
+	)
+
+	signal.Notify(interrupts, os.Interrupt)
+
+	go func() {
+		select {
+		case <-interrupts:
+			machine.Interrupt()
+		case <-done:
+		}
+	}()
+
+	obj, err := execute(machine, text, file, r.store, false)
+
+	signal.Stop(interrupts)
+	close(done)
+
+	if err != nil {
+		if err != errParse {
+			color.Red("  %s", err)
+		}
+
+		return false
+	}
+
+	if obj != nil {
+		color.Cyan("  %s", obj)
+	}
+
+	return true
+}
+
+// reset replaces the session's store with a new one,
+// containing just the prelude.
+func (r *repl) reset() {
+	r.store = store.New()
+	r.inputs = nil
+
+	if _, err := execute(vm.New(), "", replFile, r.store, true); err != nil {
+		color.Red("  could not load the prelude: %s", err)
+	}
+}
+
+func (r *repl) command(text string) {
+	var (
+		fields = strings.SplitN(strings.TrimSpace(text)[1:], " ", 2)
+		name   = fields[0]
+		arg    string
+	)
+
+	if len(fields) > 1 {
+		arg = strings.TrimSpace(fields[1])
+	}
+
+	if name == "q" {
+		name = "quit"
+	}
+
+	cmd, ok := metaCommands[name]
+	if !ok {
+		color.Red("  unknown command :%s. try :help", name)
+		return
+	}
+
+	cmd.run(r, arg)
+}
+
+func (r *repl) help(arg string) {
+	if arg != "" {
+		r.functionHelp(arg)
+		return
+	}
+
+	var names []string
+
+	for name := range metaCommands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := metaCommands[name]
+		fmt.Printf("  %-18s %s\n", cmd.usage, cmd.help)
+	}
+}
+
+// functionHelp shows the documentation of the function
+// whose pattern is search, such as "double $n". If there
+// isn't one, the functions whose patterns start with the
+// same word are shown instead.
+func (r *repl) functionHelp(search string) {
+	var (
+		words = strings.Fields(search)
+		fns   []object.Function
+	)
+
+	if fn := r.store.FunctionStore.SearchString(strings.Join(words, " ")); fn != nil {
+		fns = append(fns, *fn)
+	} else {
+		for _, fn := range r.store.FunctionStore.Functions {
+			if id, ok := fn.Pattern[0].(*ast.Identifier); ok && id.Value == words[0] {
+				fns = append(fns, fn)
+			}
+		}
+	}
+
+	if len(fns) == 0 {
+		color.Red("  no function matches '%s'", search)
+		return
+	}
+
+	for i, fn := range fns {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		text := doc.NewFunction(fn.Pattern, fn.Doc).Markdown()
+
+		for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+			if line == "" {
+				fmt.Println()
+			} else {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+}
+
+func (r *repl) quit(arg string) {
+	r.running = false
+}
+
+func (r *repl) resetCommand(arg string) {
+	r.reset()
+}
+
+func (r *repl) load(arg string) {
+	if arg == "" {
+		color.Red("  usage: :load <file>")
+		return
+	}
+
+	src, err := ioutil.ReadFile(arg)
+	if err != nil {
+		color.Red("  %s", err)
+		return
+	}
+
+	if r.eval(string(src), arg) {
+		r.inputs = append(r.inputs, string(src))
+	}
+}
+
+func (r *repl) save(arg string) {
+	if arg == "" {
+		color.Red("  usage: :save <file>")
+		return
+	}
+
+	src := strings.Join(r.inputs, "\n") + "\n"
+
+	if err := ioutil.WriteFile(arg, []byte(src), 0644); err != nil {
+		color.Red("  %s", err)
+	}
+}
+
+func (r *repl) ast(arg string) {
+	parse := parser.New(arg, replFile)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		parse.PrintErrors()
+		return
+	}
+
+	fmt.Print(prog.Tree())
+}
+
+func (r *repl) bytecode(arg string) {
+	m, err := module.Compile(arg, replFile)
+	if err != nil {
+		color.Red("  %s", err)
+		return
+	}
+
+	bytecode.Disassemble(os.Stdout, m.Unit(replFile))
+}
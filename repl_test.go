@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestReplLoadAndReset(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"lib.pluto": "def double $n { return $n * 2 }\n\nx = double 21\n",
+	})
+
+	r := &repl{running: true}
+	r.reset()
+
+	capture(t, func() {
+		r.command(":load " + filepath.Join(dir, "lib.pluto"))
+	})
+
+	if x := r.store.GetName("x"); x == nil || x.String() != "42" {
+		t.Fatalf("expected :load to assign x = 42, got %v", x)
+	}
+
+	if len(r.inputs) != 1 {
+		t.Errorf("expected the loaded file to be kept for :save, got %d inputs", len(r.inputs))
+	}
+
+	// An input can use what a loaded file defines
+	if !r.eval("y = double (x)", replFile) {
+		t.Fatal("expected the input to run")
+	}
+
+	if y := r.store.GetName("y"); y == nil || y.String() != "84" {
+		t.Errorf("expected y = 84, got %v", y)
+	}
+
+	r.command(":reset")
+
+	if x := r.store.GetName("x"); x != nil {
+		t.Errorf("expected :reset to forget x, got %v", x)
+	}
+
+	if len(r.inputs) != 0 {
+		t.Errorf("expected :reset to forget the inputs, got %d", len(r.inputs))
+	}
+
+	// The prelude is kept
+	if r.store.FunctionStore.SearchString("print $") == nil {
+		t.Error("expected the prelude to be loaded after :reset")
+	}
+}
+
+// Ctrl-C interrupts the running input, but not the REPL
+func TestReplInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts can't be sent to a process on windows")
+	}
+
+	setup(t, nil)
+
+	r := &repl{running: true}
+	r.reset()
+
+	// Interrupts are caught here too, so an early one can't
+	// kill the test
+	caught := make(chan os.Signal, 1)
+	signal.Notify(caught, os.Interrupt)
+	defer signal.Stop(caught)
+
+	var (
+		done = make(chan bool)
+		ok   bool
+	)
+
+	go func() {
+		self, _ := os.FindProcess(os.Getpid())
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				self.Signal(os.Interrupt)
+			}
+		}
+	}()
+
+	capture(t, func() {
+		ok = r.eval("while (true) { x = 1 }", replFile)
+	})
+
+	close(done)
+
+	if ok {
+		t.Error("expected the interrupted input to fail")
+	}
+
+	if !r.running {
+		t.Error("expected the REPL to keep running")
+	}
+
+	if !r.eval("y = 2", replFile) {
+		t.Error("expected the next input to run")
+	}
+}
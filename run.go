@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/profile"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/fatih/color"
+)
+
+// runCommand executes a source file. Any arguments after
+// the file name are available to the script as the array
+// 'args', and the script can set the exit status with the
+// EXIT instruction. A leading '#!' line is just a comment,
+// so executable scripts work without special handling.
+// The code in a literate .lpluto file is extracted first,
+// and a .plc file made by 'pluto compile' is run directly,
+// as is bytecode assembly in a .plasm file.
+//
+// With -profile, the program's function calls and the
+// instructions it runs are recorded, and written as a text
+// report, with folded stacks for flame graphs alongside.
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	profilePath := flags.String("profile", "", "write a profile to `file`, and folded stacks to file.folded")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto run [-profile file] <file> [args...]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var (
+		file       = flags.Arg(0)
+		scriptArgs = flags.Args()[1:]
+
+		store   = store.New()
+		machine = vm.New()
+	)
+
+	store.Define("args", stringArray(scriptArgs), false)
+
+	var prof *profile.Profile
+	if *profilePath != "" {
+		prof = profile.New()
+		prof.Attach(machine)
+	}
+
+	err := runFile(machine, file, store)
+	status := machine.ExitCode
+
+	if err != nil {
+		if err != errParse {
+			color.New(color.FgRed).Fprintf(os.Stderr, "%s\n", err)
+		}
+
+		status = 1
+	}
+
+	if prof != nil {
+		if err := writeProfile(prof, *profilePath); err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+	}
+
+	return status
+}
+
+// runFile runs a source file, a compiled .plc file without
+// compiling it again, or a .plasm assembly file
+func runFile(machine *vm.VirtualMachine, file string, store *store.Store) error {
+	if strings.HasSuffix(file, module.Extension) || strings.HasSuffix(file, module.AssemblyExtension) {
+		m, err := loadModule(file)
+		if err != nil {
+			return err
+		}
+
+		_, err = runModule(machine, m, store, true)
+		return err
+	}
+
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	_, err = execute(machine, src, file, store, true)
+	return err
+}
+
+// writeProfile writes a profile's report to path, and its
+// folded stacks to path.folded
+func writeProfile(prof *profile.Profile, path string) error {
+	writers := map[string]func(io.Writer) error{
+		path:             prof.WriteReport,
+		path + ".folded": prof.WriteFolded,
+	}
+
+	for file, write := range writers {
+		if err := writeFile(file, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile creates a file, and writes to it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func stringArray(strs []string) *object.Array {
+	arr := &object.Array{
+		Value: make([]object.Object, len(strs)),
+	}
+
+	for i, str := range strs {
+		arr.Value[i] = &object.String{Value: str}
+	}
+
+	return arr
+}
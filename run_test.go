@@ -0,0 +1,24 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A script reads its arguments and sets the exit status
+func TestRunArgsAndExit(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"script.pluto": "#!/usr/bin/env pluto run\nn = <args, LENGTH>\nprint (args[0])\n<n, EXIT>\nprint (\"unreachable\")\n",
+	})
+
+	var status int
+
+	out := capture(t, func() {
+		status = runCommand([]string{filepath.Join(dir, "script.pluto"), "first", "second"})
+	})
+
+	if status != 2 || strings.TrimSpace(out) != "first" {
+		t.Errorf("expected the status 2 and the output first, got %d and %q", status, out)
+	}
+}
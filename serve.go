@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Zac-Garby/pluto/playground"
+)
+
+// serveCommand serves the playground over HTTP: an editor
+// page, and an endpoint which runs the code sent to it in a
+// fresh virtual machine, under the given limits.
+func serveCommand(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	var (
+		addr   = flags.String("addr", "localhost:8080", "the address to listen on")
+		time   = flags.Duration("time", playground.DefaultLimits.Time, "how long each program can run for")
+		output = flags.Int("output", playground.DefaultLimits.Output, "the most bytes each program can print")
+		depth  = flags.Int("depth", playground.DefaultLimits.Depth, "how deeply each program can call functions")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto serve [-addr address] [-time duration] [-output bytes] [-depth calls]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	handler := playground.Handler(playground.Limits{
+		Time:   *time,
+		Output: *output,
+		Depth:  *depth,
+	})
+
+	fmt.Printf("serving the playground on http://%s\n", *addr)
+
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -54,6 +54,25 @@ func (s *Store) Define(name string, val object.Object, local bool) rune {
 	return rune(len(s.Names) - 1)
 }
 
+// Set defines name in the store like Define, but without
+// adding it to Names, which only bytecode refers to
+func (s *Store) Set(name string, val object.Object, local bool) {
+	for _, item := range s.Data {
+		if item.name == name {
+			item.value = val
+			item.local = local
+
+			return
+		}
+	}
+
+	s.Data = append(s.Data, &item{
+		local: local,
+		name:  name,
+		value: val,
+	})
+}
+
 // GetName searches the store for data named 'name'
 func (s *Store) GetName(name string) object.Object {
 	for _, item := range s.Data {
@@ -65,6 +84,18 @@ func (s *Store) GetName(name string) object.Object {
 	return nil
 }
 
+// DefinedNames returns the names of all the data
+// in the store, in the order they were defined
+func (s *Store) DefinedNames() []string {
+	names := make([]string, len(s.Data))
+
+	for i, item := range s.Data {
+		names[i] = item.name
+	}
+
+	return names
+}
+
 // GetID searches the store for the data whose id is 'id'
 func (s *Store) GetID(id rune) (string, object.Object) {
 	name := s.Names[id]
@@ -127,6 +158,7 @@ after_fallback:
 			Names:     fn.Names,
 			Pattern:   fn.Pattern,
 			Patterns:  fn.Patterns,
+			OnCall:    fn.OnCall,
 		}
 	}
 
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/vm"
+
+	"github.com/fatih/color"
+)
+
+// testLibrary is loaded before every test, after the
+// prelude. Its functions throw assertion errors, which
+// the runner reports as failures.
+const testLibrary = `def assert $condition {
+    <$condition, ASSERT>
+}
+
+def assert $actual equals $expected {
+    <$actual, $expected, ASSERT_EQUAL>
+}
+
+def assert $actual does not equal $unexpected {
+    <$actual, $unexpected, ASSERT_NOT_EQUAL>
+}
+
+def fail $message {
+    <$message, FAIL>
+}
+`
+
+// testSuffixes are the endings of test file names
+var testSuffixes = []string{"_test.pluto", "_test" + literate.Extension}
+
+// A plutoTest is a function in a test file whose pattern
+// starts with 'test' and has no parameters, such as:
+//
+//	def test addition works { ... }
+//
+// The test's name is the rest of the pattern.
+type plutoTest struct {
+	name string
+	line int
+}
+
+type testResult struct {
+	err      error
+	duration time.Duration
+}
+
+// testCommand runs the tests in the given files and
+// directories, or in the current directory if none are
+// given. Directories are searched for files ending in
+// _test.pluto or _test.lpluto. Each test runs in a new virtual machine,
+// after the prelude, the assertion library, and the test
+// file itself have been executed.
+//
+// With -cover, the lines run by the tests are counted, and
+// the coverage of the source files in the tests' directories
+// is reported.
+func testCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	run := flags.String("run", "", "only run the tests whose names match this regular expression")
+	cover := flags.Bool("cover", false, "report the coverage of the source files in the tests' directories")
+	lcov := flags.String("coverprofile", "", "write an LCOV coverage report to `file`, implying -cover")
+	html := flags.String("coverhtml", "", "write an annotated HTML coverage report to `file`, implying -cover")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pluto test [-run regexp] [-cover] [files or directories...]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: invalid -run pattern: %s\n", err)
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := sourceFiles(paths, testSuffixes...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return 1
+	}
+
+	if len(files) == 0 {
+		fmt.Println("no test files")
+		return 0
+	}
+
+	var counts map[string]map[int]int
+	if *cover || *lcov != "" || *html != "" {
+		counts = make(map[string]map[int]int)
+	}
+
+	status := 0
+
+	for _, file := range files {
+		if !testFile(file, filter, counts) {
+			status = 1
+		}
+	}
+
+	if counts != nil {
+		if err := reportCoverage(files, counts, *lcov, *html); err != nil {
+			fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+			return 1
+		}
+	}
+
+	return status
+}
+
+// testFile runs the tests in a file which match filter,
+// printing the results. Returns whether they all passed.
+// If counts isn't nil, the lines which are run are counted
+// in it.
+func testFile(file string, filter *regexp.Regexp, counts map[string]map[int]int) bool {
+	src, err := literate.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pluto: %s\n", err)
+		return false
+	}
+
+	parse := parser.New(src, file)
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		parse.PrintErrors()
+		color.Red("FAIL  %s  (parse error)", file)
+		return false
+	}
+
+	var (
+		passed, failed int
+		total          time.Duration
+	)
+
+	for _, test := range findTests(prog, "test") {
+		if !filter.MatchString(test.name) {
+			continue
+		}
+
+		result := runTest(src, file, test, counts)
+		total += result.duration
+
+		if result.err == nil {
+			passed++
+			color.Green("--- PASS: %s (%s)", test.name, result.duration)
+			continue
+		}
+
+		failed++
+		color.Red("--- FAIL: %s (%s)", test.name, result.duration)
+
+		var (
+			msg  = result.err.Error()
+			line = test.line
+		)
+
+		if err, ok := result.err.(*vm.Error); ok {
+			msg = fmt.Sprintf("%s: %s", err.Type, err.Message)
+			if err.Type == vm.ErrAssertion {
+				msg = err.Message
+			}
+
+			line = failureLine(err, file, line)
+		}
+
+		fmt.Printf("    %s:%d: %s\n", file, line, strings.Replace(msg, "\n", "\n    ", -1))
+	}
+
+	summary := fmt.Sprintf("%s  %d passed, %d failed (%s)", file, passed, failed, total)
+
+	if failed > 0 {
+		color.Red("FAIL  %s", summary)
+		return false
+	}
+
+	fmt.Printf("ok    %s\n", summary)
+	return true
+}
+
+// failureLine finds the line in file where a test failed,
+// skipping the positions in the assertion library. If the
+// error has no position in file, def is returned.
+func failureLine(err *vm.Error, file string, def int) int {
+	for _, pos := range err.Trace {
+		if pos.File == file {
+			return pos.Line
+		}
+	}
+
+	return def
+}
+
+// findTests returns the tests defined at the top level
+// of a program, in the order they're defined. A test's
+// pattern starts with prefix, which is "test" for tests
+// and "bench" for benchmarks.
+func findTests(prog ast.Program, prefix string) []plutoTest {
+	var tests []plutoTest
+
+outer:
+	for _, stmt := range prog.Statements {
+		def, ok := stmt.(*ast.FunctionDefinition)
+		if !ok || len(def.Pattern) < 2 {
+			continue
+		}
+
+		var words []string
+
+		for _, item := range def.Pattern {
+			id, ok := item.(*ast.Identifier)
+			if !ok {
+				continue outer
+			}
+
+			words = append(words, id.Value)
+		}
+
+		if words[0] != prefix {
+			continue
+		}
+
+		tests = append(tests, plutoTest{
+			name: strings.Join(words[1:], " "),
+			line: def.Tok.Start.Line,
+		})
+	}
+
+	return tests
+}
+
+// runTest sets up a new session for a test, and then
+// calls it. Only the call itself is timed.
+func runTest(src, file string, test plutoTest, counts map[string]map[int]int) testResult {
+	store := store.New()
+
+	machine := func() *vm.VirtualMachine {
+		m := vm.New()
+		m.Coverage = counts
+		return m
+	}
+
+	if _, err := execute(machine(), testLibrary, "<testing>", store, true); err != nil {
+		return testResult{err: err}
+	}
+
+	if _, err := execute(machine(), src, file, store, false); err != nil {
+		return testResult{err: err}
+	}
+
+	start := time.Now()
+	_, err := execute(machine(), "\\test "+test.name, file, store, false)
+
+	return testResult{
+		err:      err,
+		duration: time.Since(start),
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+const testSource = `total = 3
+
+def test addition works {
+    assert (1 + 2) equals (total)
+}
+
+def test with $param {
+    assert $param
+}
+
+def helper {
+    return 1
+}
+
+def bench addition {
+    1 + 2
+}
+
+def test subtraction {
+    assert (2 - 1) equals 2
+}
+
+def test no failure {
+    assert (2 - 1) does not equal 2
+}
+
+def test failing {
+    fail "it broke"
+}
+`
+
+func TestFindTests(t *testing.T) {
+	parse := parser.New(testSource, "tests.pluto")
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		t.Fatal(parse.Errors[0])
+	}
+
+	expected := []plutoTest{
+		{"addition works", 3},
+		{"subtraction", 19},
+		{"no failure", 23},
+		{"failing", 27},
+	}
+
+	if found := findTests(prog, "test"); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected the tests %v, got %v", expected, found)
+	}
+
+	if found := findTests(prog, "bench"); !reflect.DeepEqual(found, []plutoTest{{"addition", 15}}) {
+		t.Errorf("expected the benchmark 'addition', got %v", found)
+	}
+}
+
+func TestRunTest(t *testing.T) {
+	setup(t, nil)
+
+	tests := []struct {
+		test    plutoTest
+		message string
+		line    int
+	}{
+		{plutoTest{"addition works", 3}, "", 0},
+		{plutoTest{"no failure", 23}, "", 0},
+		{plutoTest{"subtraction", 19}, "values are not equal\n  expected: 2\n    actual: 1", 20},
+		{plutoTest{"failing", 27}, "it broke", 28},
+	}
+
+	for _, test := range tests {
+		counts := make(map[string]map[int]int)
+		result := runTest(testSource, "tests.pluto", test.test, counts)
+
+		if counts["tests.pluto"][test.test.line+1] == 0 {
+			t.Errorf("%s: expected line %d to be counted, got %v", test.test.name, test.test.line+1, counts["tests.pluto"])
+		}
+
+		if test.message == "" {
+			if result.err != nil {
+				t.Errorf("%s: expected it to pass, got %s", test.test.name, result.err)
+			}
+
+			continue
+		}
+
+		err, ok := result.err.(*vm.Error)
+
+		switch {
+		case !ok:
+			t.Errorf("%s: expected an assertion error, got %v", test.test.name, result.err)
+		case err.Type != vm.ErrAssertion || err.Message != test.message:
+			t.Errorf("%s: expected the assertion error %q, got the %s error %q", test.test.name, test.message, err.Type, err.Message)
+		case failureLine(err, "tests.pluto", test.test.line) != test.line:
+			t.Errorf("%s: expected it to fail on line %d, got %d", test.test.name, test.line, failureLine(err, "tests.pluto", test.test.line))
+		}
+	}
+}
@@ -10,6 +10,9 @@ const (
 	// Illegal is any non-recognized character
 	Illegal = "illegal"
 
+	// Comment is a comment, from a # to the end of the line
+	Comment = "comment"
+
 	// Number is a number literal (123.456)
 	Number = "number"
 
@@ -0,0 +1,108 @@
+package transpile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// Module is the path of pluto's Go module, which transpiled
+// programs import the runtime from
+const Module = "github.com/Zac-Garby/pluto"
+
+// Source finds the directory of pluto's source, as the Go
+// toolchain does from the current directory. Outside of
+// pluto's source, and of a module which requires it, it's
+// the module cache's copy of the version this program was
+// installed from.
+func Source() (string, error) {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", Module).Output()
+	if dir := strings.TrimSpace(string(out)); err == nil && dir != "" {
+		return dir, nil
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path == Module && info.Main.Version != "(devel)" {
+		out, err := exec.Command("go", "mod", "download", "-json", Module+"@"+info.Main.Version).Output()
+
+		var download struct{ Dir string }
+		if err == nil && json.Unmarshal(out, &download) == nil && download.Dir != "" {
+			return download.Dir, nil
+		}
+	}
+
+	return "", fmt.Errorf("can't find the source of %s, which the program is built with. Give its directory with -src", Module)
+}
+
+// Build builds the Go source of a transpiled program into an
+// executable at path, with the local Go toolchain. It's built
+// against the pluto source in the directory src.
+func Build(code []byte, src, path string) error {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	// The program's module replaces pluto's with src, which
+	// needs to be a module too
+	if _, err := os.Stat(filepath.Join(src, "go.mod")); err != nil {
+		return fmt.Errorf("%s isn't the source of %s: it has no go.mod", src, Module)
+	}
+
+	dir, err := ioutil.TempDir("", "pluto-build")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	mod := fmt.Sprintf("module main\n\ngo 1.16\n\nrequire %s v0.0.0\n\nreplace %s => %s\n", Module, Module, src)
+
+	files := map[string][]byte{
+		"main.go": code,
+		"go.mod":  []byte(mod),
+	}
+
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// The go.sum is copied from pluto's, since the program
+	// needs the same modules
+	if sum, err := ioutil.ReadFile(filepath.Join(src, "go.sum")); err == nil {
+		if err := ioutil.WriteFile(filepath.Join(dir, "go.sum"), sum, 0644); err != nil {
+			return err
+		}
+	}
+
+	return goCommand(dir, "build", "-mod=mod", "-o", path, ".")
+}
+
+// goCommand runs the go command in a directory, returning its
+// output as an error if it fails
+func goCommand(dir string, args ...string) error {
+	var out bytes.Buffer
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go %s: %s\n%s", args[0], err, strings.TrimSpace(out.String()))
+	}
+
+	return nil
+}
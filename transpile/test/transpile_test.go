@@ -0,0 +1,347 @@
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	. "github.com/Zac-Garby/pluto/transpile"
+	"github.com/Zac-Garby/pluto/vm"
+)
+
+var packages = map[string]string{
+	"std/prelude/io.pluto": `def print $obj {
+    <$obj, PRINT_LINE>
+}
+
+def write $obj {
+    <$obj, PRINT>
+}
+
+def exit $code {
+    <$code, EXIT>
+}
+`,
+	"shapes/shapes.pluto": `use "colours"
+
+_module = ["title": "shapes"]
+sides = 4
+
+def area of $r {
+    return $r * $r
+}
+`,
+	"colours/colours.pluto": `def shade $c {
+    return $c + "ish"
+}
+`,
+}
+
+// samples are run both in the virtual machine and natively,
+// which should give the same results
+var samples = map[string]string{
+	"operators": `print (1 + 2 * 3)
+print (7 // 2)
+print (7 % 2)
+print (2 ** 10)
+print (-(3))
+print (!true)
+print (5 | 3)
+print (6 & 3)
+print ("ab" + "cd")
+print ([1, 2] * 3)
+print ([1, 2, 3] - [2])
+print ([1, 2] | [2, 3])
+print ([1, 2, 3] & [2, 3, 4])
+print ((1, 2) != (1, 2))
+print ([1, "x", 'c', null, true])
+`,
+	"functions": `def fib $n {
+    if ($n < 2) {
+        return $n
+    }
+
+    return (fib ($n - 1)) + (fib ($n - 2))
+}
+
+def add $a to $b {
+    $a + $b
+}
+
+def greet $name {
+    greeting = "hello, " + $name
+}
+
+def shout {
+    print (greeting + "!")
+}
+
+print (fib 15)
+print (add 2 to 3)
+greet "world"
+\shout
+x = if (add 1 to 1 == 2) { "yes" } else { "no" }
+print (x)
+`,
+	"loops": `i = 0
+total = 0
+
+while (i < 10) {
+    i = i + 1
+
+    if (i == 3) {
+        next
+    }
+
+    if (i == 8) {
+        break
+    }
+
+    total = total + i
+}
+
+print (total)
+
+for (j = 0; j < 3; j = j + 1) {
+    write (j)
+}
+
+print ("")
+`,
+	"data": `a = [1, 2, 3]
+a[1] = "two"
+print (a)
+print (a[5])
+m = ["x": 1, "y": 2]
+m.z = 3
+print (m.x + m.z)
+s = "hello"
+print (s[1])
+b = { |x, y| -> x * y }
+print (<3, 4, b, DO_BLOCK>)
+print (<[1, 2, 3], LENGTH>)
+print (b)
+`,
+	"packages": `use "shapes"
+
+print (shapes.sides)
+print (shapes: area of 3)
+print (shapes: shade "blue")
+`,
+	"error": `def lookup {
+    print ("looking")
+    return missing
+}
+
+print ("start")
+\lookup
+print ("unreachable")
+`,
+	"exit": `print ("bye")
+exit 3
+print ("unreachable")
+`,
+}
+
+type result struct {
+	out, err string
+	status   int
+}
+
+func TestDifferential(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The source is found as 'pluto build --go' finds it,
+	// from inside pluto's module
+	src, err := Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if src != root {
+		t.Fatalf("expected pluto's source to be %s, got %s", root, src)
+	}
+
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	for name, text := range packages {
+		path := filepath.Join(home, "packages", name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+
+	for name, text := range samples {
+		file := name + ".pluto"
+
+		expected := runVM(t, text, file)
+
+		prog := parser.New(text, file).Parse()
+
+		code, err := Program(prog)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		exe := filepath.Join(dir, name)
+		if err := Build(code, src, exe); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if actual := runNative(t, exe); actual != expected {
+			t.Errorf("%s: the virtual machine gave %+v, but the native build gave %+v", name, expected, actual)
+		}
+	}
+}
+
+func runVM(t *testing.T, text, file string) result {
+	m, err := module.Compile(text, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		out     bytes.Buffer
+		locals  = store.New()
+		machine = vm.New()
+	)
+
+	machine.Out = &out
+
+	locals.Names = m.Names
+	locals.Patterns = m.Patterns
+	locals.FunctionStore.Define(m.Functions...)
+
+	machine.Run(m.Code, locals, m.Constants, true)
+
+	r := result{out: out.String(), status: machine.ExitCode}
+
+	if machine.Error != nil {
+		r.err, r.status = machine.Error.Error(), 1
+	}
+
+	return r
+}
+
+func runNative(t *testing.T, exe string) result {
+	var out, errOut bytes.Buffer
+
+	// The packages are built in, so they aren't needed
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), "PLUTO="+t.TempDir())
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+
+	r := result{}
+
+	if err := cmd.Run(); err != nil {
+		exit, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatal(err)
+		}
+
+		r.status = exit.ExitCode()
+	}
+
+	r.out, r.err = out.String(), strings.TrimSpace(errOut.String())
+
+	return r
+}
+
+func TestErrors(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	prelude := filepath.Join(home, "packages", "std", "prelude")
+
+	if err := os.MkdirAll(prelude, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(prelude, "io.pluto"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"emission": "x = <1, LOAD_CONST 0>\n",
+		"return":   "x = if (true) { return 1 }\n",
+		"package":  "use \"nonexistent\"\n",
+	}
+
+	for name, text := range sources {
+		prog := parser.New(text, name+".pluto").Parse()
+
+		if _, err := Program(prog); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// A program's uses are found from its own project, as
+// they are when it's run, even from somewhere else
+func TestUseFromOtherDirectory(t *testing.T) {
+	var (
+		home    = t.TempDir()
+		project = t.TempDir()
+	)
+
+	t.Setenv("PLUTO", home)
+
+	files := map[string]string{
+		filepath.Join(home, "packages", "std", "prelude", "io.pluto"): "",
+		filepath.Join(home, "packages", "maths", "maths.pluto"):       "def answer { return 1717 }\n",
+		filepath.Join(home, "packages", "maths@1.0.0", "maths.pluto"): "def answer { return 4242 }\n",
+		filepath.Join(project, "pluto.lock"):                          `{"packages": {"maths": {"version": "1.0.0", "checksum": ""}}}`,
+		filepath.Join(project, "lib", "add.pluto"):                    "def add $a to $b { return $a + $b }\n",
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	src := "use \"maths\"\nuse \"./lib/add.pluto\"\n\nx = add (\\answer) to 1\n"
+	prog := parser.New(src, filepath.Join(project, "main.pluto")).Parse()
+
+	code, err := Program(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(code), "4242") || strings.Contains(string(code), "1717") {
+		t.Errorf("expected the locked version of maths to be used, got:\n%s", code)
+	}
+}
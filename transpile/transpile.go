@@ -0,0 +1,848 @@
+// Package transpile turns Pluto programs into Go source, which
+// runs on the native package's runtime instead of the virtual
+// machine. The prelude, and every package the program uses,
+// are transpiled into it too.
+package transpile
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/native"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
+)
+
+// transpiler holds the declarations made while transpiling a
+// program, which go after its main function
+type transpiler struct {
+	decls bytes.Buffer
+
+	// count is used to give each declaration its own name
+	count int
+
+	// packages maps the strings given to 'use' to the names
+	// of the packages' bodies. queue holds the packages
+	// which haven't been transpiled yet.
+	packages map[string]string
+	queue    []use
+
+	// usesBytecode is whether the bytecode package is
+	// referred to, and so needs to be imported
+	usesBytecode bool
+}
+
+// A use is a package to transpile, and the file which
+// first used it, which it's found from
+type use struct {
+	src, file string
+}
+
+// unit is the body of a program, package, function or block,
+// which is transpiled to a Go function
+type unit struct {
+	t    *transpiler
+	body bytes.Buffer
+
+	// constants maps each constant's Go expression to the
+	// variable holding it. Like the compiler, each unit has
+	// its own constants, and uses each one wherever it's
+	// equal, so they're shared in the same way.
+	constants map[string]string
+
+	// top is whether function definitions are kept, which
+	// they only are at the top level of a program or a
+	// package. first is whether the first definition of a
+	// pattern is kept, rather than the last.
+	top, first bool
+	defs       []*ast.FunctionDefinition
+
+	// loops is the number of loops being transpiled, and
+	// closures the number of if expressions whose values are
+	// used, which are transpiled to closures
+	loops, closures int
+}
+
+// Program transpiles a program, with the prelude and the
+// packages it uses, to the source of a Go command which runs
+// it. The packages are found as they would be when it runs.
+func Program(prog ast.Program) ([]byte, error) {
+	t := &transpiler{
+		packages: make(map[string]string),
+	}
+
+	// The prelude is used after the program's functions are
+	// defined, as it is in the virtual machine
+	start := fmt.Sprintf("rt.Use(%q)", t.use(module.Prelude, ""))
+
+	program, err := t.transpileUnit(t.name("program"), prog.Statements, true, false, start)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(t.queue) > 0 {
+		u := t.queue[0]
+		t.queue = t.queue[1:]
+
+		if err := t.transpilePackage(u); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+
+	fmt.Fprintln(&out, "// Code generated by pluto build --go. DO NOT EDIT.")
+	fmt.Fprintln(&out)
+	fmt.Fprintln(&out, "package main")
+	fmt.Fprintln(&out)
+	fmt.Fprintln(&out, "import (")
+
+	if t.usesBytecode {
+		fmt.Fprintln(&out, `"github.com/Zac-Garby/pluto/bytecode"`)
+	}
+
+	fmt.Fprintln(&out, `"github.com/Zac-Garby/pluto/native"`)
+	fmt.Fprintln(&out, `"github.com/Zac-Garby/pluto/object"`)
+	fmt.Fprintln(&out, ")")
+	fmt.Fprintln(&out)
+	fmt.Fprintf(&out, "func main() {\nnative.Main(%s, packages)\n}\n\n", program)
+
+	srcs := make([]string, 0, len(t.packages))
+	for src := range t.packages {
+		srcs = append(srcs, src)
+	}
+
+	sort.Strings(srcs)
+
+	fmt.Fprintln(&out, "var packages = map[string]native.Body{")
+
+	for _, src := range srcs {
+		fmt.Fprintf(&out, "%q: %s,\n", src, t.packages[src])
+	}
+
+	fmt.Fprintln(&out, "}")
+
+	out.Write(t.decls.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+// use returns the package a 'use' statement in a file
+// refers to, which is queued to be transpiled if it hasn't
+// been
+func (t *transpiler) use(src, file string) string {
+	src = pkg.Glob(src, file)
+
+	if _, ok := t.packages[src]; !ok {
+		t.packages[src] = t.name("package")
+		t.queue = append(t.queue, use{src, file})
+	}
+
+	return src
+}
+
+// transpilePackage transpiles the sources of a package
+// together, like the virtual machine compiles them
+func (t *transpiler) transpilePackage(u use) error {
+	sources, err := pkg.LocateUse(u.src, u.file)
+	if err != nil {
+		return fmt.Errorf("use %s: %s", u.src, err)
+	}
+
+	var stmts []ast.Statement
+
+	for _, source := range sources {
+		text, err := literate.ReadFile(source)
+		if err != nil {
+			return err
+		}
+
+		parse := parser.New(text, source)
+		prog := parse.Parse()
+
+		if len(parse.Errors) > 0 {
+			return parse.Errors[0]
+		}
+
+		stmts = append(stmts, prog.Statements...)
+	}
+
+	name := t.packages[u.src]
+	_, err = t.transpileUnit(name, stmts, true, true, "")
+
+	return err
+}
+
+// name returns a new name for a declaration
+func (t *transpiler) name(prefix string) string {
+	t.count++
+	return fmt.Sprintf("%s%d", prefix, t.count)
+}
+
+// transpileUnit transpiles some statements to a function,
+// returning its name. If top is set, they're the top level of
+// a program or a package, and start is put after the
+// definitions of its functions.
+func (t *transpiler) transpileUnit(name string, stmts []ast.Statement, top, first bool, start string) (string, error) {
+	u := &unit{
+		t:         t,
+		constants: make(map[string]string),
+		top:       top,
+		first:     first,
+	}
+
+	for _, stmt := range stmts {
+		if err := u.statement(stmt); err != nil {
+			return "", err
+		}
+	}
+
+	var fn bytes.Buffer
+
+	fmt.Fprintf(&fn, "\nfunc %s(rt *native.Runtime) object.Object {\nvar top object.Object\n", name)
+
+	// Functions are defined before anything is run, as the
+	// virtual machine defines them before running the code
+	defined := make(map[string]bool)
+
+	for _, def := range u.defs {
+		pattern, shape := patternText(def.Pattern)
+
+		if u.first && defined[shape] {
+			continue
+		}
+
+		defined[shape] = true
+
+		body, err := t.function(def.Body)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&fn, "rt.Def(%q, %s)\n", pattern, body)
+	}
+
+	if start != "" {
+		fmt.Fprintln(&fn, start)
+	}
+
+	fn.Write(u.body.Bytes())
+	fmt.Fprintln(&fn, "return top\n}")
+
+	t.decls.Write(fn.Bytes())
+
+	return name, nil
+}
+
+// function transpiles the body of a function or a block,
+// returning its name
+func (t *transpiler) function(body ast.Statement) (string, error) {
+	return t.transpileUnit(t.name("fn"), []ast.Statement{body}, false, false, "")
+}
+
+func (u *unit) line(format string, args ...interface{}) {
+	fmt.Fprintf(&u.body, format+"\n", args...)
+}
+
+// statement transpiles a statement
+func (u *unit) statement(n ast.Statement) error {
+	switch n.(type) {
+	case *ast.BlockStatement, *ast.FunctionDefinition:
+	default:
+		if pos := n.Token().Start; pos.Line > 0 {
+			u.line("rt.At(%q, %d)", pos.File, pos.Line)
+		}
+	}
+
+	switch node := n.(type) {
+	case *ast.ExpressionStatement:
+		if ifExpr, ok := node.Expr.(*ast.IfExpression); ok {
+			return u.ifStatement(ifExpr)
+		}
+
+		return u.push(node.Expr)
+
+	case *ast.BlockStatement:
+		for _, stmt := range node.Statements {
+			if err := u.statement(stmt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case *ast.FunctionDefinition:
+		// Like the compiler, only the definitions at the top
+		// level are kept. They're hoisted even if they're
+		// inside an if statement or a loop.
+		if u.top {
+			u.defs = append(u.defs, node)
+		}
+
+		return nil
+
+	case *ast.ReturnStatement:
+		if u.closures > 0 {
+			return fmt.Errorf("transpile: %s: can't return from inside an if expression whose value is used", position(n))
+		}
+
+		if node.Value != nil {
+			if err := u.push(node.Value); err != nil {
+				return err
+			}
+		}
+
+		u.line("return top")
+		return nil
+
+	case *ast.WhileLoop:
+		return u.loop(node.Condition, node.Body, nil)
+
+	case *ast.ForLoop:
+		if err := u.push(node.Init); err != nil {
+			return err
+		}
+
+		return u.loop(node.Condition, node.Body, node)
+
+	case *ast.NextStatement:
+		return u.jump(n, "continue", "next")
+
+	case *ast.BreakStatement:
+		return u.jump(n, "break", "break")
+
+	case *ast.UseStatement:
+		u.line("rt.Use(%q)", u.t.use(node.Package, node.Tok.Start.File))
+		return nil
+
+	default:
+		return fmt.Errorf("transpile: transpiling not yet implemented for %s", reflect.TypeOf(n))
+	}
+}
+
+// push transpiles an expression whose value is left on the
+// stack, which is kept as the top of the stack
+func (u *unit) push(n ast.Expression) error {
+	code, nilable, err := u.expression(n)
+	if err != nil {
+		return err
+	}
+
+	if nilable {
+		u.line("if v := %s; v != nil {\ntop = v\n}", code)
+	} else {
+		u.line("top = %s", code)
+	}
+
+	return nil
+}
+
+// ifStatement transpiles an if expression whose value isn't
+// used. The branches' statements leave their values on the
+// stack.
+func (u *unit) ifStatement(node *ast.IfExpression) error {
+	cond, err := u.value(node.Condition)
+	if err != nil {
+		return err
+	}
+
+	u.line("if object.IsTruthy(%s) {", cond)
+
+	if err := u.statement(node.Consequence); err != nil {
+		return err
+	}
+
+	if node.Alternative != nil {
+		u.line("} else {")
+
+		if err := u.statement(node.Alternative); err != nil {
+			return err
+		}
+	}
+
+	u.line("}")
+
+	return nil
+}
+
+// loop transpiles a while loop, or a for loop, whose
+// increment is run at the end of its body
+func (u *unit) loop(condition ast.Expression, body ast.Statement, forLoop *ast.ForLoop) error {
+	cond, err := u.value(condition)
+	if err != nil {
+		return err
+	}
+
+	// The condition is checked inside the loop, so errors in
+	// it are given its position
+	u.line("for {")
+
+	if p := condition.Token().Start; p.Line > 0 {
+		u.line("rt.At(%q, %d)", p.File, p.Line)
+	}
+
+	u.line("if !object.IsTruthy(%s) {\nbreak\n}", cond)
+
+	u.loops++
+
+	if err := u.statement(body); err != nil {
+		return err
+	}
+
+	if forLoop != nil {
+		// The increment has the for loop's position
+		if pos := forLoop.Tok.Start; pos.Line > 0 {
+			u.line("rt.At(%q, %d)", pos.File, pos.Line)
+		}
+
+		if err := u.push(forLoop.Increment); err != nil {
+			return err
+		}
+	}
+
+	u.loops--
+
+	u.line("}")
+
+	return nil
+}
+
+// jump transpiles a break or next statement. Outside a loop,
+// it's an error when it's run, as it is in the virtual
+// machine.
+func (u *unit) jump(n ast.Statement, keyword, name string) error {
+	if u.closures > 0 {
+		return fmt.Errorf("transpile: %s: can't %s from inside an if expression whose value is used", position(n), name)
+	}
+
+	if u.loops == 0 {
+		u.line("rt.Throw(native.ErrSyntax, %q)", name+" statement found outside loop")
+	} else {
+		u.line(keyword)
+	}
+
+	return nil
+}
+
+// value transpiles an expression which has to have a value
+func (u *unit) value(n ast.Expression) (string, error) {
+	code, nilable, err := u.expression(n)
+	if err != nil {
+		return "", err
+	}
+
+	if nilable {
+		code = fmt.Sprintf("rt.Value(%s)", code)
+	}
+
+	return code, nil
+}
+
+// values transpiles some expressions, separated by commas
+func (u *unit) values(ns []ast.Expression) (string, error) {
+	codes := make([]string, len(ns))
+
+	for i, n := range ns {
+		code, err := u.value(n)
+		if err != nil {
+			return "", err
+		}
+
+		codes[i] = code
+	}
+
+	return strings.Join(codes, ", "), nil
+}
+
+// expression transpiles an expression to a Go expression.
+// nilable is whether its value might be nil, which it is if
+// a function or an emission doesn't leave a value.
+func (u *unit) expression(n ast.Expression) (code string, nilable bool, err error) {
+	switch node := n.(type) {
+	case *ast.Number:
+		return u.constant(fmt.Sprintf("&object.Number{Value: %s}", strconv.FormatFloat(node.Value, 'g', -1, 64))), false, nil
+	case *ast.String:
+		return u.constant(fmt.Sprintf("&object.String{Value: %s}", strconv.Quote(node.Value))), false, nil
+	case *ast.Boolean:
+		return u.constant(fmt.Sprintf("&object.Boolean{Value: %t}", node.Value)), false, nil
+	case *ast.Char:
+		return u.constant(fmt.Sprintf("&object.Char{Value: %s}", strconv.QuoteRune(rune(node.Value)))), false, nil
+	case *ast.Null:
+		return "object.NullObj", false, nil
+	case *ast.Identifier:
+		return fmt.Sprintf("rt.LoadName(%q)", node.Value), false, nil
+	case *ast.Parameter:
+		return fmt.Sprintf("rt.LoadName(%q)", node.Name), false, nil
+	case *ast.Argument:
+		return u.expression(node.Value)
+	case *ast.Array:
+		elems, err := u.values(node.Elements)
+		return fmt.Sprintf("&object.Array{Value: []object.Object{%s}}", elems), false, err
+	case *ast.Tuple:
+		elems, err := u.values(node.Value)
+		return fmt.Sprintf("&object.Tuple{Value: []object.Object{%s}}", elems), false, err
+	case *ast.Map:
+		return u.makeMap(node)
+	case *ast.AssignExpression:
+		return u.assign(node)
+	case *ast.InfixExpression:
+		return u.infix(node)
+	case *ast.PrefixExpression:
+		return u.prefix(node)
+	case *ast.IfExpression:
+		return u.ifExpression(node)
+	case *ast.FunctionCall:
+		pattern, args, err := u.call(node.Pattern)
+		if err != nil {
+			return "", false, err
+		}
+
+		if args != "" {
+			args = ", " + args
+		}
+
+		return fmt.Sprintf("rt.Call(%q%s)", pattern, args), true, nil
+	case *ast.QualifiedFunctionCall:
+		// The arguments are evaluated before the base
+		pattern, args, err := u.call(node.Pattern)
+		if err != nil {
+			return "", false, err
+		}
+
+		base, err := u.value(node.Base)
+		return fmt.Sprintf("rt.CallMethod(%q, []object.Object{%s}, %s)", pattern, args, base), true, err
+	case *ast.IndexExpression:
+		args, err := u.values([]ast.Expression{node.Collection, node.Index})
+		return fmt.Sprintf("rt.LoadField(%s)", args), false, err
+	case *ast.DotExpression:
+		left, err := u.value(node.Left)
+		if err != nil {
+			return "", false, err
+		}
+
+		field, err := u.field(node.Right)
+		return fmt.Sprintf("rt.LoadField(%s, %s)", left, field), false, err
+	case *ast.EmissionExpression:
+		return u.emission(node)
+	case *ast.BlockLiteral:
+		return u.block(node)
+	default:
+		return "", false, fmt.Errorf("transpile: transpiling not yet implemented for %s", reflect.TypeOf(n))
+	}
+}
+
+// constant returns the variable holding a constant, which is
+// declared if the unit doesn't have it yet
+func (u *unit) constant(expr string) string {
+	if name, ok := u.constants[expr]; ok {
+		return name
+	}
+
+	name := u.t.name("k")
+	u.constants[expr] = name
+
+	fmt.Fprintf(&u.t.decls, "\nvar %s = %s\n", name, expr)
+
+	return name
+}
+
+// field returns the constant of the name to the right of a
+// dot
+func (u *unit) field(n ast.Expression) (string, error) {
+	id, ok := n.(*ast.Identifier)
+	if !ok {
+		return "", fmt.Errorf("transpile: expected an identifier to the right of a dot")
+	}
+
+	return u.constant(fmt.Sprintf("&object.String{Value: %q}", id.Value)), nil
+}
+
+func (u *unit) makeMap(node *ast.Map) (string, bool, error) {
+	keys := make([]ast.Expression, 0, len(node.Pairs))
+	for key := range node.Pairs {
+		keys = append(keys, key)
+	}
+
+	// The pairs are in the order they were written, so the
+	// generated code is the same each time
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i].Token().Start, keys[j].Token().Start
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	var pairs []ast.Expression
+	for _, key := range keys {
+		pairs = append(pairs, key, node.Pairs[key])
+	}
+
+	args, err := u.values(pairs)
+
+	return fmt.Sprintf("rt.MakeMap(%s)", args), false, err
+}
+
+// assign transpiles an assignment. The value is evaluated
+// first, as it is in the virtual machine.
+func (u *unit) assign(node *ast.AssignExpression) (string, bool, error) {
+	val, err := u.value(node.Value)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch name := node.Name.(type) {
+	case *ast.Identifier:
+		return fmt.Sprintf("rt.StoreName(%q, %s)", name.Value, val), false, nil
+
+	case *ast.IndexExpression:
+		args, err := u.values([]ast.Expression{name.Collection, name.Index})
+		return fmt.Sprintf("rt.StoreField(%s, %s)", val, args), false, err
+
+	case *ast.DotExpression:
+		left, err := u.value(name.Left)
+		if err != nil {
+			return "", false, err
+		}
+
+		field, err := u.field(name.Right)
+		return fmt.Sprintf("rt.StoreField(%s, %s, %s)", val, left, field), false, err
+	}
+
+	return "", false, fmt.Errorf("transpile: can only assign to identifiers and field accessors")
+}
+
+// infixes maps each infix operator to its instruction
+var infixes = map[string]byte{
+	"+":  bytecode.BinaryAdd,
+	"-":  bytecode.BinarySubtract,
+	"*":  bytecode.BinaryMultiply,
+	"/":  bytecode.BinaryDivide,
+	"**": bytecode.BinaryExponent,
+	"//": bytecode.BinaryFloorDiv,
+	"%":  bytecode.BinaryFloorDiv,
+	"||": bytecode.BinaryOr,
+	"&&": bytecode.BinaryAnd,
+	"|":  bytecode.BinaryBitOr,
+	"&":  bytecode.BinaryBitAnd,
+	"==": bytecode.BinaryEquals,
+	"!=": bytecode.BinaryNotEqual,
+	"<":  bytecode.BinaryLessThan,
+	">":  bytecode.BinaryMoreThan,
+	"<=": bytecode.BinaryLessEq,
+	">=": bytecode.BinaryMoreEq,
+}
+
+// prefixes maps each prefix operator to its instruction
+var prefixes = map[string]byte{
+	"+": bytecode.UnaryNoOp,
+	"-": bytecode.UnaryNegate,
+	"!": bytecode.UnaryInvert,
+}
+
+func (u *unit) infix(node *ast.InfixExpression) (string, bool, error) {
+	op, ok := infixes[node.Operator]
+	if !ok {
+		return "", false, fmt.Errorf("transpile: operator %s not yet implemented", node.Operator)
+	}
+
+	args, err := u.values([]ast.Expression{node.Left, node.Right})
+
+	return fmt.Sprintf("rt.Binary(%s, %s)", u.opcode(op), args), false, err
+}
+
+func (u *unit) prefix(node *ast.PrefixExpression) (string, bool, error) {
+	op, ok := prefixes[node.Operator]
+	if !ok {
+		return "", false, fmt.Errorf("transpile: operator %s not yet implemented", node.Operator)
+	}
+
+	right, err := u.value(node.Right)
+
+	return fmt.Sprintf("rt.Unary(%s, %s)", u.opcode(op), right), false, err
+}
+
+// ifExpression transpiles an if expression whose value is
+// used, to a closure. Its value is the top of the stack
+// after the branch is run.
+func (u *unit) ifExpression(node *ast.IfExpression) (string, bool, error) {
+	outer := u.body
+	u.body = bytes.Buffer{}
+	u.closures++
+
+	defer func() {
+		u.body = outer
+		u.closures--
+	}()
+
+	if err := u.ifStatement(node); err != nil {
+		return "", false, err
+	}
+
+	return fmt.Sprintf("func() object.Object {\n%sreturn top\n}()", u.body.String()), true, nil
+}
+
+// call returns the pattern of a function call, in the format
+// "print $ and $", and its arguments
+func (u *unit) call(pattern []ast.Expression) (string, string, error) {
+	var (
+		words []string
+		args  []ast.Expression
+	)
+
+	for _, item := range pattern {
+		if id, ok := item.(*ast.Identifier); ok {
+			words = append(words, id.Value)
+		} else {
+			words = append(words, "$")
+			args = append(args, item)
+		}
+	}
+
+	values, err := u.values(args)
+
+	return strings.Join(words, " "), values, err
+}
+
+func (u *unit) emission(node *ast.EmissionExpression) (string, bool, error) {
+	var code bytes.Buffer
+
+	fmt.Fprintln(&code, "func() object.Object {\ne := rt.Emission()")
+
+	for _, item := range node.Items {
+		if !item.IsInstruction {
+			val, err := u.value(item.Exp)
+			if err != nil {
+				return "", false, err
+			}
+
+			fmt.Fprintf(&code, "e.Push(%s)\n", val)
+			continue
+		}
+
+		op, ok := instruction(item.Instruction)
+		if !ok {
+			return "", false, fmt.Errorf("transpile: unknown instruction %s", item.Instruction)
+		}
+
+		if !native.CanEmit(op) {
+			return "", false, fmt.Errorf("transpile: %s can't be emitted in native code", item.Instruction)
+		}
+
+		fmt.Fprintf(&code, "e.Do(%s, %d)\n", u.opcode(op), item.Argument)
+	}
+
+	fmt.Fprint(&code, "return e.Value()\n}()")
+
+	return code.String(), true, nil
+}
+
+func (u *unit) block(node *ast.BlockLiteral) (string, bool, error) {
+	// Every block is equal to every other, so the compiler
+	// only keeps the first block in each unit, and the others
+	// are the same as it
+	if name, ok := u.constants["block"]; ok {
+		return name, false, nil
+	}
+
+	body, err := u.t.function(node.Body)
+	if err != nil {
+		return "", false, err
+	}
+
+	params := []string{body}
+	for _, param := range node.Params {
+		params = append(params, strconv.Quote(param.Token().Literal))
+	}
+
+	name := u.constant(fmt.Sprintf("native.NewBlock(%s)", strings.Join(params, ", ")))
+	u.constants["block"] = name
+
+	return name, false, nil
+}
+
+// opcode returns the Go name of an instruction's opcode
+func (u *unit) opcode(op byte) string {
+	u.t.usesBytecode = true
+
+	return "bytecode." + opcodeNames[op]
+}
+
+// opcodeNames are the Go names of the opcodes which the
+// native runtime can run
+var opcodeNames = map[byte]string{
+	bytecode.Pop:            "Pop",
+	bytecode.Dup:            "Dup",
+	bytecode.LoadField:      "LoadField",
+	bytecode.StoreField:     "StoreField",
+	bytecode.UnaryInvert:    "UnaryInvert",
+	bytecode.UnaryNegate:    "UnaryNegate",
+	bytecode.UnaryNoOp:      "UnaryNoOp",
+	bytecode.BinaryAdd:      "BinaryAdd",
+	bytecode.BinarySubtract: "BinarySubtract",
+	bytecode.BinaryMultiply: "BinaryMultiply",
+	bytecode.BinaryDivide:   "BinaryDivide",
+	bytecode.BinaryExponent: "BinaryExponent",
+	bytecode.BinaryFloorDiv: "BinaryFloorDiv",
+	bytecode.BinaryMod:      "BinaryMod",
+	bytecode.BinaryOr:       "BinaryOr",
+	bytecode.BinaryAnd:      "BinaryAnd",
+	bytecode.BinaryBitOr:    "BinaryBitOr",
+	bytecode.BinaryBitAnd:   "BinaryBitAnd",
+	bytecode.BinaryEquals:   "BinaryEquals",
+	bytecode.BinaryNotEqual: "BinaryNotEqual",
+	bytecode.BinaryLessThan: "BinaryLessThan",
+	bytecode.BinaryMoreThan: "BinaryMoreThan",
+	bytecode.BinaryLessEq:   "BinaryLessEq",
+	bytecode.BinaryMoreEq:   "BinaryMoreEq",
+	bytecode.CallFn:         "CallFn",
+	bytecode.DoBlock:        "DoBlock",
+	bytecode.Print:          "Print",
+	bytecode.Println:        "Println",
+	bytecode.Length:         "Length",
+	bytecode.Exit:           "Exit",
+	bytecode.MakeArray:      "MakeArray",
+	bytecode.MakeTuple:      "MakeTuple",
+	bytecode.MakeMap:        "MakeMap",
+}
+
+// instruction finds an instruction's opcode by its name
+func instruction(name string) (byte, bool) {
+	for op, data := range bytecode.Instructions {
+		if data.Name == name {
+			return op, true
+		}
+	}
+
+	return 0, false
+}
+
+// patternText returns a function's pattern as it's defined,
+// such as "add $a to $b", and its shape, such as "add $ to
+// $", which two patterns share if they match the same calls
+func patternText(pattern []ast.Expression) (string, string) {
+	var words, shape []string
+
+	for _, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words = append(words, "$"+param.Name)
+			shape = append(shape, "$")
+		} else if id, ok := item.(*ast.Identifier); ok {
+			words = append(words, id.Value)
+			shape = append(shape, id.Value)
+		}
+	}
+
+	return strings.Join(words, " "), strings.Join(shape, " ")
+}
+
+func position(n ast.Node) string {
+	pos := n.Token().Start
+	return fmt.Sprintf("%s:%d", pos.File, pos.Line)
+}
@@ -0,0 +1,115 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/object"
+)
+
+func byteAssert(f *Frame, i bytecode.Instruction) {
+	top := f.stack.pop()
+
+	if !object.IsTruthy(top) {
+		f.vm.Error = Errf("expected a truthy value, got %s", ErrAssertion, inspect(top))
+	}
+}
+
+func byteAssertEqual(f *Frame, i bytecode.Instruction) {
+	expected, actual := f.stack.pop(), f.stack.pop()
+
+	if actual.Equals(expected) {
+		return
+	}
+
+	f.vm.Error = Err(describeDifference(expected, actual), ErrAssertion)
+}
+
+func byteAssertNotEqual(f *Frame, i bytecode.Instruction) {
+	unexpected, actual := f.stack.pop(), f.stack.pop()
+
+	if actual.Equals(unexpected) {
+		f.vm.Error = Errf("expected a value other than %s", ErrAssertion, inspect(actual))
+	}
+}
+
+func byteFail(f *Frame, i bytecode.Instruction) {
+	f.vm.Error = Err(f.stack.pop().String(), ErrAssertion)
+}
+
+// inspect returns a string representation of an object,
+// with strings and chars quoted so that their types and
+// whitespace are visible.
+func inspect(obj object.Object) string {
+	switch o := obj.(type) {
+	case *object.String:
+		return strconv.Quote(o.Value)
+	case *object.Char:
+		return strconv.QuoteRune(o.Value)
+	default:
+		return obj.String()
+	}
+}
+
+// describeDifference explains why two values aren't equal.
+// Multi-line strings are compared line by line.
+func describeDifference(expected, actual object.Object) string {
+	var (
+		estr, eok = expected.(*object.String)
+		astr, aok = actual.(*object.String)
+	)
+
+	if eok && aok && (strings.Contains(estr.Value, "\n") || strings.Contains(astr.Value, "\n")) {
+		return "strings are not equal (- expected, + actual)\n" + diffLines(
+			strings.Split(estr.Value, "\n"),
+			strings.Split(astr.Value, "\n"),
+		)
+	}
+
+	return fmt.Sprintf("values are not equal\n  expected: %s\n    actual: %s", inspect(expected), inspect(actual))
+}
+
+// diffLines returns a line-based diff which turns a into
+// b, found using the longest common subsequence.
+func diffLines(a, b []string) string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var (
+		lines []string
+		i, j  int
+	)
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "    "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "  + "+b[j])
+			j++
+		default:
+			lines = append(lines, "  - "+a[i])
+			i++
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
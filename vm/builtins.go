@@ -9,11 +9,11 @@ import (
 )
 
 func bytePrint(f *Frame, i bytecode.Instruction) {
-	fmt.Print(f.stack.pop())
+	fmt.Fprint(f.vm.Out, f.stack.pop())
 }
 
 func bytePrintln(f *Frame, i bytecode.Instruction) {
-	fmt.Println(f.stack.pop())
+	fmt.Fprintln(f.vm.Out, f.stack.pop())
 }
 
 func byteLength(f *Frame, i bytecode.Instruction) {
@@ -27,3 +27,13 @@ func byteLength(f *Frame, i bytecode.Instruction) {
 		f.vm.Error = Errf("cannot get the length of type %s", ErrWrongType, top.Type())
 	}
 }
+
+func byteExit(f *Frame, i bytecode.Instruction) {
+	top := f.stack.pop()
+
+	if code, ok := top.(object.Numeric); ok {
+		f.vm.Halt(int(code.Float64()))
+	} else {
+		f.vm.Error = Errf("cannot exit with a status of type %s", ErrWrongType, top.Type())
+	}
+}
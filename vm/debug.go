@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/Zac-Garby/pluto/ast"
+	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/compiler"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
+)
+
+// These methods let a debugger inspect a frame while it's
+// stopped in VirtualMachine.StatementHook.
+
+// Previous returns the frame which called this one, or
+// nil if it's the outermost frame.
+func (f *Frame) Previous() *Frame {
+	return f.previous
+}
+
+// Name returns the pattern of the function the frame is
+// running, "block" for a block, or "main".
+func (f *Frame) Name() string {
+	return f.name
+}
+
+// Pos returns the position of the statement being run
+func (f *Frame) Pos() token.Position {
+	return f.pos
+}
+
+// First checks if the statement being run is the first
+// one the frame has run, i.e. if the frame has just been
+// called.
+func (f *Frame) First() bool {
+	return f.first
+}
+
+// Instruction returns the instruction about to be run, and
+// its index in the frame's code.
+func (f *Frame) Instruction() (bytecode.Instruction, int) {
+	if f.offset >= len(f.code) {
+		return bytecode.Instruction{}, f.offset
+	}
+
+	return f.code[f.offset], f.offset
+}
+
+// Stack returns the objects on the frame's stack, from the
+// bottom up.
+func (f *Frame) Stack() []object.Object {
+	objects := make([]object.Object, len(f.stack.objects))
+	copy(objects, f.stack.objects)
+
+	return objects
+}
+
+// Depth returns the number of frames below this one
+func (f *Frame) Depth() int {
+	depth := 0
+
+	for p := f.previous; p != nil; p = p.previous {
+		depth++
+	}
+
+	return depth
+}
+
+// Locals returns the frame's store
+func (f *Frame) Locals() *store.Store {
+	return f.locals
+}
+
+// VM returns the virtual machine running the frame
+func (f *Frame) VM() *VirtualMachine {
+	return f.vm
+}
+
+// Eval evaluates some source code in the frame's scope,
+// returning the value of the last expression. It uses a
+// separate virtual machine, so the statement hook isn't
+// called while it runs.
+func (f *Frame) Eval(src string) (object.Object, error) {
+	parse := parser.New(src, "<eval>")
+	prog := parse.Parse()
+
+	if len(parse.Errors) > 0 {
+		return nil, parse.Errors[0]
+	}
+
+	cmp := compiler.New()
+	if err := cmp.CompileProgram(prog); err != nil {
+		return nil, err
+	}
+
+	code, err := cmp.Code()
+	if err != nil {
+		return nil, err
+	}
+
+	// Like a function call, the evaluated code has its own
+	// name and pattern tables
+	locals := f.locals
+	names, patterns := locals.Names, locals.Patterns
+	defer func() {
+		locals.Names, locals.Patterns = names, patterns
+	}()
+
+	locals.Names = cmp.Names
+	locals.Patterns = cmp.Patterns
+	locals.FunctionStore.Define(cmp.Functions...)
+
+	machine := New()
+	machine.Out = f.vm.Out
+	machine.Run(code, locals, cmp.Constants, false)
+
+	if machine.Error != nil {
+		return nil, machine.Error
+	}
+
+	return machine.ExtractValue(), nil
+}
+
+// patternName returns a function's pattern as a string,
+// such as "print $obj".
+func patternName(pattern []ast.Expression) string {
+	words := make([]string, len(pattern))
+
+	for i, item := range pattern {
+		if param, ok := item.(*ast.Parameter); ok {
+			words[i] = "$" + param.Name
+		} else {
+			words[i] = item.Token().Literal
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -51,9 +51,14 @@ func init() {
 		bytecode.Return:     byteReturn,
 		bytecode.DoBlock:    byteDoBlock,
 
-		bytecode.Print:   bytePrint,
-		bytecode.Println: bytePrintln,
-		bytecode.Length:  byteLength,
+		bytecode.Print:          bytePrint,
+		bytecode.Println:        bytePrintln,
+		bytecode.Length:         byteLength,
+		bytecode.Exit:           byteExit,
+		bytecode.Assert:         byteAssert,
+		bytecode.AssertEqual:    byteAssertEqual,
+		bytecode.AssertNotEqual: byteAssertNotEqual,
+		bytecode.Fail:           byteFail,
 
 		bytecode.Jump:        byteJump,
 		bytecode.JumpIfTrue:  byteJumpIfTrue,
@@ -454,11 +459,21 @@ func byteCall(f *Frame, i bytecode.Instruction) {
 
 	locals := f.locals
 
+	// The function shares the caller's store, but has its
+	// own name and pattern tables, so the caller's ones are
+	// put back after the call
+	names, patterns := locals.Names, locals.Patterns
+	defer func() {
+		locals.Names, locals.Patterns = names, patterns
+	}()
+
 	locals.Names = fn.Names
 	locals.Patterns = fn.Patterns
 
-	for _, item := range fn.Pattern {
-		if param, ok := item.(*ast.Parameter); ok {
+	// The arguments were pushed in order, so the last
+	// parameter is at the top of the stack
+	for i := len(fn.Pattern) - 1; i >= 0; i-- {
+		if param, ok := fn.Pattern[i].(*ast.Parameter); ok {
 			// Found a parameter
 
 			locals.Define(param.Name, f.stack.pop(), true)
@@ -467,6 +482,7 @@ func byteCall(f *Frame, i bytecode.Instruction) {
 
 	// Create the function's frame
 	fnFrame := &Frame{
+		name:      patternName(fn.Pattern),
 		code:      fn.Body,
 		constants: fn.Constants,
 		locals:    locals,
@@ -502,16 +518,22 @@ func byteDoBlock(f *Frame, i bytecode.Instruction) {
 
 	locals := f.locals
 
+	names, patterns := locals.Names, locals.Patterns
+	defer func() {
+		locals.Names, locals.Patterns = names, patterns
+	}()
+
 	locals.Names = block.Names
 	locals.Patterns = block.Patterns
 
-	for _, item := range block.Params {
-		name := item.Token().Literal
+	for i := len(block.Params) - 1; i >= 0; i-- {
+		name := block.Params[i].Token().Literal
 
 		locals.Define(name, f.stack.pop(), true)
 	}
 
 	blockFrame := &Frame{
+		name:      "block",
 		code:      block.Body,
 		constants: block.Constants,
 		locals:    locals,
@@ -2,6 +2,8 @@ package vm
 
 import (
 	"fmt"
+
+	"github.com/Zac-Garby/pluto/token"
 )
 
 // ErrType is a runtime error type
@@ -33,12 +35,24 @@ const (
 	// ErrSyntax is thrown for any syntax errors which couldn't be
 	// found in the parsing stage
 	ErrSyntax = "Syntax"
+
+	// ErrInterrupted is thrown when the program is stopped by
+	// VirtualMachine.Interrupt, e.g. after a Ctrl-C
+	ErrInterrupted = "Interrupted"
+
+	// ErrAssertion is thrown when an assertion fails, such as
+	// the ASSERT_EQUAL instruction with two different values
+	ErrAssertion = "Assertion"
 )
 
 // Error is a runtime error thrown in the virtual machine
 type Error struct {
 	Type    ErrType
 	Message string
+
+	// Trace holds the positions of the statements being
+	// executed when the error was thrown, innermost first
+	Trace []token.Position
 }
 
 // Err creates a new runtime error with the given message and type
@@ -58,5 +72,10 @@ func Errf(msg string, t ErrType, format ...interface{}) *Error {
 }
 
 func (e *Error) Error() string {
+	if len(e.Trace) > 0 {
+		pos := e.Trace[0]
+		return fmt.Sprintf("%s:%d: %s: %s", pos.File, pos.Line, e.Type, e.Message)
+	}
+
 	return fmt.Sprintf("%s: %s", e.Type, e.Message)
 }
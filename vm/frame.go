@@ -1,9 +1,12 @@
 package vm
 
 import (
+	"sync/atomic"
+
 	"github.com/Zac-Garby/pluto/bytecode"
 	"github.com/Zac-Garby/pluto/object"
 	"github.com/Zac-Garby/pluto/store"
+	"github.com/Zac-Garby/pluto/token"
 )
 
 // Frame is a virtual machine frame. A frame is
@@ -15,6 +18,9 @@ type Frame struct {
 	code     bytecode.Code   // the parsed bytecode
 	offset   int             // the current instruction index
 	vm       *VirtualMachine // the frame's virtual machine
+	name     string          // the function or block being run
+	pos      token.Position  // the current statement's position
+	first    bool            // whether pos is the frame's first statement
 
 	locals        *store.Store    // the local namespace
 	stack         stack           // the object stack
@@ -30,9 +36,34 @@ func (f *Frame) execute() {
 
 		instruction := f.code[f.offset]
 
+		if pos := instruction.Pos; pos.Line > 0 && pos != f.pos {
+			f.first = f.pos.Line == 0
+			f.pos = pos
+
+			if cov := f.vm.Coverage; cov != nil {
+				f.cover(cov)
+			}
+
+			if hook := f.vm.StatementHook; hook != nil {
+				hook(f)
+			}
+		}
+
 		f.doInstruction(instruction)
 
+		if atomic.LoadInt32(&f.vm.interrupted) != 0 && f.vm.Error == nil {
+			f.vm.Error = Err("execution interrupted", ErrInterrupted)
+		}
+
 		if f.vm.Error != nil {
+			if pos := instruction.Pos; pos.Line > 0 {
+				f.vm.Error.Trace = append(f.vm.Error.Trace, pos)
+			}
+
+			break
+		}
+
+		if f.vm.halted {
 			break
 		}
 
@@ -40,6 +71,17 @@ func (f *Frame) execute() {
 	}
 }
 
+// cover counts the current statement in a coverage map
+func (f *Frame) cover(cov map[string]map[int]int) {
+	lines, ok := cov[f.pos.File]
+	if !ok {
+		lines = make(map[int]int)
+		cov[f.pos.File] = lines
+	}
+
+	lines[f.pos.Line]++
+}
+
 func (f *Frame) doInstruction(i bytecode.Instruction) {
 	e, ok := effectors[i.Code]
 	if !ok {
@@ -47,6 +89,10 @@ func (f *Frame) doInstruction(i bytecode.Instruction) {
 		return
 	}
 
+	if hook := f.vm.InstructionHook; hook != nil {
+		hook(f, i)
+	}
+
 	e(f, i)
 }
 
@@ -1,20 +1,28 @@
 package vm
 
 import (
-	"io/ioutil"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/Zac-Garby/pluto/ast"
-	"github.com/Zac-Garby/pluto/bytecode"
 	"github.com/Zac-Garby/pluto/compiler"
-	"github.com/Zac-Garby/pluto/dir"
+	"github.com/Zac-Garby/pluto/literate"
+	"github.com/Zac-Garby/pluto/module"
 	"github.com/Zac-Garby/pluto/parser"
+	"github.com/Zac-Garby/pluto/pkg"
 	"github.com/Zac-Garby/pluto/store"
 )
 
 // Use imports the sources found by the glob src into
 // the frame
 func (f *Frame) Use(src string) {
-	sources, err := dir.LocateAnySources(src)
+	if f.vm.Packages != nil {
+		f.usePackage(src)
+		return
+	}
+
+	sources, err := pkg.LocateSourcesFrom(f.dir(), src)
 	if err != nil {
 		f.vm.Error = Err(err.Error(), ErrUnknown)
 		return
@@ -23,7 +31,7 @@ func (f *Frame) Use(src string) {
 	mergedTrees := ast.Program{}
 
 	for _, source := range sources {
-		src, err := ioutil.ReadFile(source)
+		str, err := literate.ReadFile(source)
 		if err != nil {
 			f.vm.Error = Err(err.Error(), ErrUnknown)
 
@@ -31,7 +39,6 @@ func (f *Frame) Use(src string) {
 		}
 
 		var (
-			str   = string(src)
 			parse = parser.New(str, source)
 			prog  = parse.Parse()
 		)
@@ -54,29 +61,74 @@ func (f *Frame) Use(src string) {
 		return
 	}
 
-	code, err := bytecode.Read(cmp.Bytes)
+	code, err := cmp.Code()
 	if err != nil {
 		f.vm.Error = Err(err.Error(), ErrUnknown)
 
 		return
 	}
 
-	if err != nil {
-		f.vm.Error = Err(err.Error(), ErrUnknown)
+	f.importModule(src, &module.Module{
+		Code:      code,
+		Constants: cmp.Constants,
+		Functions: cmp.Functions,
+		Names:     cmp.Names,
+		Patterns:  cmp.Patterns,
+	})
+}
+
+// dir returns the directory of the file the frame is
+// running, which uses are found from, or "." if the code
+// isn't from a file. Before the first statement, such as
+// when the prelude is used, it's the first statement's file.
+func (f *Frame) dir() string {
+	file := f.pos.File
+
+	for _, i := range f.code {
+		if file != "" {
+			break
+		}
 
+		if i.Pos.Line > 0 {
+			file = i.Pos.File
+		}
+	}
+
+	if file == "" || strings.HasPrefix(file, "<") {
+		return "."
+	}
+
+	return filepath.Dir(file)
+}
+
+// usePackage imports a package compiled in advance, from
+// the machine's Packages
+func (f *Frame) usePackage(src string) {
+	m, ok := f.vm.Packages[src]
+	if !ok {
+		f.vm.Error = Err(fmt.Sprintf("use: %s isn't bundled", src), ErrUnknown)
 		return
 	}
 
+	f.importModule(src, m)
+}
+
+// importModule runs a package's code in its own machine,
+// and imports what it defines into the frame
+func (f *Frame) importModule(src string, m *module.Module) {
 	store := &store.Store{
-		Names:    cmp.Names,
-		Patterns: cmp.Patterns,
+		Names:    m.Names,
+		Patterns: m.Patterns,
 		FunctionStore: &store.FunctionStore{
-			Functions: cmp.Functions,
+			Functions: m.Functions,
 		},
 	}
 
 	machine := New()
-	machine.Run(code, store, cmp.Constants, false)
+	machine.Out = f.vm.Out
+	machine.Coverage = f.vm.Coverage
+	machine.Packages = f.vm.Packages
+	machine.Run(m.Code, store, m.Constants, false)
 
 	f.locals.ImportModule(store, src)
 }
@@ -1,7 +1,12 @@
 package vm
 
 import (
+	"io"
+	"os"
+	"sync/atomic"
+
 	"github.com/Zac-Garby/pluto/bytecode"
+	"github.com/Zac-Garby/pluto/module"
 	"github.com/Zac-Garby/pluto/object"
 	"github.com/Zac-Garby/pluto/store"
 )
@@ -14,7 +19,45 @@ type VirtualMachine struct {
 	frames      []*Frame
 	frame       *Frame
 	returnValue object.Object
+	halted      bool
+	interrupted int32
 	Error       *Error
+
+	// ExitCode is the status passed to the
+	// EXIT instruction, if it was executed
+	ExitCode int
+
+	// Out is where PRINT and PRINTLN write to.
+	// It's os.Stdout unless it's changed.
+	Out io.Writer
+
+	// Coverage, if it's set, counts how many
+	// times the statements on each line of each
+	// file are run. It's shared with the machines
+	// which run imported modules.
+	Coverage map[string]map[int]int
+
+	// StatementHook, if it's set, is called
+	// before each statement is executed, with
+	// the frame it's in. A debugger can stop
+	// the program by not returning.
+	StatementHook func(*Frame)
+
+	// CallHook and ReturnHook, if they're set,
+	// are called before a frame starts and after
+	// it finishes, including the main frame. A
+	// profiler can use them to time functions.
+	CallHook, ReturnHook func(*Frame)
+
+	// InstructionHook, if it's set, is called
+	// before each instruction is executed
+	InstructionHook func(*Frame, bytecode.Instruction)
+
+	// Packages, if it's set, maps the strings given to
+	// 'use' to the packages' compiled code, which is used
+	// instead of their sources. A bundled program runs with
+	// every package it uses here.
+	Packages map[string]*module.Module
 }
 
 // New returns a new virtual machine
@@ -23,6 +66,7 @@ func New() *VirtualMachine {
 		frames:      make([]*Frame, 0),
 		returnValue: nil,
 		Error:       nil,
+		Out:         os.Stdout,
 	}
 }
 
@@ -30,8 +74,12 @@ func New() *VirtualMachine {
 func (vm *VirtualMachine) Run(code bytecode.Code, locals *store.Store, constants []object.Object, usePrelude bool) {
 	frame := vm.makeFrame(code, store.New(), locals, constants)
 
+	// If the prelude can't be used, the program isn't run,
+	// so the error isn't at any of its statements
 	if usePrelude {
-		frame.Use("std/prelude/*.pluto")
+		if frame.Use(module.Prelude); vm.Error != nil {
+			return
+		}
 	}
 
 	vm.pushFrame(frame)
@@ -46,6 +94,7 @@ func (vm *VirtualMachine) RunDefault(code bytecode.Code, constants []object.Obje
 
 func (vm *VirtualMachine) makeFrame(code bytecode.Code, args, locals *store.Store, constants []object.Object) *Frame {
 	frame := &Frame{
+		name:      "main",
 		code:      code,
 		locals:    locals,
 		offset:    0,
@@ -74,7 +123,50 @@ func (vm *VirtualMachine) popFrame() *Frame {
 
 func (vm *VirtualMachine) runFrame(frame *Frame) {
 	vm.pushFrame(frame)
+
+	if vm.CallHook != nil {
+		vm.CallHook(frame)
+	}
+
 	vm.popFrame().execute()
+
+	if vm.ReturnHook != nil {
+		vm.ReturnHook(frame)
+	}
+}
+
+// Halt stops the virtual machine after the
+// current instruction, setting its exit code
+func (vm *VirtualMachine) Halt(code int) {
+	vm.ExitCode = code
+	vm.halted = true
+}
+
+// Interrupt stops the virtual machine after the
+// current instruction with an ErrInterrupted error.
+// Unlike the other methods, it is safe to call from
+// a different goroutine to the one running the code.
+func (vm *VirtualMachine) Interrupt() {
+	atomic.StoreInt32(&vm.interrupted, 1)
+}
+
+// Reset clears what's left from the last code the
+// machine ran, so it can run more: its frames, its error
+// and exit code, and whether it's been halted or
+// interrupted. Out, Coverage and the hooks are kept.
+func (vm *VirtualMachine) Reset() {
+	vm.frames = vm.frames[:0]
+	vm.returnValue = nil
+	vm.halted = false
+	atomic.StoreInt32(&vm.interrupted, 0)
+	vm.Error = nil
+	vm.ExitCode = 0
+}
+
+// Halted checks if the virtual machine has
+// been stopped by Halt
+func (vm *VirtualMachine) Halted() bool {
+	return vm.halted
 }
 
 // ExtractValue returns the top value from the top frame
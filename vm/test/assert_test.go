@@ -0,0 +1,45 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/vm"
+)
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		src, message string
+	}{
+		{"<true, ASSERT>", ""},
+		{"<false, ASSERT>", "expected a truthy value, got false"},
+		{"<1, 1, ASSERT_EQUAL>", ""},
+		{"<1, 2, ASSERT_EQUAL>", "values are not equal\n  expected: 2\n    actual: 1"},
+		{`<"a", 'a', ASSERT_EQUAL>`, "values are not equal\n  expected: 'a'\n    actual: \"a\""},
+		{
+			"<\"one\ntwo\nthree\", \"one\n2\nthree\", ASSERT_EQUAL>",
+			"strings are not equal (- expected, + actual)\n    one\n  - 2\n  + two\n    three",
+		},
+		{
+			"<\"a\nb\", \"a\", ASSERT_EQUAL>",
+			"strings are not equal (- expected, + actual)\n    a\n  + b",
+		},
+		{"<1, 2, ASSERT_NOT_EQUAL>", ""},
+		{"<1, 1, ASSERT_NOT_EQUAL>", "expected a value other than 1"},
+		{`<"it broke", FAIL>`, "it broke"},
+	}
+
+	for _, test := range tests {
+		machine, _ := run(t, test.src+"\n", "assert.pluto")
+		err := machine.Error
+
+		switch {
+		case test.message == "" && err != nil:
+			t.Errorf("%q: expected no error, got %s", test.src, err)
+		case test.message == "":
+		case err == nil:
+			t.Errorf("%q: expected an assertion error", test.src)
+		case err.Type != ErrAssertion || err.Message != test.message:
+			t.Errorf("%q: expected the assertion error:\n%s\ngot the %s error:\n%s", test.src, test.message, err.Type, err.Message)
+		}
+	}
+}
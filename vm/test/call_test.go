@@ -0,0 +1,48 @@
+package test
+
+import "testing"
+
+func TestCalls(t *testing.T) {
+	tests := []struct {
+		name, src, result string
+	}{
+		{
+			"arguments are bound in order",
+			"def subtract $a from $b { return $b - $a }\nresult = subtract 3 from 10\n",
+			"7",
+		},
+		{
+			"block parameters are bound in order",
+			"b = { |x, y| -> x - y }\nresult = <10, 3, b, DO_BLOCK>\n",
+			"7",
+		},
+		{
+			"a call with a call in its arguments calls the outer function",
+			"def double $n { return $n * 2 }\ndef inc $n { return $n + 1 }\nresult = double (inc 3)\n",
+			"8",
+		},
+		{
+			"the caller's names are kept after a call",
+			"def f { y = 2; z = 3 }\na = 10\n\\f\nresult = a\n",
+			"10",
+		},
+		{
+			"the caller's names are kept after a block",
+			"b = { |y| -> z = y }\na = 10\n<1, b, DO_BLOCK>\nresult = a\n",
+			"10",
+		},
+	}
+
+	for _, test := range tests {
+		machine, s := run(t, test.src, "calls.pluto")
+
+		if machine.Error != nil {
+			t.Errorf("%s: %s", test.name, machine.Error)
+			continue
+		}
+
+		if result := s.GetName("result"); result == nil || result.String() != test.result {
+			t.Errorf("%s: expected %s, got %v", test.name, test.result, result)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/object"
+	"github.com/Zac-Garby/pluto/store"
+	. "github.com/Zac-Garby/pluto/vm"
+)
+
+func TestExit(t *testing.T) {
+	machine, s := run(t, "result = 1\n<3, EXIT>\nresult = 2\n", "exit.pluto")
+
+	if machine.Error != nil {
+		t.Fatal(machine.Error)
+	}
+
+	if !machine.Halted() || machine.ExitCode != 3 {
+		t.Errorf("expected the machine to halt with the status 3, got %t and %d", machine.Halted(), machine.ExitCode)
+	}
+
+	if result := s.GetName("result").String(); result != "1" {
+		t.Errorf("expected nothing to run after EXIT, got result = %s", result)
+	}
+
+	// A halt inside a function stops the whole program
+	machine, s = run(t, "def stop { <0, EXIT> }\n\\stop\nresult = 2\n", "exit.pluto")
+
+	if !machine.Halted() || machine.ExitCode != 0 || s.GetName("result") != nil {
+		t.Errorf("expected the machine to halt with the status 0 before the assignment, got %t, %d and %v", machine.Halted(), machine.ExitCode, s.GetName("result"))
+	}
+
+	if machine, _ = run(t, "<\"bad\", EXIT>\n", "exit.pluto"); machine.Error == nil {
+		t.Error("expected an error exiting with a string")
+	}
+}
+
+// A program's arguments are the args array, defined in its
+// store before it runs
+func TestArgs(t *testing.T) {
+	m, err := module.Compile("result = args[1] + \" \" + args[0]\ncount = <args, LENGTH>\n", "args.pluto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.New()
+	s.Define("args", &object.Array{Value: []object.Object{
+		&object.String{Value: "world"},
+		&object.String{Value: "hello"},
+	}}, false)
+
+	s.Names = m.Names
+	s.Patterns = m.Patterns
+	s.FunctionStore.Define(m.Functions...)
+
+	machine := New()
+	machine.Run(m.Code, s, m.Constants, false)
+
+	if machine.Error != nil {
+		t.Fatal(machine.Error)
+	}
+
+	if result := s.GetName("result").String(); result != "hello world" {
+		t.Errorf("expected the result hello world, got %s", result)
+	}
+
+	if count := s.GetName("count").String(); count != "2" {
+		t.Errorf("expected 2 arguments, got %s", count)
+	}
+}
@@ -0,0 +1,28 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/vm"
+)
+
+func TestMissingPrelude(t *testing.T) {
+	t.Setenv("PLUTO", t.TempDir())
+
+	var (
+		machine = New()
+		out     bytes.Buffer
+	)
+
+	machine.Out = &out
+	runWith(t, machine, "x = 1\n<x, PRINT_LINE>\n", "a.pluto", true)
+
+	if machine.Error == nil {
+		t.Fatal("expected an error using the missing prelude")
+	}
+
+	if len(machine.Error.Trace) != 0 || out.Len() != 0 {
+		t.Errorf("expected the program not to run, but it printed %q with the trace %v", out.String(), machine.Error.Trace)
+	}
+}
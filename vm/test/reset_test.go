@@ -0,0 +1,23 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/Zac-Garby/pluto/vm"
+)
+
+func TestReset(t *testing.T) {
+	machine := New()
+
+	if runWith(t, machine, "<\"broken\", FAIL>\n", "reset.pluto", false); machine.Error == nil {
+		t.Fatal("expected an error")
+	}
+
+	machine.Reset()
+
+	if _, s := runWith(t, machine, "result = 1 + 2\n", "reset.pluto", false); machine.Error != nil {
+		t.Errorf("expected no error after a reset, got %s", machine.Error)
+	} else if s.GetName("result").String() != "3" {
+		t.Errorf("expected the result 3, got %s", s.GetName("result"))
+	}
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// A program's uses are found from its own project, even if
+// it's run from somewhere else
+func TestUseFromOtherDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("PLUTO", home)
+
+	writeFiles(t, filepath.Join(home, "packages"), map[string]string{
+		"maths/maths.pluto":       "def answer { return 0 }\n",
+		"maths@1.0.0/maths.pluto": "def answer { return 42 }\n",
+	})
+
+	project := t.TempDir()
+	writeFiles(t, project, map[string]string{
+		"pluto.lock":    `{"packages": {"maths": {"version": "1.0.0", "checksum": ""}}}`,
+		"lib/add.pluto": "def add $a to $b { return $a + $b }\n",
+	})
+
+	src := `use "maths"
+use "./lib/add.pluto"
+
+result = add (\answer) to 0
+`
+
+	main := filepath.Join(project, "main.pluto")
+	if err := ioutil.WriteFile(main, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chdir(t, t.TempDir())
+
+	machine, s := run(t, src, main)
+	if machine.Error != nil {
+		t.Fatal(machine.Error)
+	}
+
+	if result := s.GetName("result"); result == nil || result.String() != "42" {
+		t.Errorf("expected the locked version of maths to be used, got %v", result)
+	}
+}
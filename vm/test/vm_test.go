@@ -0,0 +1,62 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Zac-Garby/pluto/module"
+	"github.com/Zac-Garby/pluto/store"
+	. "github.com/Zac-Garby/pluto/vm"
+)
+
+// run compiles and runs a program, without the prelude,
+// returning the machine and the store it ran in
+func run(t *testing.T, src, file string) (*VirtualMachine, *store.Store) {
+	return runWith(t, New(), src, file, false)
+}
+
+func runWith(t *testing.T, machine *VirtualMachine, src, file string, prelude bool) (*VirtualMachine, *store.Store) {
+	m, err := module.Compile(src, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.New()
+	s.Names = m.Names
+	s.Patterns = m.Patterns
+	s.FunctionStore.Define(m.Functions...)
+
+	machine.Run(m.Code, s, m.Constants, prelude)
+
+	return machine, s
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, text := range files {
+		path := filepath.Join(root, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// chdir changes the working directory until the test ends
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+}